@@ -1,7 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"abb_ia/internal/config"
 	"abb_ia/internal/controller"
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/headless"
 	"abb_ia/internal/logger"
 	"abb_ia/internal/mq"
 	"abb_ia/internal/ui"
@@ -14,7 +21,74 @@ func Execute() {
 	c := controller.NewConductor(d)
 	ui := ui.NewTUI(d)
 
+	c.ScheduleWatchChecks()
 	c.Run()
 	ui.Run()
 	logger.Info("Application finished")
 }
+
+// Build an audiobook from the IA item without the TUI. Returns the process exit code
+func ExecuteBuild(itemId string) int {
+	logger.Info("Application started in headless mode")
+
+	d := mq.NewDispatcher()
+	r := headless.NewRunner(d, itemId)
+	c := controller.NewConductor(d)
+
+	c.Run()
+	err := r.Run()
+	if err != nil {
+		logger.Error("Headless build failed: " + err.Error())
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+	logger.Info("Application finished")
+	return 0
+}
+
+// Check the watches for new items and build the new items of the auto build watches without the TUI.
+// The check is repeated every intervalHours if it's not zero. Returns the process exit code
+func ExecuteWatch(intervalHours int) int {
+	logger.Info("Application started in watch mode")
+
+	d := mq.NewDispatcher()
+	r := headless.NewRunner(d, "")
+	c := controller.NewConductor(d)
+
+	c.ScheduleWatchChecks()
+	c.Run()
+	failed := r.Watch(time.Duration(intervalHours) * time.Hour)
+	if failed > 0 {
+		logger.Error(fmt.Sprintf("Watch builds failed: %d", failed))
+		fmt.Printf("Error: %d build(s) failed\n", failed)
+		return 1
+	}
+	logger.Info("Application finished")
+	return 0
+}
+
+// Run a local fake archive.org serving the bundled (or given) fixtures. Returns the process exit code
+func ExecuteFakeIA(fixtureFile string) int {
+	items := fakeia.DefaultItems()
+	if fixtureFile != "" {
+		var err error
+		items, err = fakeia.LoadItems(fixtureFile)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			return 1
+		}
+	}
+	h, err := fakeia.NewHandler(items)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+	// restricted items are available with the configured IA credentials
+	h.SetCredentials(config.Instance().GetIaAccessKey(), config.Instance().GetIaSecretKey(), config.Instance().GetIaCookie())
+	fmt.Printf("Fake archive is listening on http://%s (%d items). Set the Archive URL in the settings to use it\n", fakeia.DefaultAddr, len(items))
+	if err := http.ListenAndServe(fakeia.DefaultAddr, h); err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+	return 0
+}
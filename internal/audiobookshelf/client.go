@@ -144,7 +144,7 @@ func (c *AudiobookshelfClient) UploadBook(ab *dto.Audiobook, libraryID string, f
 	// Open each file for upload
 	var filesList []*os.File
 	for _, part := range ab.Parts {
-		f, err := os.Open(part.M4BFile)
+		f, err := os.Open(part.OutputFile)
 		if err != nil {
 			return err
 		}
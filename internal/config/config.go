@@ -26,34 +26,57 @@ var (
 
 // Fields of this stuct should to be private but I have to make them public because yaml.Marshal/Unmarshal can't work with private fields
 type Config struct {
-	DefaultAuthor          string        `yaml:"DefaultAuthor"`
-	DefaultTitle           string        `yaml:"DefaultTitle"`
-	SortBy                 string        `yaml:"SortBy"`
-	SortOrder              string        `yaml:"SortOrder"`
-	RowsPerPage            int           `yaml:"RowsPerPage"`
-	LogFileName            string        `yaml:"LogFileName"`
-	OutputDir              string        `yaml:"Outputdir"`
-	CopyToOutputDir        bool          `yaml:"CopyToOutputDir"`
-	TmpDir                 string        `yaml:"TmpDir"`
-	LogLevel               string        `yaml:"LogLevel"`
-	UseMock                bool          `yaml:"UseMock"`
-	SaveMock               bool          `yaml:"SaveMock"`
-	ConcurrentDownloaders  int           `yaml:"ConcurrentDownloaders"`
-	ConcurrentEncoders     int           `yaml:"ConcurrentEncoders"`
-	ReEncodeFiles          bool          `yaml:"ReEncodeFiles"`
-	BasePortNumber         int           `yaml:"BasePortNumber"`
-	BitRateKbs             int           `yaml:"BitRateKbs"`
-	SampleRateHz           int           `yaml:"SampleRateHz"`
-	MaxFileSizeMb          int           `yaml:"MaxFileSizeMb"`
-	UploadToAudiobookshef  bool          `yaml:"UploadToAudiobookshelf"`
-	ScanAudiobookshef      bool          `yaml:"ScanAudiobookshelf"`
-	AudiobookshelfUrl      string        `yaml:"AudiobookshelfUrl"`
-	AudiobookshelfUser     string        `yaml:"AudiobookshelfUser"`
-	AudiobookshelfPassword string        `yaml:"AudiobookshelfPassword"`
-	AudiobookshelfLibrary  string        `yaml:"AudiobookshelfLibrary"`
-	ShortenTitles          bool          `yaml:"ShortenTitles"`
-	ShortenPairs           []ShortenPair `yaml:"ShortenPairs"`
-	Genres                 []string      `yaml:"Genres"`
+	DefaultAuthor            string        `yaml:"DefaultAuthor"`
+	DefaultTitle             string        `yaml:"DefaultTitle"`
+	SortBy                   string        `yaml:"SortBy"`
+	SortOrder                string        `yaml:"SortOrder"`
+	RowsPerPage              int           `yaml:"RowsPerPage"`
+	UseScrapeAPI             bool          `yaml:"UseScrapeAPI"`
+	IaBaseUrl                string        `yaml:"IaBaseUrl"`
+	IaAccessKey              string        `yaml:"IaAccessKey"`
+	IaSecretKey              string        `yaml:"IaSecretKey"`
+	IaCookie                 string        `yaml:"IaCookie"`
+	CacheDir                 string        `yaml:"CacheDir"`
+	CacheTTLHours            int           `yaml:"CacheTTLHours"`
+	CacheMaxSizeMb           int           `yaml:"CacheMaxSizeMb"`
+	WatchesFile              string        `yaml:"WatchesFile"`
+	HistoryFile              string        `yaml:"HistoryFile"`
+	WatchIntervalHours       int           `yaml:"WatchIntervalHours"`
+	StageTimeoutHours        int           `yaml:"StageTimeoutHours"`
+	LogFileName              string        `yaml:"LogFileName"`
+	OutputDir                string        `yaml:"Outputdir"`
+	CopyToOutputDir          bool          `yaml:"CopyToOutputDir"`
+	TmpDir                   string        `yaml:"TmpDir"`
+	LogLevel                 string        `yaml:"LogLevel"`
+	UseMock                  bool          `yaml:"UseMock"`
+	SaveMock                 bool          `yaml:"SaveMock"`
+	ConcurrentDownloaders    int           `yaml:"ConcurrentDownloaders"`
+	ConcurrentEncoders       int           `yaml:"ConcurrentEncoders"`
+	ConcurrentSearchRequests int           `yaml:"ConcurrentSearchRequests"`
+	DownloadLimitKbs         int           `yaml:"DownloadLimitKbs"`
+	UnlimitedDownloadHours   string        `yaml:"UnlimitedDownloadHours"`
+	ReEncodeFiles            bool          `yaml:"ReEncodeFiles"`
+	BitRateKbs               int           `yaml:"BitRateKbs"`
+	SampleRateHz             int           `yaml:"SampleRateHz"`
+	NormalizeLoudness        bool          `yaml:"NormalizeLoudness"`
+	TargetLoudness           float64       `yaml:"TargetLoudness"`
+	TargetTruePeak           float64       `yaml:"TargetTruePeak"`
+	TrimSilence              bool          `yaml:"TrimSilence"`
+	SilenceThresholdDb       float64       `yaml:"SilenceThresholdDb"`
+	SilenceMinDuration       float64       `yaml:"SilenceMinDuration"`
+	SilenceMaxTrim           float64       `yaml:"SilenceMaxTrim"`
+	MaxFileSizeMb            int           `yaml:"MaxFileSizeMb"`
+	FileOrder                string        `yaml:"FileOrder"`
+	OutputFormat             string        `yaml:"OutputFormat"`
+	UploadToAudiobookshef    bool          `yaml:"UploadToAudiobookshelf"`
+	ScanAudiobookshef        bool          `yaml:"ScanAudiobookshelf"`
+	AudiobookshelfUrl        string        `yaml:"AudiobookshelfUrl"`
+	AudiobookshelfUser       string        `yaml:"AudiobookshelfUser"`
+	AudiobookshelfPassword   string        `yaml:"AudiobookshelfPassword"`
+	AudiobookshelfLibrary    string        `yaml:"AudiobookshelfLibrary"`
+	ShortenTitles            bool          `yaml:"ShortenTitles"`
+	ShortenPairs             []ShortenPair `yaml:"ShortenPairs"`
+	Genres                   []string      `yaml:"Genres"`
 }
 
 type ShortenPair struct {
@@ -78,6 +101,15 @@ func Load() {
 	config.OutputDir = "output"
 	config.LogLevel = "INFO"
 	config.RowsPerPage = 25
+	config.UseScrapeAPI = false
+	config.IaBaseUrl = "https://archive.org"
+	config.CacheDir = "cache"
+	config.CacheTTLHours = 24
+	config.CacheMaxSizeMb = 100
+	config.WatchesFile = "abb_ia.watches.json"
+	config.HistoryFile = "abb_ia.history.json"
+	config.WatchIntervalHours = 24
+	config.StageTimeoutHours = 12
 	config.UseMock = false
 	config.SaveMock = false
 	config.DefaultAuthor = "Old Time Radio Researchers Group"
@@ -86,11 +118,22 @@ func Load() {
 	config.SortOrder = "Descending"
 	config.ConcurrentDownloaders = 5
 	config.ConcurrentEncoders = 5
+	config.ConcurrentSearchRequests = 8
+	config.DownloadLimitKbs = 0
+	config.UnlimitedDownloadHours = ""
 	config.ReEncodeFiles = true
-	config.BasePortNumber = 31000
 	config.BitRateKbs = 128
 	config.SampleRateHz = 44100
+	config.NormalizeLoudness = false
+	config.TargetLoudness = -16
+	config.TargetTruePeak = -1.5
+	config.TrimSilence = false
+	config.SilenceThresholdDb = -50
+	config.SilenceMinDuration = 0.5
+	config.SilenceMaxTrim = 10
 	config.MaxFileSizeMb = 250
+	config.FileOrder = "Track number"
+	config.OutputFormat = "M4B"
 	config.UploadToAudiobookshef = false
 	config.ScanAudiobookshef = false
 	config.AudiobookshelfUser = "admin"
@@ -198,6 +241,111 @@ func (c *Config) GetRowsPerPage() int {
 	return c.RowsPerPage
 }
 
+func (c *Config) SetUseScrapeAPI(b bool) {
+	c.UseScrapeAPI = b
+}
+
+func (c *Config) IsUseScrapeAPI() bool {
+	return c.UseScrapeAPI
+}
+
+func (c *Config) SetIaBaseUrl(url string) {
+	c.IaBaseUrl = url
+}
+
+func (c *Config) GetIaBaseUrl() string {
+	return c.IaBaseUrl
+}
+
+// IA S3-like API keys (https://archive.org/account/s3.php)
+func (c *Config) SetIaAccessKey(k string) {
+	c.IaAccessKey = encryptSecret(k)
+}
+
+func (c *Config) GetIaAccessKey() string {
+	return decryptSecret(c.IaAccessKey)
+}
+
+func (c *Config) SetIaSecretKey(k string) {
+	c.IaSecretKey = encryptSecret(k)
+}
+
+func (c *Config) GetIaSecretKey() string {
+	return decryptSecret(c.IaSecretKey)
+}
+
+// IA login session cookies copied from a browser: "logged-in-user=...; logged-in-sig=..."
+func (c *Config) SetIaCookie(cookie string) {
+	c.IaCookie = encryptSecret(cookie)
+}
+
+func (c *Config) GetIaCookie() string {
+	return decryptSecret(c.IaCookie)
+}
+
+func (c *Config) HasIaCredentials() bool {
+	return (c.IaAccessKey != "" && c.IaSecretKey != "") || c.IaCookie != ""
+}
+
+// IA search results and item details cache. TTL 0 disables the cache
+func (c *Config) SetCacheDir(dir string) {
+	c.CacheDir = dir
+}
+
+func (c *Config) GetCacheDir() string {
+	return c.CacheDir
+}
+
+func (c *Config) SetCacheTTLHours(h int) {
+	c.CacheTTLHours = h
+}
+
+func (c *Config) GetCacheTTLHours() int {
+	return c.CacheTTLHours
+}
+
+func (c *Config) SetCacheMaxSizeMb(s int) {
+	c.CacheMaxSizeMb = s
+}
+
+func (c *Config) GetCacheMaxSizeMb() int {
+	return c.CacheMaxSizeMb
+}
+
+func (c *Config) SetWatchesFile(f string) {
+	c.WatchesFile = f
+}
+
+func (c *Config) GetWatchesFile() string {
+	return c.WatchesFile
+}
+
+func (c *Config) SetHistoryFile(f string) {
+	c.HistoryFile = f
+}
+
+func (c *Config) GetHistoryFile() string {
+	return c.HistoryFile
+}
+
+// 0 - the watches are checked on demand only
+func (c *Config) SetWatchIntervalHours(h int) {
+	c.WatchIntervalHours = h
+}
+
+func (c *Config) GetWatchIntervalHours() int {
+	return c.WatchIntervalHours
+}
+
+// a headless build fails if a stage (download, encoding, build etc.) runs longer. 0 - no limit
+func (c *Config) SetStageTimeoutHours(h int) {
+	c.StageTimeoutHours = h
+}
+
+func (c *Config) GetStageTimeoutHours() int {
+	return c.StageTimeoutHours
+}
+
 func (c *Config) SetUseMock(b bool) {
 	c.UseMock = b
 }
@@ -270,20 +418,39 @@ func (c *Config) GetConcurrentEncoders() int {
 	return c.ConcurrentEncoders
 }
 
-func (c *Config) SetReEncodeFiles(b bool) {
-	c.ReEncodeFiles = b
+// number of item details requested from IA in parallel when the search results are fetched
+func (c *Config) SetConcurrentSearchRequests(n int) {
+	c.ConcurrentSearchRequests = n
 }
 
-func (c *Config) IsReEncodeFiles() bool {
-	return c.ReEncodeFiles
+func (c *Config) GetConcurrentSearchRequests() int {
+	return c.ConcurrentSearchRequests
+}
+
+// Total download speed limit of all the concurrent downloaders, KB/s. 0 - unlimited
+func (c *Config) SetDownloadLimitKbs(n int) {
+	c.DownloadLimitKbs = n
 }
 
-func (c *Config) SetBasePortNumber(port int) {
-	c.BasePortNumber = port
+func (c *Config) GetDownloadLimitKbs() int {
+	return c.DownloadLimitKbs
 }
 
-func (c *Config) GetBasePortNumber() int {
-	return c.BasePortNumber
+// Daily time window the download speed isn't limited in ("00:00-07:00" for ex.). Empty - always limited
+func (c *Config) SetUnlimitedDownloadHours(s string) {
+	c.UnlimitedDownloadHours = s
+}
+
+func (c *Config) GetUnlimitedDownloadHours() string {
+	return c.UnlimitedDownloadHours
+}
+
+func (c *Config) SetReEncodeFiles(b bool) {
+	c.ReEncodeFiles = b
+}
+
+func (c *Config) IsReEncodeFiles() bool {
+	return c.ReEncodeFiles
 }
 
 func (c *Config) SetBitRate(b int) {
@@ -302,6 +469,69 @@ func (c *Config) GetSampleRate() int {
 	return c.SampleRateHz
 }
 
+// EBU R128 two-pass loudness normalization of the audio files. The files are re-encoded
+func (c *Config) SetNormalizeLoudness(b bool) {
+	c.NormalizeLoudness = b
+}
+
+func (c *Config) IsNormalizeLoudness() bool {
+	return c.NormalizeLoudness
+}
+
+// integrated loudness target, LUFS
+func (c *Config) SetTargetLoudness(f float64) {
+	c.TargetLoudness = f
+}
+
+func (c *Config) GetTargetLoudness() float64 {
+	return c.TargetLoudness
+}
+
+// maximum true peak, dBTP
+func (c *Config) SetTargetTruePeak(f float64) {
+	c.TargetTruePeak = f
+}
+
+func (c *Config) GetTargetTruePeak() float64 {
+	return c.TargetTruePeak
+}
+
+// trim the leading and the trailing silence of the audio files. The files are re-encoded
+func (c *Config) SetTrimSilence(b bool) {
+	c.TrimSilence = b
+}
+
+func (c *Config) IsTrimSilence() bool {
+	return c.TrimSilence
+}
+
+// the audio below the threshold is the silence, dB
+func (c *Config) SetSilenceThreshold(f float64) {
+	c.SilenceThresholdDb = f
+}
+
+func (c *Config) GetSilenceThreshold() float64 {
+	return c.SilenceThresholdDb
+}
+
+// shorter silence is not trimmed, seconds
+func (c *Config) SetSilenceMinDuration(f float64) {
+	c.SilenceMinDuration = f
+}
+
+func (c *Config) GetSilenceMinDuration() float64 {
+	return c.SilenceMinDuration
+}
+
+// maximum silence trimmed from each end of a file, seconds
+func (c *Config) SetSilenceMaxTrim(f float64) {
+	c.SilenceMaxTrim = f
+}
+
+func (c *Config) GetSilenceMaxTrim() float64 {
+	return c.SilenceMaxTrim
+}
+
 func (c *Config) SetMaxFileSizeMb(s int) {
 	c.MaxFileSizeMb = s
 }
@@ -343,31 +573,11 @@ func (c *Config) SetAudiobookshelfUser(u string) {
 }
 
 func (c *Config) GetAudiobookshelfPassword() string {
-	base64 := c.AudiobookshelfPassword
-	if base64 == "" {
-		return ""
-	}
-	encrypted, err := utils.DecodeBase64(base64)
-	if err != nil {
-		logger.Error("Can't decode base64 password: " + err.Error())
-		return ""
-	}
-	decrypted, err := utils.DecryptString(encrypted)
-	if err != nil {
-		logger.Error("Can't decrypt password: " + err.Error())
-		return ""
-	}
-	return decrypted
+	return decryptSecret(c.AudiobookshelfPassword)
 }
 
 func (c *Config) SetAudiobookshelfPassword(p string) {
-
-	encrypted, err := utils.EncryptString(p)
-	if err != nil {
-		logger.Error("Can't encrypt password: " + err.Error())
-	}
-	base64 := utils.EncodeBase64(encrypted)
-	c.AudiobookshelfPassword = base64
+	c.AudiobookshelfPassword = encryptSecret(p)
 }
 
 func (c *Config) GetAudiobookshelfLibrary() string {
@@ -378,6 +588,33 @@ func (c *Config) SetAudiobookshelfLibrary(l string) {
 	c.AudiobookshelfLibrary = l
 }
 
+func (c *Config) SetFileOrder(s string) {
+	c.FileOrder = s
+}
+
+func (c *Config) GetFileOrder() string {
+	return c.FileOrder
+}
+
+// "Track number" - by IA track metadata, then by disc (album), then by file name. "File name" - by file name only
+func (c *Config) GetFileOrderOptions() []string {
+	return []string{"Track number", "File name"}
+}
+
+func (c *Config) SetOutputFormat(s string) {
+	c.OutputFormat = s
+}
+
+func (c *Config) GetOutputFormat() string {
+	return c.OutputFormat
+}
+
+// "M4B" - AAC in MP4 container with chapters. "Opus" - Opus in Ogg container, chapters as Vorbis comments.
+// "MP3 with chapters" - mp3 with ID3v2 chapter frames for the players playing mp3 only
+func (c *Config) GetOutputFormatOptions() []string {
+	return []string{"M4B", "Opus", "MP3 with chapters"}
+}
+
 func (c *Config) SetShortenTitles(b bool) {
 	c.ShortenTitles = b
 }
@@ -419,3 +656,32 @@ func (c *Config) GetBuildDate() string {
 func (c *Config) GetCopy() Config {
 	return *configInstance
 }
+
+// passwords and keys are stored encrypted with the machine specific key
+func encryptSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	encrypted, err := utils.EncryptString(s)
+	if err != nil {
+		logger.Error("Can't encrypt password: " + err.Error())
+	}
+	return utils.EncodeBase64(encrypted)
+}
+
+func decryptSecret(base64 string) string {
+	if base64 == "" {
+		return ""
+	}
+	encrypted, err := utils.DecodeBase64(base64)
+	if err != nil {
+		logger.Error("Can't decode base64 password: " + err.Error())
+		return ""
+	}
+	decrypted, err := utils.DecryptString(encrypted)
+	if err != nil {
+		logger.Error("Can't decrypt password: " + err.Error())
+		return ""
+	}
+	return decrypted
+}
@@ -1,20 +1,23 @@
 package controller
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"abb_ia/internal/dto"
 	"abb_ia/internal/ffmpeg"
+	"abb_ia/internal/id3"
 	"abb_ia/internal/mp4"
+	"abb_ia/internal/ogg"
 	"abb_ia/internal/utils"
 
 	"abb_ia/internal/logger"
@@ -26,7 +29,11 @@ type BuildController struct {
 	ab        *dto.Audiobook
 	startTime time.Time
 	stopFlag  bool
+	ctx       context.Context    // the ffmpeg processes of the build are killed when it's done
+	cancel    context.CancelFunc // stops the build
+	mu        sync.Mutex         // guards files, errors and cancel. The chapters of a part are encoded concurrently
 	files     []fileBuild
+	errors    []string // ffmpeg errors of the chapters and the parts
 }
 
 // progress tracking arrays
@@ -38,6 +45,14 @@ type fileBuild struct {
 	encodingSpeed    float64
 	progress         int
 	complete         bool
+	chapters         []chapterEncode
+}
+
+type chapterEncode struct {
+	bytesProcessed   int64
+	secondsProcessed float64
+	encodingSpeed    float64
+	complete         bool
 }
 
 func NewBuildController(dispatcher *mq.Dispatcher) *BuildController {
@@ -67,14 +82,25 @@ func (c *BuildController) dispatchMessage(m *mq.Message) {
 
 func (c *BuildController) stopBuild(cmd *dto.StopCommand) {
 	c.stopFlag = true
+	c.mu.Lock()
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.mu.Unlock()
 	logger.Info(fmt.Sprintf("Building the audiobook: %s - %s...", c.ab.Author, c.ab.Title))
 }
 
 func (c *BuildController) startBuild(cmd *dto.BuildCommand) {
 	c.stopFlag = false
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c.mu.Lock()
+	c.ctx, c.cancel = ctx, cancel
+	c.mu.Unlock()
 	c.startTime = time.Now()
 	c.ab = cmd.Audiobook
 	c.files = make([]fileBuild, len(c.ab.Parts))
+	c.errors = []string{}
 
 	// calculate output file names
 	for i := range c.ab.Parts {
@@ -83,10 +109,10 @@ func (c *BuildController) startBuild(cmd *dto.BuildCommand) {
 		if len(c.ab.Parts) > 1 {
 			filePath = filePath + fmt.Sprintf(", Part %04d", i+1)
 		}
-		part.AACFile = filePath + ".aac"
-		part.M4BFile = filePath + ".m4b"
-		c.files[i].fileName = part.M4BFile
+		part.OutputFile = filePath + outputFileExt(c.ab.Config.GetOutputFormat())
+		c.files[i].fileName = part.OutputFile
 		c.files[i].totalDuration = part.Duration
+		c.files[i].chapters = make([]chapterEncode, len(part.Chapters))
 	}
 
 	c.mq.SendMessage(mq.BuildController, mq.BuildPage, &dto.DisplayBookInfoCommand{Audiobook: c.ab}, true)
@@ -95,44 +121,114 @@ func (c *BuildController) startBuild(cmd *dto.BuildCommand) {
 
 	logger.Info(fmt.Sprintf("Building the audiobook: %s - %s...", c.ab.Author, c.ab.Title))
 
-	// prepare .mp3 file list
+	// prepare audio file list
 	c.createFilesLists(c.ab)
-	// prepare metadata
-	c.createMetadata(c.ab)
 	c.downloadCoverImage(c.ab)
 
-	// build audiobook parts
-
+	// encode the chapters to AAC. A book of a single part uses all the encoders too
 	jd := utils.NewJobDispatcher(c.ab.Config.GetConcurrentEncoders())
-	for i := range c.ab.Parts {
-		jd.AddJob(i, c.buildAudiobookPart, c.ab, i)
+	jobId := 0
+	for i, part := range c.ab.Parts {
+		for j := range part.Chapters {
+			jd.AddJob(jobId, c.encodeChapter, c.ab, i, j)
+			jobId++
+		}
 	}
 	go c.updateTotalProgress()
 	jd.Start()
 
+	// join the encoded chapters into audiobook parts. No re-encoding, so it's fast
+	if len(c.errors) == 0 && !c.stopFlag {
+		// the chapter marks and the metadata are calculated from the encoded chapters
+		c.updateChapterTimes(c.ab)
+		c.createMetadata(c.ab)
+		jd = utils.NewJobDispatcher(c.ab.Config.GetConcurrentEncoders())
+		for i := range c.ab.Parts {
+			jd.AddJob(i, c.buildAudiobookPart, c.ab, i)
+		}
+		jd.Start()
+	}
+
 	c.mq.SendMessage(mq.BuildController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
 	c.mq.SendMessage(mq.BuildController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
-	if !c.stopFlag {
+	if !c.stopFlag && len(c.errors) > 0 {
+		c.mq.SendMessage(mq.BuildController, mq.BuildPage, &dto.ProcessFailed{Process: "Build", Audiobook: cmd.Audiobook, Error: strings.Join(c.errors, "; ")}, true)
+	} else if !c.stopFlag {
 		c.mq.SendMessage(mq.BuildController, mq.BuildPage, &dto.BuildComplete{Audiobook: cmd.Audiobook}, true)
 	}
 	c.stopFlag = true
 }
 
+// the audio files of each chapter and the encoded chapters of each part
 func (c *BuildController) createFilesLists(ab *dto.Audiobook) {
 	for i := range ab.Parts {
 		part := &ab.Parts[i]
-		part.FListFile = filepath.Join(ab.OutputDir, fmt.Sprintf("Part %04d Files.txt", part.Number))
-		f, err := os.OpenFile(part.FListFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
-		if err != nil {
-			logger.Error("Can't open FList file for writing: " + err.Error())
+		_, ext := chapterEncodingParams(ab.Config.GetOutputFormat(), ab.Config.GetBitRate(), ab.Config.GetSampleRate())
+		names := []string{}
+		for j := range part.Chapters {
+			chapter := &part.Chapters[j]
+			name := fmt.Sprintf("Part %04d Chapter %04d", part.Number, j+1)
+			chapter.EncodedFile = filepath.Join(ab.OutputDir, name+ext)
+			chapter.FListFile = filepath.Join(ab.OutputDir, name+" Files.txt")
+			files := []string{}
+			for _, file := range chapter.Files {
+				files = append(files, file.FileName)
+			}
+			writeFilesList(chapter.FListFile, files)
+			names = append(names, name+ext)
 		}
+		part.FListFile = filepath.Join(ab.OutputDir, fmt.Sprintf("Part %04d Files.txt", part.Number))
+		writeFilesList(part.FListFile, names)
+	}
+}
+
+// ffmpeg concat demuxer file list. The file names are relative to the list directory
+func writeFilesList(listFile string, fileNames []string) {
+	f, err := os.OpenFile(listFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+	if err != nil {
+		logger.Error("Can't open FList file for writing: " + err.Error())
+		return
+	}
+	for _, fileName := range fileNames {
+		f.WriteString("file '" + strings.TrimPrefix(fileName, "/") + "'\n")
+	}
+	f.Close()
+}
+
+/**
+ * Each chapter is encoded separately, so it gets its own encoder delay and padding.
+ * The concatenation keeps them all, and the chapter marks calculated from the source
+ * durations drift by seconds over a long part. Take the durations of the encoded chapters instead
+ **/
+func (c *BuildController) updateChapterTimes(ab *dto.Audiobook) {
+	ab.TotalDuration = 0
+	for i := range ab.Parts {
+		part := &ab.Parts[i]
+		durations := []float64{}
 		for _, chapter := range part.Chapters {
-			for _, file := range chapter.Files {
-				f.WriteString("file '" + strings.TrimPrefix(file.FileName, "/") + "'\n")
+			duration, err := ffmpeg.AudioDuration(chapter.EncodedFile)
+			if err != nil || duration <= 0 {
+				logger.Warn(fmt.Sprintf("Can't get the duration of %s. The source duration is used", chapter.EncodedFile))
+				duration = chapter.Duration
 			}
+			durations = append(durations, duration)
 		}
-		f.Close()
+		setChapterTimes(part, durations)
+		ab.TotalDuration += part.Duration
+	}
+}
+
+// chapter start and end times of a part from the chapter durations
+func setChapterTimes(part *dto.Part, durations []float64) {
+	var offset float64 = 0
+	for i := range part.Chapters {
+		chapter := &part.Chapters[i]
+		chapter.Duration = durations[i]
+		chapter.Start = offset
+		offset += chapter.Duration
+		chapter.End = offset
 	}
+	part.Duration = offset
 }
 
 func (c *BuildController) createMetadata(ab *dto.Audiobook) {
@@ -190,132 +286,297 @@ func (c *BuildController) downloadCoverImage(ab *dto.Audiobook) error {
 	return nil
 }
 
-func (c *BuildController) buildAudiobookPart(ab *dto.Audiobook, partId int) {
+// encode the chapter audio files into a single file (.aac for m4b, .opus, .mp3)
+func (c *BuildController) encodeChapter(ab *dto.Audiobook, partId int, chapterId int) {
 	if c.stopFlag {
 		return
 	}
 
-	part := &ab.Parts[partId]
-
-	// launch progress listener
-	l, port := c.startProgressListener(partId)
-	defer l.Close()
-	go c.updateFileProgress(partId, l)
-
-	// concatenate mp3 files into single .aac file
-	_, err := ffmpeg.NewFFmpeg().
-		Input(part.FListFile, "-safe 0 -f concat").
-		Output(part.AACFile, "-acodec aac -vn").
+	chapter := &ab.Parts[partId].Chapters[chapterId]
+	params, _ := chapterEncodingParams(ab.Config.GetOutputFormat(), ab.Config.GetBitRate(), ab.Config.GetSampleRate())
+	concat := ffmpeg.NewFFmpeg()
+	if isSameCodec(chapter.Files) {
+		concat.Input(chapter.FListFile, "-safe 0 -f concat").
+			Output(chapter.EncodedFile, params)
+	} else {
+		// files of different codecs can't be joined by concat demuxer. Use concat filter instead
+		filter := ""
+		for i, file := range chapter.Files {
+			concat.Input(filepath.Join(ab.OutputDir, file.FileName), "")
+			filter += fmt.Sprintf("[%d:a:0]", i)
+		}
+		filter += fmt.Sprintf("concat=n=%d:v=0:a=1[a]", len(chapter.Files))
+		concat.Output(chapter.EncodedFile, "-filter_complex "+filter+" -map [a] "+params)
+	}
+	concat.
 		Overwrite(true).
 		Params("-hide_banner -nostdin -nostats -loglevel error").
-		SendProgressTo("http://127.0.0.1:" + strconv.Itoa(port)).
-		Run()
-	if err != nil {
+		OnProgress(func(p ffmpeg.Progress) { c.updateChapterProgress(partId, chapterId, p) }).
+		Context(c.ctx)
+
+	_, err := concat.Run()
+	if err != nil && !c.stopFlag {
 		logger.Error("FFMPEG Error: " + string(err.Error()))
+		c.fail(fmt.Sprintf("can't encode chapter %d of part %d: %s", chapterId+1, partId+1, err.Error()))
+	}
+}
+
+func (c *BuildController) fail(message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, message)
+}
+
+func (c *BuildController) buildAudiobookPart(ab *dto.Audiobook, partId int) {
+	if c.stopFlag {
 		return
 	}
 
-	// add chapters and convert to .m4b
+	part := &ab.Parts[partId]
+
+	// join the encoded chapters. M4B gets the chapters from the metadata file, Opus and MP3 get them with the tags
 	ffmpeg := ffmpeg.NewFFmpeg().
-		Input(part.AACFile, "").
-		Input(part.MetadataFile, "").
-		Output(part.M4BFile, "-map_metadata 1 -y -vn -y -acodec copy").
-		Overwrite(true).
+		Input(part.FListFile, "-safe 0 -f concat")
+	switch ab.Config.GetOutputFormat() {
+	case "Opus":
+		ffmpeg.Output(part.OutputFile, "-map_metadata -1 -map_chapters -1 -vn -acodec copy")
+	case "MP3 with chapters":
+		ffmpeg.Output(part.OutputFile, "-map_metadata -1 -map_chapters -1 -vn -acodec copy -id3v2_version 0")
+	default:
+		ffmpeg.Input(part.MetadataFile, "").
+			Output(part.OutputFile, "-map_metadata 1 -vn -acodec copy")
+	}
+	ffmpeg.Overwrite(true).
 		Params("-hide_banner -nostdin -nostats -loglevel error").
-		SendProgressTo("http://127.0.0.1:" + strconv.Itoa(port))
+		Context(c.ctx)
 
-	go c.killSwitch(ffmpeg)
-	_, err = ffmpeg.Run()
-	if err != nil && !c.stopFlag {
-		logger.Error("FFMPEG Error: " + string(err.Error()))
+	_, err := ffmpeg.Run()
+	if err != nil {
+		if !c.stopFlag {
+			logger.Error("FFMPEG Error: " + string(err.Error()))
+			c.fail(fmt.Sprintf("can't build part %d: %s", partId+1, err.Error()))
+		}
 		return
 	}
 
 	// clean up
-	os.Remove(part.AACFile)
+	for _, chapter := range part.Chapters {
+		os.Remove(chapter.EncodedFile)
+	}
 
 	// add tags and cover image
-	m4b, er := mp4.NewMp4(part.M4BFile)
-	if er != nil && !c.stopFlag {
+	switch ab.Config.GetOutputFormat() {
+	case "Opus":
+		c.tagOpus(ab, part)
+	case "MP3 with chapters":
+		c.tagMp3(ab, part)
+	default:
+		c.tagM4B(ab, part)
+	}
+}
+
+func (c *BuildController) tagM4B(ab *dto.Audiobook, part *dto.Part) {
+	m4b, err := mp4.NewMp4(part.OutputFile)
+	if err != nil {
 		logger.Error("Can't open m4b file for write: " + err.Error())
+		return
 	}
 	m4b.SetTag("\xa9nam", ab.Title)
 	m4b.SetTag("\xa9alb", ab.Title)
 	m4b.SetTag("\xa9ART", ab.Author)
 	m4b.SetTag("desc", ab.Description)
-	m4b.SetTag("cprt", ab.LicenseUrl)
+	m4b.SetTag("cprt", copyrightText(ab))
+	if ab.Genre != "" {
+		m4b.SetTag("\xa9gen", ab.Genre)
+	}
+	if ab.Year != "" {
+		m4b.SetTag("\xa9day", ab.Year)
+	}
+	if ab.Narrator != "" {
+		// Audiobookshelf and most players show the composer as the narrator
+		m4b.SetTag("\xa9wrt", ab.Narrator)
+	}
 	m4b.SetTag("purl", ab.IaURL)
-	m4b.SetTag("\xa9cmt", "This audiobook was created using the 'Audiobook Builder' tool: https://github.com/"+ab.Config.GetRepoOwner()+"/"+ab.Config.GetRepoName()+"\n"+
-		"The audio files used for this book were obtained from the Internet Archive site: "+ab.IaURL)
+	m4b.SetTag("\xa9cmt", commentText(ab))
 
-	imageData, er := ioutil.ReadFile(ab.CoverFile)
-	if er == nil {
+	imageData, err := ioutil.ReadFile(ab.CoverFile)
+	if err == nil {
 		m4b.SetImage(imageData, mp4.DataTypeJPEG)
 	}
 
-	er = m4b.Save()
-	if er != nil {
+	if err := m4b.Save(); err != nil {
 		logger.Error("Can't save m4b file: " + err.Error())
 	}
 }
 
-func (c *BuildController) killSwitch(ffmpeg *ffmpeg.FFmpeg) {
-	for !c.stopFlag {
-		time.Sleep(mq.PullFrequency)
+// Vorbis comments. The chapters are CHAPTERxxx comments, the cover is METADATA_BLOCK_PICTURE
+func (c *BuildController) tagOpus(ab *dto.Audiobook, part *dto.Part) {
+	opus, err := ogg.NewOpus(part.OutputFile)
+	if err != nil {
+		logger.Error("Can't open opus file for write: " + err.Error())
+		return
 	}
-	ffmpeg.Kill()
-}
+	opus.SetTag("TITLE", ab.Title)
+	opus.SetTag("ALBUM", ab.Title)
+	opus.SetTag("ARTIST", ab.Author)
+	opus.SetTag("DESCRIPTION", ab.Description)
+	opus.SetTag("COPYRIGHT", copyrightText(ab))
+	if ab.Genre != "" {
+		opus.SetTag("GENRE", ab.Genre)
+	}
+	if ab.Year != "" {
+		opus.SetTag("DATE", ab.Year)
+	}
+	if ab.Narrator != "" {
+		opus.SetTag("COMPOSER", ab.Narrator)
+	}
+	opus.SetTag("COMMENT", commentText(ab))
 
-func (c *BuildController) startProgressListener(fileId int) (net.Listener, int) {
+	starts := []float64{}
+	names := []string{}
+	for _, chapter := range part.Chapters {
+		starts = append(starts, chapter.Start)
+		names = append(names, chapter.Name)
+	}
+	opus.SetChapters(starts, names)
 
-	basePortNumber := 31000
-	portNumber := basePortNumber + fileId
+	imageData, err := ioutil.ReadFile(ab.CoverFile)
+	if err == nil {
+		opus.SetImage(imageData, coverMimeType(ab.CoverFile))
+	}
 
-	l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(portNumber))
-	if err != nil {
-		logger.Error("Build progress: Start listener error: " + err.Error())
+	if err := opus.Save(); err != nil {
+		logger.Error("Can't save opus file: " + err.Error())
 	}
-	return l, portNumber
 }
 
-func (c *BuildController) updateFileProgress(fileId int, l net.Listener) {
-	fd, err := l.Accept()
+// ID3v2.4 tag. The chapters are CHAP frames listed by CTOC frame, the cover is APIC frame
+func (c *BuildController) tagMp3(ab *dto.Audiobook, part *dto.Part) {
+	mp3, err := id3.NewMp3(part.OutputFile)
 	if err != nil {
-		return // listener may be closed already
+		logger.Error("Can't open mp3 file for write: " + err.Error())
+		return
+	}
+	mp3.SetTag("TIT2", ab.Title)
+	mp3.SetTag("TALB", ab.Title)
+	mp3.SetTag("TPE1", ab.Author)
+	mp3.SetTag("TCOP", copyrightText(ab))
+	if ab.Genre != "" {
+		mp3.SetTag("TCON", ab.Genre)
 	}
-	buf := make([]byte, 16)
-	data := ""
-	percent := 0
+	if ab.Year != "" {
+		mp3.SetTag("TDRC", ab.Year)
+	}
+	if ab.Narrator != "" {
+		mp3.SetTag("TCOM", ab.Narrator)
+	}
+	if len(ab.Parts) > 1 {
+		mp3.SetTag("TPOS", fmt.Sprintf("%d/%d", part.Number, len(ab.Parts)))
+	}
+	mp3.SetComment(commentText(ab))
 
-	for !c.stopFlag {
-		_, err := fd.Read(buf)
-		if err != nil {
-			return // listener is closed
-		}
-		data += string(buf)
-		bytesProcessed, secondsProcessed, encodingSpeed, complete := ffmpeg.ParseFFMPEGProgress(data)
-		percent = int(secondsProcessed / c.files[fileId].totalDuration * 100)
-		// wrong calculation protection
-		if percent < 0 {
-			percent = 0
-		} else if percent > 100 {
-			percent = 100
-		} else if percent < c.files[fileId].progress {
-			percent = c.files[fileId].progress
-		} else if complete {
-			percent = 100
+	chapters := []id3.Chapter{}
+	for _, chapter := range part.Chapters {
+		chapters = append(chapters, id3.Chapter{Start: chapter.Start, End: chapter.End, Title: chapter.Name})
+	}
+	mp3.SetChapters(chapters)
+
+	imageData, err := ioutil.ReadFile(ab.CoverFile)
+	if err == nil {
+		mp3.SetImage(imageData, coverMimeType(ab.CoverFile))
+	}
+
+	if err := mp3.Save(); err != nil {
+		logger.Error("Can't save mp3 file: " + err.Error())
+	}
+}
+
+func coverMimeType(coverFile string) string {
+	if strings.HasSuffix(coverFile, ".png") {
+		return "image/png"
+	}
+	return "image/jpeg"
+}
+
+func copyrightText(ab *dto.Audiobook) string {
+	if ab.Copyright != "" {
+		return ab.Copyright
+	}
+	return strings.Join(ab.GetLicenses(), " ")
+}
+
+func commentText(ab *dto.Audiobook) string {
+	return "This audiobook was created using the 'Audiobook Builder' tool: https://github.com/" + ab.Config.GetRepoOwner() + "/" + ab.Config.GetRepoName() + "\n" +
+		"The audio files used for this book were obtained from the Internet Archive site: " + sourcesText(ab)
+}
+
+// IA url of the book item or the urls and licenses of all the items the book is merged from
+func sourcesText(ab *dto.Audiobook) string {
+	if len(ab.SourceItems) <= 1 {
+		return ab.IaURL
+	}
+	sources := []string{}
+	for _, s := range ab.SourceItems {
+		if s.LicenseUrl != "" {
+			sources = append(sources, s.IaURL+" (license: "+s.LicenseUrl+")")
+		} else {
+			sources = append(sources, s.IaURL)
 		}
+	}
+	return "\n" + strings.Join(sources, "\n")
+}
 
-		// sent a message only if progress changed
-		if percent != c.files[fileId].progress {
-			c.files[fileId].bytesProcessed = bytesProcessed
-			c.files[fileId].secondsProcessed = secondsProcessed
-			c.files[fileId].encodingSpeed = encodingSpeed
-			c.files[fileId].progress = percent
-			c.files[fileId].complete = complete
-			c.mq.SendMessage(mq.BuildController, mq.BuildPage, &dto.FileBuildProgress{FileId: fileId, FileName: c.files[fileId].fileName, Percent: percent}, true)
+func (c *BuildController) updateChapterProgress(partId int, chapterId int, p ffmpeg.Progress) {
+	if c.stopFlag {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f := &c.files[partId]
+	ch := &f.chapters[chapterId]
+	ch.bytesProcessed = p.Bytes
+	ch.secondsProcessed = p.Seconds
+	ch.encodingSpeed = p.Speed
+	ch.complete = p.Complete
+
+	// the part progress is the sum of its chapters
+	var bytesProcessed int64 = 0
+	var secondsProcessed float64 = 0
+	var encodingSpeed float64 = 0
+	complete := true
+	for _, ch := range f.chapters {
+		bytesProcessed += ch.bytesProcessed
+		secondsProcessed += ch.secondsProcessed
+		if !ch.complete {
+			encodingSpeed += ch.encodingSpeed
+			complete = false
 		}
 	}
+	if encodingSpeed > 0 {
+		f.encodingSpeed = encodingSpeed
+	}
+	f.secondsProcessed = secondsProcessed
+	f.bytesProcessed = bytesProcessed
+
+	percent := int(secondsProcessed / f.totalDuration * 100)
+	// wrong calculation protection
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	} else if percent < f.progress {
+		percent = f.progress
+	} else if complete {
+		percent = 100
+	}
+
+	// sent a message only if progress changed
+	if percent != f.progress || complete != f.complete {
+		f.progress = percent
+		f.complete = complete
+		c.mq.SendMessage(mq.BuildController, mq.BuildPage, &dto.FileBuildProgress{FileId: partId, FileName: f.fileName, Percent: percent}, true)
+	}
 }
 
 func (c *BuildController) updateTotalProgress() {
@@ -327,6 +588,7 @@ func (c *BuildController) updateTotalProgress() {
 		var totalSpeed float64 = 0
 		filesProcessed := 0
 		filesComplete := 0
+		c.mu.Lock()
 		for _, f := range c.files {
 			totalDuration += f.totalDuration
 			secondsProcessed += f.secondsProcessed
@@ -338,6 +600,7 @@ func (c *BuildController) updateTotalProgress() {
 				filesProcessed++
 			}
 		}
+		c.mu.Unlock()
 		percent := int(secondsProcessed / totalDuration * 100)
 		// wrong calculation protection
 		if percent < 0 {
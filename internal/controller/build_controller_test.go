@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/ffmpeg"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChapterFilesLists(t *testing.T) {
+	ab := &dto.Audiobook{OutputDir: t.TempDir()}
+	conf := config.Instance().GetCopy()
+	conf.SetOutputFormat("M4B")
+	ab.Config = &conf
+	ab.Parts = []dto.Part{{Number: 1, Chapters: []dto.Chapter{
+		{Number: 1, Files: []dto.Mp3File{{FileName: "/item/01.mp3"}, {FileName: "/item/02.mp3"}}},
+		{Number: 2, Files: []dto.Mp3File{{FileName: "/item/03.mp3"}}},
+	}}}
+
+	c := NewBuildController(mq.NewDispatcher())
+	c.createFilesLists(ab)
+
+	part := ab.Parts[0]
+	assert.Equal(t, filepath.Join(ab.OutputDir, "Part 0001 Chapter 0002.aac"), part.Chapters[1].EncodedFile)
+	data, err := os.ReadFile(part.Chapters[0].FListFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "file 'item/01.mp3'\nfile 'item/02.mp3'\n", string(data))
+	data, err = os.ReadFile(part.FListFile)
+	assert.NoError(t, err)
+	assert.Equal(t, "file 'Part 0001 Chapter 0001.aac'\nfile 'Part 0001 Chapter 0002.aac'\n", string(data))
+
+	// the chapters are encoded to the output format codec
+	conf.SetOutputFormat("Opus")
+	c.createFilesLists(ab)
+	assert.Equal(t, filepath.Join(ab.OutputDir, "Part 0001 Chapter 0001.opus"), ab.Parts[0].Chapters[0].EncodedFile)
+	assert.Equal(t, ".opus", outputFileExt(conf.GetOutputFormat()))
+	assert.Equal(t, ".mp3", outputFileExt("MP3 with chapters"))
+	// the configured bit rate applies to opus as well
+	params, _ := chapterEncodingParams("Opus", 48, 44100)
+	assert.Contains(t, params, "-b:a 48k")
+}
+
+func TestChapterProgress(t *testing.T) {
+	c := NewBuildController(mq.NewDispatcher())
+	c.files = []fileBuild{{fileName: "book.m4b", totalDuration: 100, chapters: make([]chapterEncode, 2)}}
+
+	// the chapters are encoded concurrently. The part progress is their sum
+	c.updateChapterProgress(0, 0, ffmpeg.Progress{Seconds: 20, Speed: 10})
+	c.updateChapterProgress(0, 1, ffmpeg.Progress{Seconds: 30, Speed: 15})
+	assert.Equal(t, 50, c.files[0].progress)
+	assert.Equal(t, float64(25), c.files[0].encodingSpeed)
+	assert.False(t, c.files[0].complete)
+
+	c.updateChapterProgress(0, 0, ffmpeg.Progress{Seconds: 40, Speed: 10, Complete: true})
+	assert.False(t, c.files[0].complete)
+	c.updateChapterProgress(0, 1, ffmpeg.Progress{Seconds: 59, Speed: 15, Complete: true})
+	assert.True(t, c.files[0].complete)
+	assert.Equal(t, 100, c.files[0].progress)
+}
+
+func TestSetChapterTimes(t *testing.T) {
+	part := &dto.Part{Duration: 3600, Chapters: []dto.Chapter{
+		{Number: 1, Start: 0, End: 1800, Duration: 1800},
+		{Number: 2, Start: 1800, End: 3600, Duration: 1800},
+	}}
+	// the encoded chapters are a bit longer than the source files
+	setChapterTimes(part, []float64{1800.046, 1800.052})
+	assert.Equal(t, 1800.046, part.Chapters[0].End)
+	assert.Equal(t, 1800.046, part.Chapters[1].Start)
+	assert.Equal(t, 1800.052, part.Chapters[1].Duration)
+	assert.InDelta(t, 3600.098, part.Chapters[1].End, 1e-9)
+	assert.InDelta(t, 3600.098, part.Duration, 1e-9)
+}
+
+func TestStopBuild(t *testing.T) {
+	c := NewBuildController(mq.NewDispatcher())
+	c.ab = &dto.Audiobook{Author: "Author", Title: "Title"}
+	c.ctx, c.cancel = context.WithCancel(context.Background())
+	// the running ffmpeg processes are killed by the build context
+	c.stopBuild(&dto.StopCommand{Process: "Build", Reason: "Timeout"})
+	assert.True(t, c.stopFlag)
+	assert.ErrorIs(t, c.ctx.Err(), context.Canceled)
+}
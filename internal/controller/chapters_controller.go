@@ -93,13 +93,13 @@ func (c *ChaptersController) createChapters(cmd *dto.ChaptersCreate) {
 	for i, file := range c.ab.Mp3Files {
 		filePath := filepath.Join(c.ab.OutputDir, file.FileName)
 		mp3, _ := ffmpeg.NewFFProbe(filePath)
-		chapterFiles = append(chapterFiles, dto.Mp3File{Number: fileNo, FileName: file.FileName, Size: mp3.Size(), Duration: mp3.Duration()})
+		chapterFiles = append(chapterFiles, dto.Mp3File{Number: fileNo, FileName: file.FileName, Size: mp3.Size(), Duration: mp3.Duration(), ItemID: file.ItemID})
 		fileNo++
 		abSize += mp3.Size()
 		abDuration += mp3.Duration()
 		partSize += mp3.Size()
 		partDuration += mp3.Duration()
-		chapter := dto.Chapter{Number: chapterNo, Name: mp3.Title(), Size: mp3.Size(), Duration: mp3.Duration(), Start: offset, End: offset + mp3.Duration(), Files: chapterFiles}
+		chapter := dto.Chapter{Number: chapterNo, Name: mp3.Title(), Size: mp3.Size(), Duration: mp3.Duration(), Start: offset, End: offset + mp3.Duration(), ItemID: file.ItemID, Files: chapterFiles}
 		partChapters = append(partChapters, chapter)
 		c.mq.SendMessage(mq.ChaptersController, mq.ChaptersPage, &dto.AddChapterCommand{Chapter: &chapter}, true)
 		offset += mp3.Duration()
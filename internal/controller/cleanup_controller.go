@@ -9,13 +9,15 @@ import (
 )
 
 type CleanupController struct {
-	mq *mq.Dispatcher
-	ab *dto.Audiobook
+	mq      *mq.Dispatcher
+	ab      *dto.Audiobook
+	history *buildHistory
 }
 
-func NewCleanupController(dispatcher *mq.Dispatcher) *CleanupController {
+func NewCleanupController(dispatcher *mq.Dispatcher, history *buildHistory) *CleanupController {
 	c := &CleanupController{}
 	c.mq = dispatcher
+	c.history = history
 	c.mq.RegisterListener(mq.CleanupController, c.dispatchMessage)
 	return c
 }
@@ -45,16 +47,19 @@ func (c *CleanupController) cleanUp(cmd *dto.CleanupCommand, requestor string) {
 		os.Remove(c.ab.CoverFile)
 
 		for _, part := range c.ab.Parts {
-			os.Remove(part.AACFile)
 			os.Remove(part.FListFile)
 			os.Remove(part.MetadataFile)
 			if c.ab.Config.IsCopyToOutputDir() {
-				os.Remove(part.M4BFile)
+				os.Remove(part.OutputFile)
 			}
 		}
 	}
 
 	if requestor == mq.BuildPage {
+		// the audiobook is built. Remember it
+		if err := c.history.add(newHistoryEntry(c.ab)); err != nil {
+			logger.Error(mq.CleanupController + ": Can't update the build history: " + err.Error())
+		}
 		c.mq.SendMessage(mq.CleanupController, mq.BuildPage, &dto.CleanupComplete{Audiobook: cmd.Audiobook}, true)
 	}
 }
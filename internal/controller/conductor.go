@@ -13,12 +13,14 @@ type controller interface {
 type Conductor struct {
 	dispatcher  *mq.Dispatcher
 	controllers []controller
+	watches     *WatchController
 }
 
 func NewConductor(dispatcher *mq.Dispatcher) *Conductor {
 	c := &Conductor{}
 	c.dispatcher = dispatcher
-	c.controllers = append(c.controllers, NewSearchController(c.dispatcher))
+	history := NewHistoryController(c.dispatcher)
+	c.controllers = append(c.controllers, NewSearchController(c.dispatcher, history.history))
 	c.controllers = append(c.controllers, NewConfigController(c.dispatcher))
 	c.controllers = append(c.controllers, NewDownloadController(c.dispatcher))
 	c.controllers = append(c.controllers, NewEncodingController(c.dispatcher))
@@ -26,7 +28,10 @@ func NewConductor(dispatcher *mq.Dispatcher) *Conductor {
 	c.controllers = append(c.controllers, NewBuildController(c.dispatcher))
 	c.controllers = append(c.controllers, NewCopyController(c.dispatcher))
 	c.controllers = append(c.controllers, NewUploadController(c.dispatcher))
-	c.controllers = append(c.controllers, NewCleanupController(c.dispatcher))
+	c.controllers = append(c.controllers, NewCleanupController(c.dispatcher, history.history))
+	c.watches = NewWatchController(c.dispatcher)
+	c.controllers = append(c.controllers, c.watches)
+	c.controllers = append(c.controllers, history)
 	c.controllers = append(c.controllers, NewBootController(c.dispatcher))
 	return c
 }
@@ -40,6 +45,11 @@ func (c *Conductor) startEventListener() {
 	}
 }
 
+// Check the watches for new items every WatchIntervalHours in the background. Call it before Run
+func (c *Conductor) ScheduleWatchChecks() {
+	c.watches.scheduled = true
+}
+
 func (c *Conductor) Run() {
 	go c.startEventListener()
 }
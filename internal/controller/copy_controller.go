@@ -6,9 +6,10 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
-	"abb_ia/internal/audiobookshelf"
 	"abb_ia/internal/dto"
 	"abb_ia/internal/utils"
 
@@ -25,6 +26,8 @@ type CopyController struct {
 	ab        *dto.Audiobook
 	startTime time.Time
 	stopFlag  bool
+	mu        sync.Mutex // guards errors. The parts are copied concurrently
+	errors    []string
 
 	// progress tracking arrays
 	filesCopy []fileCopy
@@ -92,9 +95,10 @@ func (c *CopyController) startCopy(cmd *dto.CopyCommand) {
 	abSize := int64(0)
 	for i := range c.ab.Parts {
 		part := &c.ab.Parts[i]
-		fileInfo, err := os.Stat(part.M4BFile)
+		fileInfo, err := os.Stat(part.OutputFile)
 		if err != nil {
-			logger.Error("Can't open .mb4 file: " + err.Error())
+			logger.Error("Can't open the audiobook file: " + err.Error())
+			c.mq.SendMessage(mq.CopyController, mq.BuildPage, &dto.ProcessFailed{Process: "Copy", Audiobook: cmd.Audiobook, Error: err.Error()}, true)
 			return
 		}
 		// Get file size in bytes
@@ -109,6 +113,7 @@ func (c *CopyController) startCopy(cmd *dto.CopyCommand) {
 	logger.Info(fmt.Sprintf("Copying the audiobook: %s - %s to %s/...", c.ab.Author, c.ab.Title, c.ab.Config.OutputDir))
 
 	c.stopFlag = false
+	c.errors = []string{}
 	c.filesCopy = make([]fileCopy, len(c.ab.Parts))
 	jd := utils.NewJobDispatcher(c.ab.Config.GetConcurrentDownloaders())
 	for i := range c.ab.Parts {
@@ -119,7 +124,9 @@ func (c *CopyController) startCopy(cmd *dto.CopyCommand) {
 
 	c.mq.SendMessage(mq.CopyController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
 	c.mq.SendMessage(mq.CopyController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
-	if !c.stopFlag {
+	if !c.stopFlag && len(c.errors) > 0 {
+		c.mq.SendMessage(mq.CopyController, mq.BuildPage, &dto.ProcessFailed{Process: "Copy", Audiobook: cmd.Audiobook, Error: strings.Join(c.errors, "; ")}, true)
+	} else if !c.stopFlag {
 		c.mq.SendMessage(mq.CopyController, mq.BuildPage, &dto.CopyComplete{Audiobook: cmd.Audiobook}, true)
 	}
 	c.stopFlag = true
@@ -134,45 +141,51 @@ func (c *CopyController) copyAudiobookPart(ab *dto.Audiobook, partId int) {
 
 	part := &ab.Parts[partId]
 
-	file, err := os.Open(part.M4BFile)
+	file, err := os.Open(part.OutputFile)
 	if err != nil {
-		logger.Error("Can't open .mb4 file: " + err.Error())
+		logger.Error("Can't open the audiobook file: " + err.Error())
+		c.fail(err)
 		return
 	}
 	fileReader := bufio.NewReader(file)
 	defer file.Close()
 
-	// Calculate Audiobookshelf directory structure (see: https://www.audiobookshelf.org/docs#book-directory-structure)
-	destPath := audiobookshelf.GetDestignationPath(ab.Config.GetOutputDir(), ab.Series, ab.Author)
-	destDir := audiobookshelf.GetDestignationDir(ab.Series, ab.SeriesNo, ab.Title, ab.Narrator)
-
-	filePath := filepath.Clean(filepath.Join(destPath, destDir, filepath.Base(part.M4BFile)))
+	filePath := destinationFilePath(ab, part.OutputFile)
 	fullPath := filepath.Dir(filePath)
 
 	if err := os.MkdirAll(fullPath, 0750); err != nil {
 		logger.Error("Can't create output directory: " + err.Error())
+		c.fail(err)
 		return
 	}
 	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		logger.Fatal("Can't create Audiobookshelf .m4b file: " + err.Error())
+		logger.Error("Can't create Audiobookshelf audiobook file: " + err.Error())
+		c.fail(err)
 		return
 	}
 	defer f.Close()
 
 	progressReader := &ProgressReader{
 		FileId:   partId,
-		FileName: part.M4BFile,
+		FileName: part.OutputFile,
 		Reader:   fileReader,
 		Size:     part.Size,
 		Callback: c.updateFileCopyProgress,
 	}
 
 	if _, err := io.Copy(f, progressReader); err != nil {
-		logger.Error("Error while copying .m4b file: " + err.Error())
+		logger.Error("Error while copying the audiobook file: " + err.Error())
+		c.fail(err)
 	}
 }
 
+func (c *CopyController) fail(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errors = append(c.errors, err.Error())
+}
+
 func (c *CopyController) updateFileCopyProgress(fileId int, fileName string, size int64, pos int64, percent int) {
 	if c.filesCopy[fileId].progress != percent {
 
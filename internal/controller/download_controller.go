@@ -2,6 +2,7 @@ package controller
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"time"
 
@@ -15,16 +16,21 @@ import (
 type DownloadController struct {
 	mq        *mq.Dispatcher
 	ab        *dto.Audiobook
+	ia        *ia_client.IAClient
 	startTime time.Time
 	files     []fileDownload
 	stopFlag  bool
 }
 
+// how many times a file is downloaded before giving up on checksum mismatch
+const maxDownloadAttempts = 3
+
 type fileDownload struct {
 	fileId          int
 	fileSize        int64
 	bytesDownloaded int64
 	progress        int
+	failed          bool
 }
 
 func NewDownloadController(dispatcher *mq.Dispatcher) *DownloadController {
@@ -45,6 +51,10 @@ func (c *DownloadController) dispatchMessage(m *mq.Message) {
 	switch dto := m.Dto.(type) {
 	case *dto.DownloadCommand:
 		go c.startDownload(dto)
+	case *dto.RetryDownloadCommand:
+		go c.retryDownload(dto)
+	case *dto.SkipFailedFilesCommand:
+		go c.skipFailedFiles(dto)
 	case *dto.StopCommand:
 		go c.stopDownload(dto)
 	default:
@@ -60,20 +70,36 @@ func (c *DownloadController) stopDownload(cmd *dto.StopCommand) {
 func (c *DownloadController) startDownload(cmd *dto.DownloadCommand) {
 	c.startTime = time.Now()
 
-	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: "Downloading mp3 files..."}, false)
+	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: "Downloading audio files..."}, false)
 	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
 
 	c.ab = cmd.Audiobook
-	item := c.ab.IAItem
+	// order the files using the build ordering policy. The search result item is left intact
+	item := *c.ab.IAItem
+	item.AudioFiles = append([]dto.AudioFile{}, item.AudioFiles...)
+	sortItemFiles(item.AudioFiles, c.ab.GetIAItems(), c.ab.Config.GetFileOrder())
+	c.ab.IAItem = &item
 	c.ab.Author = item.Creator
 	c.ab.Title = item.Title
 	c.ab.Description = item.Description
 	c.ab.CoverURL = item.CoverUrl
 	c.ab.IaURL = item.IaURL
 	c.ab.LicenseUrl = item.LicenseUrl
+	c.ab.Year = item.Year
+	c.ab.Language = item.Language
+	c.ab.Narrator = item.Narrator
+	c.ab.Genre = matchGenre(item.Subjects, c.ab.Config.GetGenres())
 	c.ab.OutputDir = utils.SanitizeFilePath(filepath.Join(c.ab.Config.GetTmpDir(), item.ID))
 	c.ab.TotalSize = item.TotalSize
 	c.ab.TotalDuration = item.TotalLength
+	c.ab.SourceItems = []dto.SourceItem{}
+	for _, i := range c.ab.GetIAItems() {
+		c.ab.SourceItems = append(c.ab.SourceItems, dto.SourceItem{ID: i.ID, Title: i.Title, IaURL: i.IaURL, LicenseUrl: i.LicenseUrl})
+	}
+	c.ab.Copyright = copyright(c.ab.GetLicenses())
+	if len(c.ab.IAItems) > 1 {
+		logger.Info(fmt.Sprintf("Merging %d IA items into one audiobook", len(c.ab.IAItems)))
+	}
 
 	logger.Info(fmt.Sprintf("Downloading IA item: %s - %s...", c.ab.Author, c.ab.Title))
 
@@ -81,25 +107,133 @@ func (c *DownloadController) startDownload(cmd *dto.DownloadCommand) {
 	c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.DisplayBookInfoCommand{Audiobook: c.ab}, true)
 
 	// download files
-	ia := ia_client.New(c.ab.Config.GetRowsPerPage(), c.ab.Config.IsUseMock(), c.ab.Config.IsSaveMock())
-	c.stopFlag = false
+	c.ia = newIAClient(c.ab.Config)
+	if limit := c.ia.RateLimiter().Limit(); limit > 0 {
+		logger.Info("Download speed is limited to " + utils.SpeedToHuman(limit))
+	}
 	c.files = make([]fileDownload, len(item.AudioFiles))
-	jd := utils.NewJobDispatcher(c.ab.Config.GetConcurrentDownloaders())
+	fileIds := []int{}
 	for i, iaFile := range item.AudioFiles {
-		localFileName := utils.SanitizeFilePath(filepath.Join(item.Dir, iaFile.Name))
-		c.ab.Mp3Files = append(c.ab.Mp3Files, dto.Mp3File{Number: i, FileName: localFileName, Size: iaFile.Size, Duration: iaFile.Length})
-		jd.AddJob(i, ia.DownloadFile, c.ab.OutputDir, localFileName, item.Server, item.Dir, iaFile.Name, i, iaFile.Size, c.updateFileProgress)
+		source := c.ab.GetIAItem(iaFile.ItemID)
+		localFileName := utils.SanitizeFilePath(iaFile.Name)
+		if len(c.ab.IAItems) > 1 {
+			// files of the merged items are kept in the <item ID> directories so the files having the same name don't clash
+			localFileName = utils.SanitizeFilePath(filepath.Join(source.ID, iaFile.Name))
+		}
+		c.ab.Mp3Files = append(c.ab.Mp3Files, dto.Mp3File{Number: i, FileName: localFileName, Size: iaFile.Size, Duration: iaFile.Length, Md5: iaFile.Md5, Sha1: iaFile.Sha1, Crc32: iaFile.Crc32, ItemID: source.ID})
+		fileIds = append(fileIds, i)
+	}
+	c.downloadFiles(fileIds)
+}
+
+func (c *DownloadController) retryDownload(cmd *dto.RetryDownloadCommand) {
+	fileIds := []int{}
+	for i, f := range c.files {
+		if f.failed {
+			c.files[i] = fileDownload{progress: -1}
+			fileIds = append(fileIds, i)
+			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.FileVerificationResult{FileId: i, FileName: c.ab.IAItem.AudioFiles[i].Name, Status: ""}, false)
+		}
+	}
+	logger.Info(fmt.Sprintf("Retrying download of %d failed files", len(fileIds)))
+	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: "Downloading audio files..."}, false)
+	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
+	c.downloadFiles(fileIds)
+}
+
+func (c *DownloadController) skipFailedFiles(cmd *dto.SkipFailedFilesCommand) {
+	mp3Files := []dto.Mp3File{}
+	for _, f := range c.ab.Mp3Files {
+		if c.files[f.Number].failed {
+			logger.Warn(mq.DownloadController + ": Skipping failed file " + f.FileName)
+			c.ab.TotalSize -= f.Size
+			c.ab.TotalDuration -= f.Duration
+			os.Remove(filepath.Join(c.ab.OutputDir, f.FileName))
+		} else {
+			mp3Files = append(mp3Files, f)
+		}
+	}
+	c.ab.Mp3Files = mp3Files
+	c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.DownloadComplete{Audiobook: c.ab}, true)
+}
+
+// download the files in parallel and report the result to the DownloadPage
+func (c *DownloadController) downloadFiles(fileIds []int) {
+	item := c.ab.IAItem
+	c.stopFlag = false
+	jd := utils.NewJobDispatcher(c.ab.Config.GetConcurrentDownloaders())
+	for _, i := range fileIds {
+		source := c.ab.GetIAItem(item.AudioFiles[i].ItemID)
+		jd.AddJob(i, c.downloadFile, source.Server, source.Dir, item.AudioFiles[i].Name, c.ab.Mp3Files[i])
 	}
 	go c.updateTotalProgress()
 
 	jd.Start()
 
+	stopped := c.stopFlag
+	c.stopFlag = true
 	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
-	c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
-	if !c.stopFlag {
-		c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.DownloadComplete{Audiobook: cmd.Audiobook}, true)
+	if stopped {
+		c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
+		return
+	}
+
+	failedFiles := []string{}
+	for _, i := range fileIds {
+		if c.files[i].failed {
+			failedFiles = append(failedFiles, item.AudioFiles[i].Name)
+		}
+	}
+	if len(failedFiles) > 0 {
+		logger.Error(fmt.Sprintf("%s: %d files failed to download", mq.DownloadController, len(failedFiles)))
+		c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: fmt.Sprintf("%d file(s) failed to download", len(failedFiles))}, false)
+		c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.DownloadFailed{Audiobook: c.ab, Files: failedFiles}, true)
+	} else {
+		c.mq.SendMessage(mq.DownloadController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
+		c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.DownloadComplete{Audiobook: c.ab}, true)
 	}
-	c.stopFlag = true
+}
+
+// download a file and verify it against IA checksums. Re-download the file on checksum mismatch
+func (c *DownloadController) downloadFile(iaServer string, iaDir string, iaFile string, mp3File dto.Mp3File) {
+	localPath := filepath.Join(c.ab.OutputDir, mp3File.FileName)
+	for attempt := 1; attempt <= maxDownloadAttempts; attempt++ {
+		if c.stopFlag {
+			return
+		}
+		err := c.ia.DownloadFile(c.ab.OutputDir, mp3File.FileName, iaServer, iaDir, iaFile, mp3File.Number, mp3File.Size, c.updateFileProgress)
+		if c.stopFlag {
+			return
+		}
+		if err != nil {
+			c.fileFailed(mp3File.Number, iaFile, err.Error())
+			return
+		}
+		if c.ab.Config.IsUseMock() {
+			return
+		}
+		if mp3File.Md5 == "" && mp3File.Sha1 == "" && mp3File.Crc32 == "" {
+			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.FileVerificationResult{FileId: mp3File.Number, FileName: iaFile, Status: "n/a"}, false)
+			return
+		}
+		err = utils.VerifyChecksum(localPath, mp3File.Md5, mp3File.Sha1, mp3File.Crc32)
+		if err == nil {
+			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.FileVerificationResult{FileId: mp3File.Number, FileName: iaFile, Status: "OK"}, false)
+			return
+		}
+		logger.Warn(fmt.Sprintf("%s: %s verification failed (attempt %d of %d): %s", mq.DownloadController, iaFile, attempt, maxDownloadAttempts, err.Error()))
+		os.Remove(localPath)
+		if attempt < maxDownloadAttempts {
+			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.FileVerificationResult{FileId: mp3File.Number, FileName: iaFile, Status: fmt.Sprintf("Retry %d", attempt)}, false)
+		}
+	}
+	c.fileFailed(mp3File.Number, iaFile, "checksum mismatch")
+}
+
+func (c *DownloadController) fileFailed(fileId int, fileName string, reason string) {
+	logger.Error(mq.DownloadController + ": Can't download " + fileName + ": " + reason)
+	c.files[fileId].failed = true
+	c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.FileDownloadError{FileId: fileId, FileName: fileName, Error: reason}, false)
 }
 
 func (c *DownloadController) updateFileProgress(fileId int, fileName string, size int64, pos int64, percent int) {
@@ -170,8 +304,12 @@ func (c *DownloadController) updateTotalProgress() {
 			filesH := fmt.Sprintf("%d/%d", filesDownloaded, len(item.AudioFiles))
 			speedH := utils.SpeedToHuman(speed)
 			etaH := utils.SecondsToTime(eta)
+			limitH := ""
+			if limit := c.ia.RateLimiter().Limit(); limit > 0 {
+				limitH = utils.SpeedToHuman(limit)
+			}
 
-			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.TotalDownloadProgress{Elapsed: elapsedH, Percent: percent, Files: filesH, Bytes: bytesH, Speed: speedH, ETA: etaH}, false)
+			c.mq.SendMessage(mq.DownloadController, mq.DownloadPage, &dto.TotalDownloadProgress{Elapsed: elapsedH, Percent: percent, Files: filesH, Bytes: bytesH, Speed: speedH, Limit: limitH, ETA: etaH}, false)
 		}
 		time.Sleep(mq.PullFrequency)
 	}
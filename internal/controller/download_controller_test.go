@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloadMergedItems(t *testing.T) {
+	// both parts have the files with the same names
+	h := newHarness(t,
+		fakeia.Item{Identifier: "fake_serial_part2", Title: "Fake Serial Part 2", Creator: "Fake Serial", Licenseurl: "http://creativecommons.org/licenses/by/4.0/",
+			Files: []fakeia.File{{Name: "chapter1.mp3", Format: "VBR MP3", Length: "60", Size: 2048}}},
+		fakeia.Item{Identifier: "fake_serial_part1", Title: "Fake Serial Part 1", Creator: "Fake Serial", Licenseurl: "http://creativecommons.org/publicdomain/mark/1.0/",
+			Files: []fakeia.File{{Name: "chapter1.mp3", Format: "VBR MP3", Length: "60", Size: 1024}, {Name: "chapter2.mp3", Format: "VBR MP3", Length: "60", Size: 1024}}},
+	)
+	config.Instance().SetRowsPerPage(12)
+	config.Instance().SetTmpDir(t.TempDir())
+
+	items := map[string]*dto.IAItem{}
+	for _, item := range h.search(dto.SearchCondition{Author: "Fake Serial"}) {
+		items[item.ID] = item
+	}
+	assert.Equal(t, 2, len(items))
+
+	ab := &dto.Audiobook{}
+	ab.IAItems = []*dto.IAItem{items["fake_serial_part1"], items["fake_serial_part2"]}
+	ab.IAItem = dto.MergeIAItems(ab.IAItems)
+	ab.Config = h.config()
+	assert.Equal(t, "Fake Serial Part 1", ab.IAItem.Title)
+	assert.Equal(t, 3, len(ab.IAItem.AudioFiles))
+	assert.Equal(t, int64(4096), ab.IAItem.TotalSize)
+
+	NewDownloadController(h.mq).startDownload(&dto.DownloadCommand{Audiobook: ab})
+	var complete *dto.DownloadComplete
+	for _, m := range h.received(mq.DownloadPage) {
+		if c, ok := m.(*dto.DownloadComplete); ok {
+			complete = c
+		}
+	}
+	if assert.NotNil(t, complete) {
+		assert.Equal(t, 3, len(ab.Mp3Files))
+		expected := []string{"fake_serial_part1", "fake_serial_part1", "fake_serial_part2"}
+		for i, f := range ab.Mp3Files {
+			assert.Equal(t, expected[i], f.ItemID)
+			fi, err := os.Stat(filepath.Join(ab.OutputDir, f.FileName))
+			if assert.NoError(t, err) {
+				assert.Equal(t, f.Size, fi.Size())
+			}
+		}
+		// the files with the same name are kept in the item directories
+		assert.Equal(t, filepath.Join("fake_serial_part1", "chapter1.mp3"), ab.Mp3Files[0].FileName)
+		assert.Equal(t, filepath.Join("fake_serial_part2", "chapter1.mp3"), ab.Mp3Files[2].FileName)
+		assert.Equal(t, 2, len(ab.SourceItems))
+		assert.Equal(t, []string{"http://creativecommons.org/publicdomain/mark/1.0/", "http://creativecommons.org/licenses/by/4.0/"}, ab.GetLicenses())
+	}
+}
@@ -2,13 +2,12 @@ package controller
 
 import (
 	"fmt"
-	"net"
 	"os"
 	"path/filepath"
-	"strconv"
+	"strings"
+	"sync"
 	"time"
 
-	"abb_ia/internal/config"
 	"abb_ia/internal/dto"
 	"abb_ia/internal/ffmpeg"
 	"abb_ia/internal/logger"
@@ -22,6 +21,8 @@ type EncodingController struct {
 	startTime time.Time
 	files     []fileEncode
 	stopFlag  bool
+	mu        sync.Mutex // guards failed. The files are encoded concurrently
+	failed    []string   // the files failed to encode
 }
 
 // progress tracking arrays
@@ -35,6 +36,7 @@ type fileEncode struct {
 	encodingSpeed    float64
 	progress         int
 	complete         bool
+	trimmed          float64 // seconds of silence trimmed
 }
 
 func NewEncodingController(dispatcher *mq.Dispatcher) *EncodingController {
@@ -73,12 +75,13 @@ func (c *EncodingController) startEncoding(cmd *dto.EncodeCommand) {
 	c.ab = cmd.Audiobook
 	c.stopFlag = false
 	c.files = make([]fileEncode, len(c.ab.Mp3Files))
+	c.failed = []string{}
 
 	c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.DisplayBookInfoCommand{Audiobook: c.ab}, true)
-	c.mq.SendMessage(mq.EncodingController, mq.Footer, &dto.UpdateStatus{Message: "Re-encoding mp3 files..."}, false)
+	c.mq.SendMessage(mq.EncodingController, mq.Footer, &dto.UpdateStatus{Message: "Re-encoding audio files..."}, false)
 	c.mq.SendMessage(mq.EncodingController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
 
-	logger.Info(fmt.Sprintf("Re-encoding mp3 files: %s - %s...", c.ab.Author, c.ab.Title))
+	logger.Info(fmt.Sprintf("Re-encoding audio files: %s - %s...", c.ab.Author, c.ab.Title))
 
 	// re-encode files
 	jd := utils.NewJobDispatcher(c.ab.Config.GetConcurrentEncoders())
@@ -94,9 +97,17 @@ func (c *EncodingController) startEncoding(cmd *dto.EncodeCommand) {
 	go c.updateTotalProgress()
 	jd.Start()
 
+	for _, f := range c.files {
+		c.ab.TotalDuration -= f.trimmed
+	}
+
 	c.mq.SendMessage(mq.EncodingController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
 	c.mq.SendMessage(mq.EncodingController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
-	if !c.stopFlag {
+	if !c.stopFlag && len(c.failed) > 0 {
+		// don't build an audiobook of broken files
+		message := fmt.Sprintf("%d file(s) failed to encode: %s", len(c.failed), strings.Join(c.failed, ", "))
+		c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.ProcessFailed{Process: "Encoding", Audiobook: cmd.Audiobook, Error: message}, true)
+	} else if !c.stopFlag {
 		c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingComplete{Audiobook: cmd.Audiobook}, true)
 	}
 	c.stopFlag = true
@@ -109,34 +120,134 @@ func (c *EncodingController) encodeFile(fileId int, outputDir string) {
 
 	filePath := c.files[fileId].filePath
 	tmpFile := filePath + ".tmp"
+	duration := c.files[fileId].totalDuration
 
-	// launch progress listener
-	l, port := c.startProgressListener(fileId)
-	defer l.Close()
-	go c.updateFileProgress(fileId, l)
+	// two-pass processing: find the silence to trim and measure the loudness first, then apply the filters while encoding
+	normalize := c.ab.Config.IsNormalizeLoudness()
+	trim := c.ab.Config.IsTrimSilence()
+	filters := []string{}
+	loudnorm := false
+	var trimmed float64 = 0
+	if normalize || trim {
+		log, err := c.analyzeFile(fileId, normalize, trim)
+		if c.stopFlag {
+			return
+		}
+		if err != nil {
+			logger.Warn("Can't analyze " + filePath + ": " + err.Error())
+		}
+		if err == nil && trim {
+			start, end := ffmpeg.TrimRange(ffmpeg.ParseSilences(log), duration, c.ab.Config.GetSilenceMaxTrim())
+			if start > 0 || end < duration {
+				filters = append(filters, ffmpeg.TrimFilter(start, end))
+				trimmed = duration - (end - start)
+			}
+		}
+		if err == nil && normalize {
+			measured, err := ffmpeg.ParseLoudness(log)
+			if err != nil {
+				logger.Warn("Can't measure the loudness of " + filePath + ": " + err.Error())
+			} else if !measured.IsValid() {
+				logger.Warn("The loudness of " + filePath + " can't be normalized (silence?)")
+			} else {
+				filters = append(filters, ffmpeg.LoudnormFilter(c.ab.Config.GetTargetLoudness(), c.ab.Config.GetTargetTruePeak(), measured))
+				loudnorm = true
+				c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingFileLoudness{FileId: fileId, Measured: measured.InputI}, true)
+			}
+		}
+	}
+	filter := ""
+	if len(filters) > 0 {
+		filter = "-af " + strings.Join(filters, ",") + " "
+	}
+	logLevel := "error"
+	if loudnorm {
+		// loudnorm prints the stats at info level
+		logLevel = "info"
+	}
 
 	// launch ffmpeg process
-	ffmpeg := ffmpeg.NewFFmpeg().
-		Input(filePath, "-f mp3").
-		Output(tmpFile, fmt.Sprintf("-f mp3 -ab %dk -ar %d -vn", c.ab.Config.GetBitRate(), c.ab.Config.GetSampleRate())).
+	encoder := ffmpeg.NewFFmpeg().
+		Input(filePath, decodingParams(filePath)).
+		Output(tmpFile, filter+encodingParams(filePath, c.ab.Config.GetBitRate(), c.ab.Config.GetSampleRate())).
 		Overwrite(true).
-		Params("-hide_banner -nostdin -nostats -loglevel error").
-		SendProgressTo("http://127.0.0.1:" + strconv.Itoa(port))
+		Params("-hide_banner -nostdin -nostats -loglevel " + logLevel).
+		OnProgress(func(p ffmpeg.Progress) {
+			if trimmed > 0 {
+				// the output is shorter than the input
+				p.Seconds = p.Seconds * duration / (duration - trimmed)
+			}
+			if normalize || trim {
+				// the second half of the file progress
+				p.Seconds = (duration + p.Seconds) / 2
+			}
+			c.updateFileProgress(fileId, p)
+		})
 
-	go c.killSwitch(ffmpeg)
-	_, err := ffmpeg.Run()
+	go c.killSwitch(encoder)
+	_, err := encoder.Run()
 	if err != nil && !c.stopFlag {
 		logger.Error("FFMPEG Error: " + string(err.Error()))
-	} else {
+		c.fail(fileId)
+	} else if err == nil {
+		if loudnorm {
+			if l, err := ffmpeg.ParseLoudness(encoder.Stderr()); err == nil {
+				c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingFileLoudness{FileId: fileId, Measured: l.InputI, Corrected: l.OutputI, Normalized: true}, true)
+			}
+		}
 		err := os.Remove(filePath)
 		if err != nil {
 			logger.Error("Can't delete file " + filePath + ": " + err.Error())
+			c.fail(fileId)
+		} else if err := os.Rename(tmpFile, filePath); err != nil {
+			logger.Error("Can't rename file " + tmpFile + ": " + err.Error())
+			c.fail(fileId)
 		} else {
-			os.Rename(tmpFile, filePath)
+			if trimmed > 0 {
+				// the chapters are calculated from the trimmed file durations
+				c.files[fileId].trimmed = trimmed
+				c.ab.Mp3Files[fileId].Duration = duration - trimmed
+				logger.Debug(fmt.Sprintf("Trimmed %.1fs of silence: %s", trimmed, filePath))
+				c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingFileTrimmed{FileId: fileId, Trimmed: trimmed, Duration: duration - trimmed}, true)
+			}
 		}
 	}
 }
 
+// The first pass: detect the leading and trailing silence and/or measure the loudness.
+// Returns the ffmpeg log with the filter stats. The file progress goes to 50%
+func (c *EncodingController) analyzeFile(fileId int, normalize bool, trim bool) (string, error) {
+	filePath := c.files[fileId].filePath
+	filters := []string{}
+	if trim {
+		filters = append(filters, ffmpeg.SilenceDetectFilter(c.ab.Config.GetSilenceThreshold(), c.ab.Config.GetSilenceMinDuration()))
+	}
+	if normalize {
+		filters = append(filters, ffmpeg.LoudnormFilter(c.ab.Config.GetTargetLoudness(), c.ab.Config.GetTargetTruePeak(), nil))
+	}
+	analyzer := ffmpeg.NewFFmpeg().
+		Input(filePath, decodingParams(filePath)).
+		Output("-", "-af "+strings.Join(filters, ",")+" -vn -f null").
+		Params("-hide_banner -nostdin -nostats -loglevel info").
+		OnProgress(func(p ffmpeg.Progress) {
+			p.Seconds = p.Seconds / 2
+			p.Complete = false
+			c.updateFileProgress(fileId, p)
+		})
+
+	go c.killSwitch(analyzer)
+	if _, err := analyzer.Run(); err != nil {
+		return "", err
+	}
+	return analyzer.Stderr(), nil
+}
+
+func (c *EncodingController) fail(fileId int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.failed = append(c.failed, c.files[fileId].fileName)
+}
+
 func (c *EncodingController) killSwitch(ffmpeg *ffmpeg.FFmpeg) {
 	for !c.stopFlag {
 		time.Sleep(mq.PullFrequency)
@@ -144,52 +255,30 @@ func (c *EncodingController) killSwitch(ffmpeg *ffmpeg.FFmpeg) {
 	ffmpeg.Kill()
 }
 
-func (c *EncodingController) startProgressListener(fileId int) (net.Listener, int) {
-	portNumber := config.Instance().GetBasePortNumber() + fileId
-	l, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(portNumber))
-	if err != nil {
-		logger.Error("Encoding progress: Start listener error: " + err.Error())
+func (c *EncodingController) updateFileProgress(fileId int, p ffmpeg.Progress) {
+	if c.stopFlag {
+		return
 	}
-	return l, portNumber
-}
-
-func (c *EncodingController) updateFileProgress(fileId int, l net.Listener) {
-	fd, err := l.Accept()
-	if err != nil {
-		return // listener may be closed already
+	percent := int(p.Seconds / c.files[fileId].totalDuration * 100)
+	// wrong calculation protection
+	if percent < 0 {
+		percent = 0
+	} else if percent > 100 {
+		percent = 100
+	} else if percent < c.files[fileId].progress {
+		percent = c.files[fileId].progress
+	} else if p.Complete {
+		percent = 100
 	}
-	buf := make([]byte, 16)
-	data := ""
-	percent := 0
 
-	for !c.stopFlag {
-		_, err := fd.Read(buf)
-		if err != nil {
-			return // listener is closed
-		}
-		data += string(buf)
-		bytesProcessed, secondsProcessed, encodingSpeed, complete := ffmpeg.ParseFFMPEGProgress(data)
-		percent = int(secondsProcessed / c.files[fileId].totalDuration * 100)
-		// wrong calculation protection
-		if percent < 0 {
-			percent = 0
-		} else if percent > 100 {
-			percent = 100
-		} else if percent < c.files[fileId].progress {
-			percent = c.files[fileId].progress
-		} else if complete {
-			percent = 100
-		}
-
-		// sent a message only if progress changed
-		if percent != c.files[fileId].progress {
-			c.files[fileId].bytesProcessed = bytesProcessed
-			c.files[fileId].secondsProcessed = secondsProcessed
-			c.files[fileId].encodingSpeed = encodingSpeed
-			c.files[fileId].progress = percent
-			c.files[fileId].complete = complete
-			c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingFileProgress{FileId: fileId, FileName: c.files[fileId].fileName, Percent: percent}, true)
-		}
+	// sent a message only if progress changed
+	if percent != c.files[fileId].progress {
+		c.files[fileId].bytesProcessed = p.Bytes
+		c.files[fileId].secondsProcessed = p.Seconds
+		c.files[fileId].encodingSpeed = p.Speed
+		c.files[fileId].progress = percent
+		c.files[fileId].complete = p.Complete
+		c.mq.SendMessage(mq.EncodingController, mq.EncodingPage, &dto.EncodingFileProgress{FileId: fileId, FileName: c.files[fileId].fileName, Percent: percent}, true)
 	}
 }
 
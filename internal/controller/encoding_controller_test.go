@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodingFailure(t *testing.T) {
+	ab := &dto.Audiobook{OutputDir: t.TempDir()}
+	conf := config.Instance().GetCopy()
+	conf.SetNormalizeLoudness(false)
+	conf.SetTrimSilence(false)
+	ab.Config = &conf
+	ab.Mp3Files = []dto.Mp3File{{Number: 1, FileName: "01.mp3"}}
+	// not an mp3 file. ffmpeg fails to decode it (or isn't installed at all)
+	assert.NoError(t, os.WriteFile(filepath.Join(ab.OutputDir, "01.mp3"), []byte("not an mp3"), 0644))
+
+	d := mq.NewDispatcher()
+	c := NewEncodingController(d)
+	c.startEncoding(&dto.EncodeCommand{Audiobook: ab})
+
+	// the pipeline stops instead of building a book of the broken files
+	var failed *dto.ProcessFailed
+	for m := d.GetMessage(mq.EncodingPage); m != nil; m = d.GetMessage(mq.EncodingPage) {
+		_, complete := m.Dto.(*dto.EncodingComplete)
+		assert.False(t, complete)
+		if f, ok := m.Dto.(*dto.ProcessFailed); ok {
+			failed = f
+		}
+	}
+	if assert.NotNil(t, failed) {
+		assert.Equal(t, "Encoding", failed.Process)
+		assert.Contains(t, failed.Error, "01.mp3")
+	}
+	// the source file is kept
+	_, err := os.Stat(filepath.Join(ab.OutputDir, "01.mp3"))
+	assert.NoError(t, err)
+}
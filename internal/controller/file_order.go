@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+
+	"abb_ia/internal/dto"
+	"abb_ia/internal/utils"
+)
+
+const (
+	FileOrderTrack = "Track number"
+	FileOrderName  = "File name"
+)
+
+var trackNumberRe = regexp.MustCompile(`^\s*(\d+)`)
+
+// sort the audio files in the playback order using the ordering policy (see config.GetFileOrderOptions)
+func sortAudioFiles(files []dto.AudioFile, order string) {
+	sort.SliceStable(files, func(i, j int) bool { return compareFiles(files[i], files[j], order) })
+}
+
+// sort the files of every item using the ordering policy. The items order (a merged book) is kept
+func sortItemFiles(files []dto.AudioFile, items []*dto.IAItem, order string) {
+	itemIndex := map[string]int{}
+	for i, item := range items {
+		itemIndex[item.ID] = i
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		ii, ij := itemIndex[files[i].ItemID], itemIndex[files[j].ItemID]
+		if ii != ij {
+			return ii < ij
+		}
+		return compareFiles(files[i], files[j], order)
+	})
+}
+
+// by track number, then by disc (album), then by file name in the natural order (2 < 10).
+// Files having a track number go first
+func compareFiles(f1 dto.AudioFile, f2 dto.AudioFile, order string) bool {
+	if order == FileOrderTrack {
+		t1, ok1 := trackNumber(f1.Track)
+		t2, ok2 := trackNumber(f2.Track)
+		if ok1 && ok2 && t1 != t2 {
+			return t1 < t2
+		}
+		if ok1 != ok2 {
+			return ok1
+		}
+		if f1.Album != f2.Album {
+			return utils.CompareNaturalOrder(f1.Album, f2.Album)
+		}
+	}
+	return utils.CompareNaturalOrder(f1.Name, f2.Name)
+}
+
+// "3", "03" and "3/12" are all track 3
+func trackNumber(track string) (int, bool) {
+	m := trackNumberRe.FindStringSubmatch(track)
+	if m == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"testing"
+
+	"abb_ia/internal/dto"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(files []dto.AudioFile) []string {
+	result := []string{}
+	for _, f := range files {
+		result = append(result, f.Name)
+	}
+	return result
+}
+
+func TestSortAudioFiles(t *testing.T) {
+	files := []dto.AudioFile{
+		{Name: "a_last.mp3", Track: "3/3"},
+		{Name: "no_track.mp3"},
+		{Name: "c_first.mp3", Track: "01"},
+		{Name: "b_second.mp3", Track: "2"},
+	}
+	sortAudioFiles(files, FileOrderTrack)
+	assert.Equal(t, []string{"c_first.mp3", "b_second.mp3", "a_last.mp3", "no_track.mp3"}, names(files))
+
+	sortAudioFiles(files, FileOrderName)
+	assert.Equal(t, []string{"a_last.mp3", "b_second.mp3", "c_first.mp3", "no_track.mp3"}, names(files))
+
+	// the same track numbers on different discs
+	files = []dto.AudioFile{
+		{Name: "d2t1.mp3", Track: "1", Album: "Part 2"},
+		{Name: "d10t1.mp3", Track: "1", Album: "Part 10"},
+		{Name: "d1t1.mp3", Track: "1", Album: "Part 1"},
+	}
+	sortAudioFiles(files, FileOrderTrack)
+	assert.Equal(t, []string{"d1t1.mp3", "d2t1.mp3", "d10t1.mp3"}, names(files))
+
+	// no track metadata. Natural file name order
+	files = []dto.AudioFile{{Name: "Part 10.mp3"}, {Name: "Part 2.mp3"}, {Name: "Part 1.mp3"}}
+	sortAudioFiles(files, FileOrderTrack)
+	assert.Equal(t, []string{"Part 1.mp3", "Part 2.mp3", "Part 10.mp3"}, names(files))
+}
+
+func TestSortItemFiles(t *testing.T) {
+	items := []*dto.IAItem{{ID: "part1"}, {ID: "part2"}}
+	files := []dto.AudioFile{
+		{Name: "01.mp3", Track: "1", ItemID: "part2"},
+		{Name: "02.mp3", Track: "2", ItemID: "part1"},
+		{Name: "01.mp3", Track: "1", ItemID: "part1"},
+	}
+	sortItemFiles(files, items, FileOrderTrack)
+	assert.Equal(t, "part1", files[0].ItemID)
+	assert.Equal(t, "1", files[0].Track)
+	assert.Equal(t, "part1", files[1].ItemID)
+	assert.Equal(t, "2", files[1].Track)
+	assert.Equal(t, "part2", files[2].ItemID)
+}
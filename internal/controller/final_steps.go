@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"path/filepath"
+
+	"abb_ia/internal/audiobookshelf"
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+)
+
+// A final operation of the build: the controller and its command
+type FinalStep struct {
+	Name       string // Copy, Upload, Scan or Cleanup
+	Controller string
+	Command    dto.Dto
+}
+
+// the chain of the final operations in order. The disabled ones are skipped
+var finalSteps = []struct {
+	name       string
+	controller string
+	enabled    func(c *config.Config) bool
+	command    func(ab *dto.Audiobook) dto.Dto
+}{
+	{"Copy", mq.CopyController, (*config.Config).IsCopyToOutputDir, func(ab *dto.Audiobook) dto.Dto { return &dto.CopyCommand{Audiobook: ab} }},
+	{"Upload", mq.UploadController, (*config.Config).IsUploadToAudiobookshef, func(ab *dto.Audiobook) dto.Dto { return &dto.AbsUploadCommand{Audiobook: ab} }},
+	{"Scan", mq.UploadController, (*config.Config).IsScanAudiobookshef, func(ab *dto.Audiobook) dto.Dto { return &dto.AbsScanCommand{Audiobook: ab} }},
+	{"Cleanup", mq.CleanupController, func(c *config.Config) bool { return true }, func(ab *dto.Audiobook) dto.Dto { return &dto.CleanupCommand{Audiobook: ab} }},
+}
+
+/**
+ * The operations after the audiobook is built: ?Copy -> ?Upload -> ?Scan -> Cleanup.
+ * Returns the next operation after the completed one (BuildComplete, CopyComplete, UploadComplete or ScanComplete).
+ * Used by the BuildPage and the headless Runner, so the TUI and headless builds do the same
+ **/
+func NextFinalStep(completed dto.Dto) (FinalStep, bool) {
+	var ab *dto.Audiobook
+	var next int
+	switch c := completed.(type) {
+	case *dto.BuildComplete:
+		ab, next = c.Audiobook, 0
+	case *dto.CopyComplete:
+		ab, next = c.Audiobook, 1
+	case *dto.UploadComplete:
+		ab, next = c.Audiobook, 2
+	case *dto.ScanComplete:
+		ab, next = c.Audiobook, 3
+	default:
+		return FinalStep{}, false
+	}
+	for _, s := range finalSteps[next:] {
+		if s.enabled(ab.Config) {
+			return FinalStep{Name: s.name, Controller: s.controller, Command: s.command(ab)}, true
+		}
+	}
+	return FinalStep{}, false
+}
+
+// where the audiobook file is after the build is complete
+func OutputFilePath(ab *dto.Audiobook, outputFile string) string {
+	if !ab.Config.IsCopyToOutputDir() {
+		return outputFile
+	}
+	return destinationFilePath(ab, outputFile)
+}
+
+// the audiobook file in the output directory. Audiobookshelf directory structure (see: https://www.audiobookshelf.org/docs#book-directory-structure)
+func destinationFilePath(ab *dto.Audiobook, outputFile string) string {
+	destPath := audiobookshelf.GetDestignationPath(ab.Config.GetOutputDir(), ab.Series, ab.Author)
+	destDir := audiobookshelf.GetDestignationDir(ab.Series, ab.SeriesNo, ab.Title, ab.Narrator)
+	return filepath.Clean(filepath.Join(destPath, destDir, filepath.Base(outputFile)))
+}
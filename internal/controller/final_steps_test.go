@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextFinalStep(t *testing.T) {
+	conf := config.Instance().GetCopy()
+	ab := &dto.Audiobook{Config: &conf}
+	tests := []struct {
+		copy, upload, scan bool
+		completed          dto.Dto
+		want               string
+		controller         string
+	}{
+		{true, true, true, &dto.BuildComplete{Audiobook: ab}, "Copy", mq.CopyController},
+		{false, true, true, &dto.BuildComplete{Audiobook: ab}, "Upload", mq.UploadController},
+		{false, false, true, &dto.BuildComplete{Audiobook: ab}, "Scan", mq.UploadController},
+		{false, false, false, &dto.BuildComplete{Audiobook: ab}, "Cleanup", mq.CleanupController},
+		{true, true, true, &dto.CopyComplete{Audiobook: ab}, "Upload", mq.UploadController},
+		{true, false, true, &dto.CopyComplete{Audiobook: ab}, "Scan", mq.UploadController},
+		{true, true, true, &dto.UploadComplete{Audiobook: ab}, "Scan", mq.UploadController},
+		{true, true, true, &dto.ScanComplete{Audiobook: ab}, "Cleanup", mq.CleanupController},
+	}
+	for _, tt := range tests {
+		conf.SetCopyToOutputDir(tt.copy)
+		conf.SetUploadToAudiobookshelf(tt.upload)
+		conf.SetScanAudiobookshelf(tt.scan)
+		step, ok := NextFinalStep(tt.completed)
+		assert.True(t, ok)
+		assert.Equal(t, tt.want, step.Name, tt.completed.String())
+		assert.Equal(t, tt.controller, step.Controller)
+	}
+
+	_, ok := NextFinalStep(&dto.CleanupComplete{Audiobook: ab})
+	assert.False(t, ok)
+}
+
+func TestOutputFilePath(t *testing.T) {
+	conf := config.Instance().GetCopy()
+	conf.SetOutputdDir("output")
+	ab := &dto.Audiobook{Config: &conf, Author: "Author", Title: "Title"}
+	outputFile := filepath.Join("tmp", "Author - Title.m4b")
+
+	conf.SetCopyToOutputDir(false)
+	assert.Equal(t, outputFile, OutputFilePath(ab, outputFile))
+	conf.SetCopyToOutputDir(true)
+	assert.Equal(t, filepath.Join("output", "Author", "Title", "Author - Title.m4b"), OutputFilePath(ab, outputFile))
+}
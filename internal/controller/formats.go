@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"abb_ia/internal/dto"
+)
+
+var (
+	// audio format list ranged by priority (the last one is the best).
+	// MP3 is preferred since it's the most common IA derivative. Other codecs are used if an item has no MP3 files.
+	// Lossless formats are the last resort because of their size
+	AudioFormats = []string{
+		"WAVE", "AIFF", "24bit Flac", "Flac", "Apple Lossless Audio",
+		"Opus", "AAC", "M4A", "Ogg Vorbis",
+		"16Kbps MP3", "24Kbps MP3", "32Kbps MP3", "40Kbps MP3", "48Kbps MP3", "56Kbps MP3", "64Kbps MP3", "80Kbps MP3", "96Kbps MP3", "112Kbps MP3", "128Kbps MP3", "144Kbps MP3", "160Kbps MP3", "224Kbps MP3", "256Kbps MP3", "320Kbps MP3", "VBR MP3",
+	}
+	// audiobook cover formats
+	CoverFormats = []string{"JPEG", "JPEG Thumb"}
+	// typical bit rates of the lossless formats, kbps. IA often has no length of the lossless files
+	losslessBitRates = map[string]int{"WAVE": 1411, "AIFF": 1411, "24bit Flac": 2000, "Flac": 800, "Apple Lossless Audio": 800}
+)
+
+// estimate the length (seconds) of a lossless file by its size. 0 if the format is not lossless
+func estimatedLength(format string, size int64) float64 {
+	bitRate, ok := losslessBitRates[format]
+	if !ok {
+		return 0
+	}
+	return float64(size) * 8 / 1000 / float64(bitRate)
+}
+
+// ffmpeg input parameters for an audio file
+func decodingParams(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".mp3":
+		return "-f mp3"
+	default:
+		// let ffmpeg detect the format
+		return ""
+	}
+}
+
+// ffmpeg output parameters to re-encode an audio file to the same codec and container
+func encodingParams(fileName string, bitRate int, sampleRate int) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".ogg", ".oga":
+		return fmt.Sprintf("-f ogg -c:a libvorbis -ab %dk -ar %d -vn", bitRate, sampleRate)
+	case ".opus":
+		// opus supports 48kHz sample rate only
+		return fmt.Sprintf("-f opus -c:a libopus -ab %dk -ar 48000 -vn", bitRate)
+	case ".flac":
+		// lossless. Keep the quality, unify the sample rate only
+		return fmt.Sprintf("-f flac -c:a flac -ar %d -vn", sampleRate)
+	case ".m4a", ".mp4":
+		return fmt.Sprintf("-f ipod -c:a aac -ab %dk -ar %d -vn", bitRate, sampleRate)
+	case ".aac":
+		return fmt.Sprintf("-f adts -c:a aac -ab %dk -ar %d -vn", bitRate, sampleRate)
+	case ".wav":
+		return fmt.Sprintf("-f wav -c:a pcm_s16le -ar %d -vn", sampleRate)
+	default:
+		return fmt.Sprintf("-f mp3 -ab %dk -ar %d -vn", bitRate, sampleRate)
+	}
+}
+
+// ffmpeg concat demuxer requires all files to have the same codec.
+// Files of different types have to be joined using concat filter
+func isSameCodec(files []dto.Mp3File) bool {
+	for _, f := range files {
+		if !strings.EqualFold(filepath.Ext(f.FileName), filepath.Ext(files[0].FileName)) {
+			return false
+		}
+	}
+	return true
+}
+
+// file extension of the audiobook parts for the output format
+func outputFileExt(format string) string {
+	switch format {
+	case "Opus":
+		return ".opus"
+	case "MP3 with chapters":
+		return ".mp3"
+	default:
+		return ".m4b"
+	}
+}
+
+// ffmpeg output parameters to encode a chapter for the output format and the chapter file extension.
+// The encoded chapters are joined into the audiobook part without re-encoding
+func chapterEncodingParams(format string, bitRate int, sampleRate int) (string, string) {
+	switch format {
+	case "Opus":
+		// opus supports 48kHz sample rate only
+		return fmt.Sprintf("-f opus -acodec libopus -b:a %dk -ar 48000 -vn", bitRate), ".opus"
+	case "MP3 with chapters":
+		// no tags. The part gets a single ID3v2 tag with the chapters
+		return fmt.Sprintf("-f mp3 -acodec libmp3lame -ab %dk -ar %d -vn -map_metadata -1 -id3v2_version 0", bitRate, sampleRate), ".mp3"
+	default:
+		return fmt.Sprintf("-f adts -acodec aac -b:a %dk -ar %d -vn", bitRate, sampleRate), ".aac"
+	}
+}
@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"testing"
+
+	"abb_ia/internal/dto"
+	"abb_ia/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCodecParams(t *testing.T) {
+	tests := []struct {
+		fileName string
+		decoding string
+		encoding string
+	}{
+		{"01.mp3", "-f mp3", "-f mp3 -ab 64k -ar 44100 -vn"},
+		{"01.MP3", "-f mp3", "-f mp3 -ab 64k -ar 44100 -vn"},
+		{"01.ogg", "", "-f ogg -c:a libvorbis -ab 64k -ar 44100 -vn"},
+		{"01.oga", "", "-f ogg -c:a libvorbis -ab 64k -ar 44100 -vn"},
+		{"01.opus", "", "-f opus -c:a libopus -ab 64k -ar 48000 -vn"},
+		{"01.flac", "", "-f flac -c:a flac -ar 44100 -vn"},
+		{"01.m4a", "", "-f ipod -c:a aac -ab 64k -ar 44100 -vn"},
+		{"01.mp4", "", "-f ipod -c:a aac -ab 64k -ar 44100 -vn"},
+		{"01.aac", "", "-f adts -c:a aac -ab 64k -ar 44100 -vn"},
+		{"01.wav", "", "-f wav -c:a pcm_s16le -ar 44100 -vn"},
+		{"01", "", "-f mp3 -ab 64k -ar 44100 -vn"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.decoding, decodingParams(tt.fileName), tt.fileName)
+		assert.Equal(t, tt.encoding, encodingParams(tt.fileName, 64, 44100), tt.fileName)
+	}
+}
+
+func TestChapterEncodingParams(t *testing.T) {
+	tests := []struct {
+		format string
+		params string
+		ext    string
+	}{
+		{"M4B", "-f adts -acodec aac -b:a 64k -ar 22050 -vn", ".aac"},
+		{"Opus", "-f opus -acodec libopus -b:a 64k -ar 48000 -vn", ".opus"},
+		{"MP3 with chapters", "-f mp3 -acodec libmp3lame -ab 64k -ar 22050 -vn -map_metadata -1 -id3v2_version 0", ".mp3"},
+	}
+	for _, tt := range tests {
+		params, ext := chapterEncodingParams(tt.format, 64, 22050)
+		assert.Equal(t, tt.params, params, tt.format)
+		assert.Equal(t, tt.ext, ext, tt.format)
+	}
+}
+
+func TestEstimatedLength(t *testing.T) {
+	assert.Equal(t, 60.0, estimatedLength("Flac", 6000000))
+	assert.InDelta(t, 60.0, estimatedLength("WAVE", 10584000), 0.1)
+	// the lossy files have the length in IA metadata
+	assert.Equal(t, 0.0, estimatedLength("VBR MP3", 6000000))
+}
+
+func TestIsSameCodec(t *testing.T) {
+	tests := []struct {
+		files []string
+		want  bool
+	}{
+		{[]string{"01.mp3"}, true},
+		{[]string{"01.mp3", "02.MP3"}, true},
+		{[]string{"01.mp3", "02.ogg"}, false},
+		{[]string{"01.flac", "02.flac", "03.wav"}, false},
+	}
+	for _, tt := range tests {
+		files := []dto.Mp3File{}
+		for _, f := range tt.files {
+			files = append(files, dto.Mp3File{FileName: f})
+		}
+		assert.Equal(t, tt.want, isSameCodec(files), tt.files)
+	}
+}
+
+func TestAudioFormatsPriority(t *testing.T) {
+	// the lower priority format first
+	tests := [][2]string{
+		{"Flac", "VBR MP3"},
+		{"WAVE", "Flac"},
+		{"Ogg Vorbis", "64Kbps MP3"},
+		{"64Kbps MP3", "128Kbps MP3"},
+		{"320Kbps MP3", "VBR MP3"},
+	}
+	for _, tt := range tests {
+		assert.Less(t, utils.GetIndex(AudioFormats, tt[0]), utils.GetIndex(AudioFormats, tt[1]), tt)
+	}
+	assert.Equal(t, -1, utils.GetIndex(AudioFormats, "JPEG"))
+}
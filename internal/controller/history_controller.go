@@ -0,0 +1,187 @@
+package controller
+
+import (
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/logger"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+)
+
+type HistoryController struct {
+	mq      *mq.Dispatcher
+	history *buildHistory // the SearchController marks the items built, the CleanupController adds the books built
+}
+
+func NewHistoryController(dispatcher *mq.Dispatcher) *HistoryController {
+	c := &HistoryController{}
+	c.mq = dispatcher
+	c.history = &buildHistory{}
+	c.mq.RegisterListener(mq.HistoryController, c.dispatchMessage)
+	return c
+}
+
+func (c *HistoryController) checkMQ() {
+	m := c.mq.GetMessage(mq.HistoryController)
+	if m != nil {
+		c.dispatchMessage(m)
+	}
+}
+
+func (c *HistoryController) dispatchMessage(m *mq.Message) {
+	switch dto := m.Dto.(type) {
+	case *dto.GetHistoryCommand:
+		go c.getHistory()
+	case *dto.DeleteHistoryCommand:
+		go c.deleteEntry(dto)
+	default:
+		m.UnsupportedTypeError(mq.HistoryController)
+	}
+}
+
+func (c *HistoryController) getHistory() {
+	entries, err := c.history.list()
+	if err != nil {
+		logger.Error(mq.HistoryController + ": Can't load the build history: " + err.Error())
+	}
+	c.mq.SendMessage(mq.HistoryController, mq.HistoryPage, &dto.HistoryList{Entries: entries}, false)
+}
+
+func (c *HistoryController) deleteEntry(cmd *dto.DeleteHistoryCommand) {
+	if err := c.history.delete(cmd.ID, cmd.BuildDate); err != nil {
+		logger.Error(mq.HistoryController + ": Can't update the build history: " + err.Error())
+	}
+	c.getHistory()
+}
+
+/**
+ * Local history of the audiobooks built. Owned by the HistoryController.
+ * The file is re-read if it's changed by another abb_ia process (abb_ia watch started by cron for ex.)
+ **/
+type buildHistory struct {
+	mu       sync.Mutex
+	fileName string
+	modTime  time.Time
+	size     int64 // the modification time resolution may be too low to notice a change
+	entries  []dto.HistoryEntry
+}
+
+// must be called with the mutex locked
+func (h *buildHistory) load() error {
+	fileName := config.Instance().GetHistoryFile()
+	fi, err := os.Stat(fileName)
+	if os.IsNotExist(err) {
+		h.fileName = fileName
+		h.modTime = time.Time{}
+		h.size = 0
+		h.entries = []dto.HistoryEntry{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fileName == h.fileName && fi.ModTime().Equal(h.modTime) && fi.Size() == h.size {
+		return nil
+	}
+	entries := []dto.HistoryEntry{}
+	if err := utils.LoadJson(fileName, &entries); err != nil {
+		return err
+	}
+	h.fileName = fileName
+	h.modTime = fi.ModTime()
+	h.size = fi.Size()
+	h.entries = entries
+	return nil
+}
+
+// must be called with the mutex locked
+func (h *buildHistory) save() error {
+	if err := utils.DumpJson(h.fileName, h.entries); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(h.fileName); err == nil {
+		h.modTime = fi.ModTime()
+		h.size = fi.Size()
+	}
+	return nil
+}
+
+func (h *buildHistory) add(e dto.HistoryEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		return err
+	}
+	h.entries = append(h.entries, e)
+	return h.save()
+}
+
+func (h *buildHistory) delete(id string, buildDate time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		return err
+	}
+	for i, e := range h.entries {
+		if e.ID == id && e.BuildDate.Equal(buildDate) {
+			h.entries = append(h.entries[:i], h.entries[i+1:]...)
+			break
+		}
+	}
+	return h.save()
+}
+
+// The entries, the most recent first
+func (h *buildHistory) list() ([]dto.HistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		return []dto.HistoryEntry{}, err
+	}
+	entries := append([]dto.HistoryEntry{}, h.entries...)
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].BuildDate.After(entries[j].BuildDate) })
+	return entries, nil
+}
+
+// true if an audiobook has been built from the item (alone or merged with other items)
+func (h *buildHistory) isBuilt(itemId string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := h.load(); err != nil {
+		logger.Error("Can't load the build history: " + err.Error())
+		return false
+	}
+	for _, e := range h.entries {
+		if e.ID == itemId || utils.Contains(e.ItemIDs, itemId) {
+			return true
+		}
+	}
+	return false
+}
+
+// History entry of the audiobook built. The output files are checksummed
+func newHistoryEntry(ab *dto.Audiobook) dto.HistoryEntry {
+	e := dto.HistoryEntry{}
+	e.ID = ab.IAItem.ID
+	for _, item := range ab.GetIAItems() {
+		e.ItemIDs = append(e.ItemIDs, item.ID)
+	}
+	e.Author = ab.Author
+	e.Title = ab.Title
+	e.BuildDate = time.Now()
+	e.Settings = dto.NewBuildSettings(ab.Config)
+	for _, part := range ab.Parts {
+		p := dto.HistoryPart{Path: OutputFilePath(ab, part.OutputFile), Size: part.Size}
+		if sum, err := utils.Sha1Sum(p.Path); err == nil {
+			p.Sha1 = sum
+		} else {
+			logger.Warn("Can't calculate the audiobook part checksum: " + err.Error())
+		}
+		e.Parts = append(e.Parts, p)
+	}
+	return e
+}
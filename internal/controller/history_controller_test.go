@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildHistory(t *testing.T) {
+	h := newHarness(t,
+		fakeia.Item{Identifier: "fake_built_book", Title: "Fake Built Book", Creator: "Fake History",
+			Files: []fakeia.File{{Name: "book.mp3", Format: "VBR MP3", Length: "60", Size: 1024}}},
+		fakeia.Item{Identifier: "fake_new_book", Title: "Fake New Book", Creator: "Fake History",
+			Files: []fakeia.File{{Name: "book.mp3", Format: "VBR MP3", Length: "60", Size: 1024}}},
+	)
+
+	config.Instance().SetHistoryFile(filepath.Join(t.TempDir(), "history.json"))
+
+	// the audiobook is kept in the work directory
+	m4bFile := filepath.Join(t.TempDir(), "Fake Built Book.m4b")
+	assert.NoError(t, os.WriteFile(m4bFile, []byte("hello world"), 0644))
+	ab := &dto.Audiobook{Author: "Fake History", Title: "Fake Built Book"}
+	ab.IAItem = &dto.IAItem{ID: "fake_built_book"}
+	ab.OutputDir = t.TempDir()
+	ab.Parts = []dto.Part{{Number: 1, OutputFile: m4bFile, Size: 11}}
+	ab.Config = h.config()
+	ab.Config.SetCopyToOutputDir(false)
+	ab.Config.SetBitRate(64)
+
+	NewCleanupController(h.mq, h.history).cleanUp(&dto.CleanupCommand{Audiobook: ab}, mq.BuildPage)
+	entries, err := h.history.list()
+	assert.NoError(t, err)
+	if assert.Equal(t, 1, len(entries)) {
+		e := entries[0]
+		assert.Equal(t, "fake_built_book", e.ID)
+		assert.Equal(t, []string{"fake_built_book"}, e.ItemIDs)
+		assert.Equal(t, "Fake Built Book", e.Title)
+		assert.Equal(t, 64, e.Settings.BitRateKbs)
+		if assert.Equal(t, 1, len(e.Parts)) {
+			assert.Equal(t, m4bFile, e.Parts[0].Path)
+			assert.Equal(t, "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", e.Parts[0].Sha1)
+		}
+	}
+
+	// the search result marks the items built already
+	items := map[string]*dto.IAItem{}
+	for _, item := range h.search(dto.SearchCondition{Author: "Fake History"}) {
+		items[item.ID] = item
+	}
+	if assert.Equal(t, 2, len(items)) {
+		assert.True(t, items["fake_built_book"].Built)
+		assert.False(t, items["fake_new_book"].Built)
+	}
+
+	hc := &HistoryController{mq: h.mq, history: h.history}
+	hc.deleteEntry(&dto.DeleteHistoryCommand{ID: entries[0].ID, BuildDate: entries[0].BuildDate})
+	var list *dto.HistoryList
+	for _, m := range h.received(mq.HistoryPage) {
+		if l, ok := m.(*dto.HistoryList); ok {
+			list = l
+		}
+	}
+	if assert.NotNil(t, list) {
+		assert.Equal(t, 0, len(list.Entries))
+	}
+	assert.False(t, h.history.isBuilt("fake_built_book"))
+}
+
+func TestBuildHistoryReload(t *testing.T) {
+	restoreConfig(t)
+	fileName := filepath.Join(t.TempDir(), "history.json")
+	config.Instance().SetHistoryFile(fileName)
+	h := &buildHistory{}
+	assert.NoError(t, h.add(dto.HistoryEntry{ID: "fake_first_book"}))
+	fi, _ := os.Stat(fileName)
+
+	// another process adds a book within the same modification time
+	other := &buildHistory{}
+	assert.NoError(t, other.add(dto.HistoryEntry{ID: "fake_second_book"}))
+	assert.NoError(t, os.Chtimes(fileName, fi.ModTime(), fi.ModTime()))
+	assert.True(t, h.isBuilt("fake_second_book"))
+}
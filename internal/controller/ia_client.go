@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/ia"
+	"abb_ia/internal/logger"
+)
+
+// IA client configured with the application settings
+func newIAClient(c *config.Config) *ia_client.IAClient {
+	ia := ia_client.New(c.GetRowsPerPage(), c.IsUseMock(), c.IsSaveMock())
+	ia.SetUseScrapeAPI(c.IsUseScrapeAPI())
+	ia.SetBaseURL(c.GetIaBaseUrl())
+	ia.SetCredentials(c.GetIaAccessKey(), c.GetIaSecretKey(), c.GetIaCookie())
+	if c.GetCacheTTLHours() > 0 {
+		ia.SetCache(ia_client.NewCache(c.GetCacheDir(), time.Duration(c.GetCacheTTLHours())*time.Hour, c.GetCacheMaxSizeMb()))
+	}
+	if c.GetDownloadLimitKbs() > 0 {
+		limiter, err := ia_client.NewRateLimiter(c.GetDownloadLimitKbs(), c.GetUnlimitedDownloadHours())
+		if err != nil {
+			logger.Error("Wrong unlimited download hours: " + err.Error())
+			limiter, _ = ia_client.NewRateLimiter(c.GetDownloadLimitKbs(), "")
+		}
+		ia.SetRateLimiter(limiter)
+	}
+	return ia
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"abb_ia/internal/dto"
+	ia_client "abb_ia/internal/ia"
+)
+
+var (
+	yearRe = regexp.MustCompile(`\b(1[0-9]|20)\d{2}\b`)
+	// LibriVox descriptions: "Read by John Smith.", "read by: Jane Doe (1932-2010)"
+	readByRe = regexp.MustCompile(`(?i)\bread by:?\s+([^.;,()\n]+)`)
+
+	// IA subjects meaning the configured genres
+	genreSynonyms = map[string][]string{
+		"audiobook":  {"librivox", "audio book", "audiobooks"},
+		"radiodrama": {"old time radio", "oldtimeradio", "otr", "radio drama", "radio", "radio show"},
+		"nonfiction": {"non-fiction", "non fiction"},
+		"education":  {"lecture", "lectures", "educational"},
+		"speech":     {"speeches"},
+		"podcast":    {"podcasts"},
+	}
+
+	// https://creativecommons.org/licenses/by-nc-sa/3.0/ -> CC BY-NC-SA 3.0
+	ccLicenseRe = regexp.MustCompile(`creativecommons\.org/licenses/([a-z-]+)/([0-9.]+)`)
+)
+
+// fill the book metadata (year, language, subjects, narrator) from the search result and the item details
+func fillItemMetadata(item *dto.IAItem, doc ia_client.SearchDoc, d *ia_client.ItemDetails) {
+	if len(doc.Year) > 0 && doc.Year[0] > 0 {
+		item.Year = strconv.Itoa(int(doc.Year[0]))
+	} else if len(d.Metadata.Date) > 0 {
+		item.Year = yearRe.FindString(d.Metadata.Date[0])
+	} else if len(d.Metadata.Year) > 0 {
+		item.Year = yearRe.FindString(d.Metadata.Year[0])
+	}
+
+	if len(doc.Language) > 0 && doc.Language[0] != "" {
+		item.Language = doc.Language[0]
+	} else if len(d.Metadata.Language) > 0 {
+		item.Language = d.Metadata.Language[0]
+	}
+
+	subjects := []string(doc.Subject)
+	if len(subjects) == 0 {
+		subjects = d.Metadata.Subject
+	}
+	item.Subjects = []string{}
+	for _, s := range subjects {
+		// IA subjects are often a single "a; b; c" string
+		for _, subject := range strings.Split(s, ";") {
+			if subject = strings.TrimSpace(subject); subject != "" {
+				item.Subjects = append(item.Subjects, subject)
+			}
+		}
+	}
+
+	if len(d.Metadata.Description) > 0 {
+		item.Narrator = readBy(d.Metadata.Description[0])
+	}
+	if item.Narrator == "" {
+		item.Narrator = readBy(doc.Description)
+	}
+}
+
+// narrator name from a LibriVox description
+func readBy(description string) string {
+	m := readByRe.FindStringSubmatch(description)
+	if m == nil {
+		return ""
+	}
+	narrator := strings.TrimSpace(m[1])
+	if strings.EqualFold(narrator, "various") || strings.HasPrefix(strings.ToLower(narrator), "various ") {
+		return "Various"
+	}
+	return narrator
+}
+
+// the configured genre matching the item subjects. Exact matches win over the word matches. Empty if nothing matches
+func matchGenre(subjects []string, genres []string) string {
+	for _, s := range subjects {
+		subject := strings.ToLower(strings.TrimSpace(s))
+		for _, g := range genres {
+			for _, name := range genreNames(g) {
+				if subject == name {
+					return g
+				}
+			}
+		}
+	}
+	for _, s := range subjects {
+		subject := strings.ToLower(s)
+		for _, g := range genres {
+			for _, name := range genreNames(g) {
+				if regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`).MatchString(subject) {
+					return g
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func genreNames(genre string) []string {
+	g := strings.ToLower(genre)
+	return append([]string{g}, genreSynonyms[g]...)
+}
+
+// human readable name of a license url
+func licenseName(url string) string {
+	u := strings.ToLower(url)
+	switch {
+	case strings.Contains(u, "creativecommons.org/publicdomain/mark"):
+		return "Public Domain"
+	case strings.Contains(u, "creativecommons.org/publicdomain/zero"):
+		return "CC0 1.0"
+	}
+	if m := ccLicenseRe.FindStringSubmatch(u); m != nil {
+		return "CC " + strings.ToUpper(m[1]) + " " + m[2]
+	}
+	return url
+}
+
+// copyright of the book built from one or several IA items
+func copyright(licenses []string) string {
+	names := []string{}
+	for _, l := range licenses {
+		name := licenseName(l)
+		found := false
+		for _, n := range names {
+			if n == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "; ")
+}
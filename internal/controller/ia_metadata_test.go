@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchGenre(t *testing.T) {
+	genres := []string{"Audiobook", "Fiction", "Radiodrama", "Nonfiction", "Education"}
+	assert.Equal(t, "Radiodrama", matchGenre([]string{"detective", "Old Time Radio"}, genres))
+	assert.Equal(t, "Nonfiction", matchGenre([]string{"non-fiction"}, genres))
+	assert.Equal(t, "Fiction", matchGenre([]string{"science fiction"}, genres))
+	assert.Equal(t, "Audiobook", matchGenre([]string{"LibriVox", "fiction"}, genres))
+	assert.Equal(t, "", matchGenre([]string{"cats"}, genres))
+	assert.Equal(t, "", matchGenre([]string{"radio"}, []string{"Podcast"}))
+}
+
+func TestReadBy(t *testing.T) {
+	assert.Equal(t, "John Smith", readBy("A LibriVox recording. Read by John Smith. Duration 2:03:04"))
+	assert.Equal(t, "Jane Doe", readBy("read by: Jane Doe (1932-2010)"))
+	assert.Equal(t, "Various", readBy("Read by various readers"))
+	assert.Equal(t, "", readBy("Three episodes of the Fake Show"))
+}
+
+func TestCopyright(t *testing.T) {
+	assert.Equal(t, "Public Domain", copyright([]string{"http://creativecommons.org/publicdomain/mark/1.0/"}))
+	assert.Equal(t, "CC BY-NC-SA 3.0; CC0 1.0", copyright([]string{"https://creativecommons.org/licenses/by-nc-sa/3.0/", "http://creativecommons.org/publicdomain/zero/1.0/"}))
+	assert.Equal(t, "https://example.com/license", copyright([]string{"https://example.com/license"}))
+	assert.Equal(t, "", copyright([]string{}))
+}
+
+func TestItemMetadata(t *testing.T) {
+	h := newHarness(t, fakeia.Item{
+		Identifier:  "fake_librivox_book",
+		Title:       "Fake LibriVox Book",
+		Creator:     "Fake Author",
+		Description: "A fake LibriVox recording. Read by Jane Reader.",
+		Subject:     []string{"librivox; fiction"},
+		Language:    "English",
+		Year:        1911,
+		Licenseurl:  "http://creativecommons.org/publicdomain/mark/1.0/",
+		Files:       []fakeia.File{{Name: "book_01.mp3", Format: "VBR MP3", Length: "60", Size: 1024}},
+	})
+
+	found := h.search(dto.SearchCondition{Author: "Fake Author"})
+	if assert.Equal(t, 1, len(found)) {
+		item := found[0]
+		assert.Equal(t, "1911", item.Year)
+		assert.Equal(t, "English", item.Language)
+		assert.Equal(t, "Jane Reader", item.Narrator)
+		assert.Equal(t, []string{"librivox", "fiction"}, item.Subjects)
+		assert.Equal(t, "Audiobook", matchGenre(item.Subjects, config.Instance().GetGenres()))
+	}
+}
@@ -2,8 +2,7 @@ package controller
 
 import (
 	"fmt"
-	"net/url"
-	"sort"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -17,22 +16,17 @@ import (
 	"github.com/vpoluyaktov/tview"
 )
 
-var (
-	// mp3 format list ranged by priority
-	Mp3Formats = []string{"16Kbps MP3", "24Kbps MP3", "32Kbps MP3", "40Kbps MP3", "48Kbps MP3", "56Kbps MP3", "64Kbps MP3", "80Kbps MP3", "96Kbps MP3", "112Kbps MP3", "128Kbps MP3", "144Kbps MP3", "160Kbps MP3", "224Kbps MP3", "256Kbps MP3", "320Kbps MP3", "VBR MP3"}
-	// audiobook cover formats
-	CoverFormats = []string{"JPEG", "JPEG Thumb"}
-)
-
 type SearchController struct {
 	mq                *mq.Dispatcher
 	ia                *ia_client.IAClient
+	history           *buildHistory
 	totalItemsFetched int
 }
 
-func NewSearchController(dispatcher *mq.Dispatcher) *SearchController {
+func NewSearchController(dispatcher *mq.Dispatcher, history *buildHistory) *SearchController {
 	c := &SearchController{}
 	c.mq = dispatcher
+	c.history = history
 	c.mq.RegisterListener(mq.SearchController, c.dispatchMessage)
 	return c
 }
@@ -56,16 +50,24 @@ func (c *SearchController) dispatchMessage(m *mq.Message) {
 }
 
 func (c *SearchController) search(cmd *dto.SearchCommand) {
-	logger.Info(fmt.Sprintf("Searching for: %s - %s", cmd.Condition.Author, cmd.Condition.Title))
+	if cmd.Condition.Collection != "" {
+		logger.Info(fmt.Sprintf("Listing collection %s: %s - %s", cmd.Condition.Collection, cmd.Condition.Author, cmd.Condition.Title))
+	} else {
+		logger.Info(fmt.Sprintf("Searching for: %s - %s", cmd.Condition.Author, cmd.Condition.Title))
+	}
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.UpdateStatus{Message: "Fetching Internet Archive items..."}, false)
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
 	c.totalItemsFetched = 0
-	c.ia = ia_client.New(config.Instance().GetRowsPerPage(), config.Instance().IsUseMock(), config.Instance().IsSaveMock())
-	resp := c.ia.Search(cmd.Condition.Author, cmd.Condition.Title, "audio", cmd.Condition.SortBy, cmd.Condition.SortOrder)
+	c.ia = newIAClient(config.Instance())
+	c.ia.SetForceRefresh(cmd.ForceRefresh)
+	resp := c.ia.SearchByFilter(searchFilter(cmd.Condition), cmd.Condition.SortBy, cmd.Condition.SortOrder)
 	if resp == nil {
 		logger.Error(mq.SearchController + ": Failed to perform IA search with condition: " + cmd.Condition.Author + " - " + cmd.Condition.Title)
+		c.searchFailed()
+		c.mq.SendMessage(mq.SearchController, mq.SearchPage, &dto.NothingFoundError{Condition: cmd.Condition}, false)
+		return
 	}
-	itemsFetched, err := c.fetchDetails(resp)
+	itemsFetched, err := c.fetchDetails(resp, cmd.Condition)
 	if err != nil {
 		logger.Error(mq.SearchController + ": Failed to fetch item details: " + err.Error())
 	}
@@ -83,18 +85,21 @@ func (c *SearchController) search(cmd *dto.SearchCommand) {
 func (c *SearchController) getGetNextPage(cmd *dto.GetNextPageCommand) {
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.UpdateStatus{Message: "Fetching Internet Archive items..."}, false)
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
-	resp := c.ia.GetNextPage(cmd.Condition.Author, cmd.Condition.Title, "audio", cmd.Condition.SortBy, cmd.Condition.SortOrder)
+	resp := c.ia.GetNextPageByFilter(searchFilter(cmd.Condition), cmd.Condition.SortBy, cmd.Condition.SortOrder)
 	if resp == nil {
 		logger.Error(mq.SearchController + ": Failed to perform IA search with condition: " + cmd.Condition.Author + " - " + cmd.Condition.Title)
+		c.searchFailed()
+		return
 	}
-	itemsFetched, err := c.fetchDetails(resp)
+	_, err := c.fetchDetails(resp, cmd.Condition)
 	if err != nil {
 		logger.Error(mq.SearchController + ": Failed to fetch item details: " + err.Error())
 	}
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
 	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.UpdateStatus{Message: ""}, false)
 	c.mq.SendMessage(mq.SearchController, mq.SearchPage, &dto.SearchComplete{Condition: cmd.Condition}, false)
-	if itemsFetched == 0 {
+	// the page may have items filtered out by runtime. Only an empty page is the last one
+	if len(resp.Response.Docs) == 0 {
 		logger.Info("Last page reached")
 		c.mq.SendMessage(mq.SearchController, mq.SearchPage, &dto.LastPageMessage{Condition: cmd.Condition}, false)
 	} else {
@@ -102,134 +107,269 @@ func (c *SearchController) getGetNextPage(cmd *dto.GetNextPageCommand) {
 	}
 }
 
-func (c *SearchController) fetchDetails(resp *ia_client.SearchResponse) (int, error) {
+// the search request failed. Stop the busy indicator
+func (c *SearchController) searchFailed() {
+	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
+	c.mq.SendMessage(mq.SearchController, mq.Footer, &dto.UpdateStatus{Message: "Internet Archive search failed"}, false)
+}
+
+func searchFilter(condition dto.SearchCondition) ia_client.SearchFilter {
+	return ia_client.SearchFilter{
+		Author:     condition.Author,
+		Title:      condition.Title,
+		Collection: condition.Collection,
+		Subject:    condition.Subject,
+		Language:   condition.Language,
+		YearFrom:   condition.YearFrom,
+		YearTo:     condition.YearTo,
+		RawQuery:   condition.Query,
+	}
+}
+
+// IA doesn't index the total item runtime. Filter the items after the details are fetched
+func runtimeMatches(condition dto.SearchCondition, totalLength float64) bool {
+	if condition.MinRuntime > 0 && totalLength < float64(condition.MinRuntime*60) {
+		return false
+	}
+	if condition.MaxRuntime > 0 && totalLength > float64(condition.MaxRuntime*60) {
+		return false
+	}
+	return true
+}
+
+// result of the item details fetching job
+type fetchResult struct {
+	item *dto.IAItem // nil if the item doesn't match the search condition
+	err  error
+}
+
+// Fetch the item details concurrently and send the items to the SearchPage in the search result order
+func (c *SearchController) fetchDetails(resp *ia_client.SearchResponse, condition dto.SearchCondition) (int, error) {
 	itemsTotal := resp.Response.NumFound
 	itemsFetched := 0
 
 	docs := resp.Response.Docs
-	for _, doc := range docs {
-		item := &dto.IAItem{}
-		item.ID = doc.Identifier
-		item.Title = tview.Escape(doc.Title)
-		item.IaURL = ia_client.IA_BASE_URL + "/details/" + doc.Identifier
-		item.LicenseUrl = doc.Licenseurl
-
-		item.AudioFiles = make([]dto.AudioFile, 0)
-		var totalSize int64 = 0
-		var totalLength float64 = 0.0
-		d := c.ia.GetItemDetails(doc.Identifier)
-		if d != nil {
-			item.Server = d.Server
-			item.Dir = d.Dir
-			if len(doc.Creator) > 0 && doc.Creator[0] != "" {
-				item.Creator = doc.Creator[0]
-			} else if len(d.Metadata.Creator) > 0 && d.Metadata.Creator[0] != "" {
-				item.Creator = d.Metadata.Creator[0]
-			} else if len(d.Metadata.Artist) > 0 && d.Metadata.Artist[0] != "" {
-				item.Creator = d.Metadata.Artist[0]
-			} else {
-				item.Creator = "Internet Archive"
-			}
+	results := make([]chan fetchResult, len(docs))
+	workers := config.Instance().GetConcurrentSearchRequests()
+	if workers < 1 {
+		workers = 1
+	}
+	jd := utils.NewJobDispatcher(workers)
+	for i, doc := range docs {
+		results[i] = make(chan fetchResult, 1)
+		jd.AddJob(i, c.fetchItem, doc, condition, results[i])
+	}
+	go jd.Start()
 
-			if len(d.Metadata.Description) > 0 {
-				item.Description = tview.Escape(c.ia.Html2Text(d.Metadata.Description[0]))
+	var err error
+	for _, result := range results {
+		r := <-result
+		if r.err != nil {
+			if err == nil {
+				err = r.err
 			}
+			continue
+		}
+		if r.item == nil {
+			continue
+		}
+		r.item.Built = c.history.isBuilt(r.item.ID)
+		itemsFetched++
+		c.totalItemsFetched++
+		c.mq.SendMessage(mq.SearchController, mq.SearchPage, &dto.SearchProgress{ItemsTotal: itemsTotal, ItemsFetched: c.totalItemsFetched}, false)
+		c.mq.SendMessage(mq.SearchController, mq.SearchPage, r.item, false)
+		logger.Debug(mq.SearchController + " fetched first " + strconv.Itoa(c.totalItemsFetched) + " items from " + strconv.Itoa(itemsTotal) + " total")
+	}
+	return itemsFetched, err
+}
+
+func (c *SearchController) fetchItem(doc ia_client.SearchDoc, condition dto.SearchCondition, result chan fetchResult) {
+	item, err := itemDetails(c.ia, doc, condition)
+	result <- fetchResult{item: item, err: err}
+}
+
+// IA item with the audio files. Nil if the item doesn't match the search condition
+func itemDetails(client *ia_client.IAClient, doc ia_client.SearchDoc, condition dto.SearchCondition) (*dto.IAItem, error) {
+	item := &dto.IAItem{}
+	item.ID = doc.Identifier
+	item.Title = tview.Escape(doc.Title)
+	item.IaURL = client.BaseURL() + "/details/" + doc.Identifier
+	item.LicenseUrl = doc.Licenseurl
 
+	// collections have no files. Show them as is so the user can open them
+	if doc.Mediatype == "collection" {
+		item.Collection = true
+		if len(doc.Creator) > 0 && doc.Creator[0] != "" {
+			item.Creator = doc.Creator[0]
+		} else {
+			item.Creator = "Internet Archive"
+		}
+		item.Description = tview.Escape(client.Html2Text(doc.Description))
+		return item, nil
+	}
+
+	item.AudioFiles = make([]dto.AudioFile, 0)
+	var totalSize int64 = 0
+	var totalLength float64 = 0.0
+	d := client.GetItemDetails(doc.Identifier)
+	if d != nil {
+		item.Server = d.Server
+		item.Dir = d.Dir
+		item.Restricted = d.IsRestricted()
+		fillItemMetadata(item, doc, d)
+		if len(doc.Creator) > 0 && doc.Creator[0] != "" {
+			item.Creator = doc.Creator[0]
+		} else if len(d.Metadata.Creator) > 0 && d.Metadata.Creator[0] != "" {
+			item.Creator = d.Metadata.Creator[0]
+		} else if len(d.Metadata.Artist) > 0 && d.Metadata.Artist[0] != "" {
+			item.Creator = d.Metadata.Artist[0]
+		} else {
+			item.Creator = "Internet Archive"
+		}
+
+		if len(d.Metadata.Description) > 0 {
+			item.Description = tview.Escape(client.Html2Text(d.Metadata.Description[0]))
+		}
+
+		// follow the derivatives chain (e.g. 64Kbps MP3 -> VBR MP3 -> Flac) to the original file. "" if it's unknown
+		originalOf := func(name string) string {
+			original := ""
+			if d.Files[name].Source == "original" {
+				original = strings.TrimPrefix(name, "/")
+			}
+			for parent, i := d.Files[name], 0; parent.Source == "derivative" && len(parent.Original) > 0 && i < len(d.Files); i++ {
+				original = strings.TrimPrefix(parent.Original[0], "/")
+				parent = d.Files["/"+original]
+			}
+			return original
+		}
+		// the length of a file derived from the original. 0 if there is none
+		derivativeLength := func(original string) float64 {
 			for name, metadata := range d.Files {
-				format := metadata.Format
-				// collect mp3 files
-
-				if utils.Contains(Mp3Formats, format) {
-					size, sErr := strconv.ParseInt(metadata.Size, 10, 64)
-					length, lErr := utils.TimeToSeconds(metadata.Length)
-					if sErr != nil || lErr != nil {
-						logger.Error("Can't parse the file metadata: " + name)
-						return 0, fmt.Errorf("can't parse file metadata: " + name)
+				if metadata.Length != "" && originalOf(name) == original {
+					if length, err := utils.TimeToSeconds(metadata.Length); err == nil && length > 0 {
+						return length
+					}
+				}
+			}
+			return 0
+		}
+		// the original file of every audio file collected. IA derivatives of the same recording share it
+		originals := map[string]string{}
+		for name, metadata := range d.Files {
+			format := metadata.Format
+			// collect audio files
+			if utils.Contains(AudioFormats, format) {
+				size, sErr := strconv.ParseInt(metadata.Size, 10, 64)
+				length, lErr := utils.TimeToSeconds(metadata.Length)
+				original := originalOf(name)
+				if metadata.Length == "" {
+					// lossless originals often have no length in IA metadata. Take it from a derivative or estimate it by the size.
+					// The exact duration is probed after download
+					length, lErr = 0, nil
+					if original != "" {
+						length = derivativeLength(original)
+					}
+					if length == 0 && sErr == nil {
+						length = estimatedLength(format, size)
+					}
+				}
+				if sErr != nil || lErr != nil {
+					logger.Error("Can't parse the file metadata: " + name)
+					return nil, fmt.Errorf("can't parse file metadata: " + name)
+				} else {
+					file := dto.AudioFile{}
+					file.Name = strings.TrimPrefix(name, "/")
+					if metadata.Title != "" {
+						file.Title = metadata.Title
 					} else {
-						file := dto.AudioFile{}
-						file.Name = strings.TrimPrefix(name, "/")
-						if metadata.Title != "" {
-							file.Title = metadata.Title
-						} else {
-							file.Title = utils.SanitizeMp3FileName(file.Name)
+						// strip the extension so derivatives of the same file in different codecs have the same title
+						file.Title = utils.SanitizeMp3FileName(strings.TrimSuffix(file.Name, filepath.Ext(file.Name)))
+					}
+					file.Size = size
+					file.Length = length
+					file.Format = metadata.Format
+					file.Md5 = metadata.Md5
+					file.Sha1 = metadata.Sha1
+					file.Crc32 = metadata.Crc32
+					file.ItemID = item.ID
+					file.Track = metadata.Track
+					file.Album = metadata.Album
+					// check if there is the same recording in a different format or bitrate. Keep the highest priority one only.
+					// The derivatives are grouped with their original. The files not derived from each other are matched by title.
+					// see https://archive.org/details/voyage_moon_1512_librivox or https://archive.org/details/OTRR_Blair_of_the_Mounties_Singles for ex.
+					addNewFile := true
+					for i, oldFile := range item.AudioFiles {
+						oldOriginal := originals[oldFile.Name]
+						sameFile := file.Title == oldFile.Title
+						if original != "" && oldOriginal != "" && (original != file.Name || oldOriginal != oldFile.Name) {
+							sameFile = original == oldOriginal
 						}
-						file.Size = size
-						file.Length = length
-						file.Format = metadata.Format
-						// check if there is a file with the same title but different bitrate. Keep highest bitrate only
-						// see https://archive.org/details/voyage_moon_1512_librivox or https://archive.org/details/OTRR_Blair_of_the_Mounties_Singles for ex.
-						addNewFile := true
-						for i, oldFile := range item.AudioFiles {
-							if file.Title == oldFile.Title {
-								oldFilePriority := utils.GetIndex(Mp3Formats, oldFile.Format)
-								newFilePriority := utils.GetIndex(Mp3Formats, file.Format)
-								if newFilePriority > oldFilePriority {
-									// remove old file from the list
-									item.AudioFiles = append(item.AudioFiles[:i], item.AudioFiles[i+1:]...)
-									totalSize -= oldFile.Size
-									totalLength -= oldFile.Length
-									// and add new one
-									addNewFile = true
-								} else if newFilePriority == oldFilePriority {
-									// means multiple files have the same title
-									addNewFile = true
-								} else {
-									addNewFile = false
-								}
-								break
+						if sameFile {
+							oldFilePriority := utils.GetIndex(AudioFormats, oldFile.Format)
+							newFilePriority := utils.GetIndex(AudioFormats, file.Format)
+							if newFilePriority > oldFilePriority {
+								// remove old file from the list
+								item.AudioFiles = append(item.AudioFiles[:i], item.AudioFiles[i+1:]...)
+								totalSize -= oldFile.Size
+								totalLength -= oldFile.Length
+								// and add new one
+								addNewFile = true
+							} else if newFilePriority == oldFilePriority {
+								// means multiple files have the same title
+								addNewFile = true
+							} else {
+								addNewFile = false
 							}
-						}
-						if addNewFile {
-							item.AudioFiles = append(item.AudioFiles, file)
-							totalSize += size
-							totalLength += length
+							break
 						}
 					}
-				}
-
-				// collect image files
-				if utils.Contains(CoverFormats, format) {
-					size, err := strconv.ParseInt(metadata.Size, 10, 64)
-					if err == nil {
-						file := dto.ImageFile{}
-						file.Name = strings.TrimPrefix(name, "/")
-						file.Size = size
-						file.Format = metadata.Format
-						item.ImageFiles = append(item.ImageFiles, file)
+					if addNewFile {
+						originals[file.Name] = original
+						item.AudioFiles = append(item.AudioFiles, file)
+						totalSize += size
+						totalLength += length
 					}
 				}
 			}
 
-			// sort mp3 files by name TODO: Check if sort is needed
-			sort.Slice(item.AudioFiles, func(i, j int) bool { return item.AudioFiles[i].Name < item.AudioFiles[j].Name })
-			item.TotalSize = totalSize
-			item.TotalLength = totalLength
-
-			// if len(d.Misc.Image) > 0 { // _thumb images are too small. Have to collect and sort my size all item images below
-			// 	item.CoverUrl = d.Misc.Image
-			// }
-			// find biggest image by size (TODO: Need to find better solution. Maybe analyze if the image is colorful?)
-			if len(item.ImageFiles) > 0 {
-				biggestImage := item.ImageFiles[0]
-				for i := 1; i < len(item.ImageFiles); i++ {
-					if item.ImageFiles[i].Size > biggestImage.Size {
-						biggestImage = item.ImageFiles[i]
-					}
+			// collect image files
+			if utils.Contains(CoverFormats, format) {
+				size, err := strconv.ParseInt(metadata.Size, 10, 64)
+				if err == nil {
+					file := dto.ImageFile{}
+					file.Name = strings.TrimPrefix(name, "/")
+					file.Size = size
+					file.Format = metadata.Format
+					item.ImageFiles = append(item.ImageFiles, file)
 				}
-				item.CoverUrl = (&url.URL{Scheme: "https", Host: item.Server, Path: item.Dir + "/" + biggestImage.Name}).String()
-			} else {
-				item.CoverUrl = "No cover available!"
 			}
+		}
 
-			if len(item.AudioFiles) > 0 {
-				itemsFetched++
-				c.totalItemsFetched++
-				sp := &dto.SearchProgress{ItemsTotal: itemsTotal, ItemsFetched: c.totalItemsFetched}
-				c.mq.SendMessage(mq.SearchController, mq.SearchPage, sp, false)
-				c.mq.SendMessage(mq.SearchController, mq.SearchPage, item, false)
+		// IA returns the files in random order. Put them in the playback order
+		sortAudioFiles(item.AudioFiles, config.Instance().GetFileOrder())
+		item.TotalSize = totalSize
+		item.TotalLength = totalLength
+
+		// if len(d.Misc.Image) > 0 { // _thumb images are too small. Have to collect and sort my size all item images below
+		// 	item.CoverUrl = d.Misc.Image
+		// }
+		// find biggest image by size (TODO: Need to find better solution. Maybe analyze if the image is colorful?)
+		if len(item.ImageFiles) > 0 {
+			biggestImage := item.ImageFiles[0]
+			for i := 1; i < len(item.ImageFiles); i++ {
+				if item.ImageFiles[i].Size > biggestImage.Size {
+					biggestImage = item.ImageFiles[i]
+				}
 			}
+			item.CoverUrl = client.FileURL(item.Server, item.Dir, biggestImage.Name)
+		} else {
+			item.CoverUrl = "No cover available!"
+		}
+
+		if len(item.AudioFiles) > 0 && runtimeMatches(condition, item.TotalLength) {
+			return item, nil
 		}
-		logger.Debug(mq.SearchController + " fetched first " + strconv.Itoa(c.totalItemsFetched) + " items from " + strconv.Itoa(itemsTotal) + " total")
 	}
-	return itemsFetched, nil
+	return nil, nil
 }
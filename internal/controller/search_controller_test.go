@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchKeepsOrder(t *testing.T) {
+	items := []fakeia.Item{}
+	for i := 0; i < 12; i++ {
+		items = append(items, fakeia.Item{
+			Identifier: fmt.Sprintf("fake_item_%02d", i),
+			Title:      fmt.Sprintf("Fake Item %02d", i),
+			Creator:    "Fake Creator",
+			Files:      []fakeia.File{{Name: "part1.mp3", Format: "VBR MP3", Length: "60", Size: 1024}},
+		})
+	}
+	h := newHarness(t, items...)
+	h.archive.SetLatency(50 * time.Millisecond)
+	config.Instance().SetRowsPerPage(12)
+	config.Instance().SetConcurrentSearchRequests(4)
+
+	found := h.search(dto.SearchCondition{Author: "Fake Creator"})
+	assert.Equal(t, 12, len(found))
+	for i, item := range found {
+		assert.Equal(t, fmt.Sprintf("fake_item_%02d", i), item.ID)
+	}
+}
+
+func TestSearchGroupsDerivatives(t *testing.T) {
+	h := newHarness(t, fakeia.Item{
+		Identifier: "fake_derived_book",
+		Title:      "Fake Derived Book",
+		Creator:    "Fake Narrator",
+		Files: []fakeia.File{
+			// the derivatives have no title of their own
+			{Name: "book_01.flac", Format: "Flac", Title: "Chapter 1", Size: 8192},
+			{Name: "book_01.mp3", Format: "VBR MP3", Length: "60", Size: 2048, Original: "book_01.flac"},
+			{Name: "book_01_64kb.mp3", Format: "64Kbps MP3", Length: "60", Size: 1024, Original: "book_01.mp3"},
+			// different recordings with the same title
+			{Name: "book_02.flac", Format: "Flac", Title: "Chapter", Size: 8192},
+			{Name: "book_02_64kb.mp3", Format: "64Kbps MP3", Title: "Chapter", Length: "60", Size: 1024, Original: "book_02.flac"},
+			{Name: "book_03.flac", Format: "Flac", Title: "Chapter", Size: 8192},
+			{Name: "book_03_64kb.mp3", Format: "64Kbps MP3", Title: "Chapter", Length: "60", Size: 1024, Original: "book_03.flac"},
+			// no derivatives info. Matched by title
+			{Name: "intro_128kb.mp3", Format: "128Kbps MP3", Title: "Intro", Length: "60", Size: 1024},
+			{Name: "intro.mp3", Format: "VBR MP3", Title: "Intro", Length: "60", Size: 1024},
+			// lossless files without length. It's taken from the derivative or estimated by the size
+			{Name: "book_04.aiff", Format: "AIFF", Title: "Chapter 4", Size: 8192},
+			{Name: "book_04.wav", Format: "WAVE", Length: "120", Size: 8192, Original: "book_04.aiff"},
+			{Name: "epilogue.flac", Format: "Flac", Title: "Epilogue", Size: 6000000},
+		},
+	})
+
+	found := h.search(dto.SearchCondition{Author: "Fake Narrator"})
+	if assert.Equal(t, 1, len(found)) {
+		item := found[0]
+		names := []string{}
+		for _, f := range item.AudioFiles {
+			names = append(names, f.Name)
+		}
+		assert.ElementsMatch(t, []string{"book_01.mp3", "book_02_64kb.mp3", "book_03_64kb.mp3", "intro.mp3", "book_04.aiff", "epilogue.flac"}, names)
+		assert.Equal(t, int64(6013312), item.TotalSize)
+		assert.Equal(t, 4*60.0+120+60, item.TotalLength)
+	}
+}
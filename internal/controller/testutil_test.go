@@ -0,0 +1,96 @@
+package controller
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/logger"
+	"abb_ia/internal/mq"
+)
+
+func TestMain(m *testing.M) {
+	logger.Init("/tmp/abb_ia.test.log", "DEBUG")
+	config.Load()
+	os.Exit(m.Run())
+}
+
+// the UI components receiving the messages of the controllers under test
+var testPages = []string{mq.Footer, mq.SearchPage, mq.DownloadPage, mq.EncodingPage, mq.BuildPage, mq.HistoryPage}
+
+/**
+ * Test harness of the controllers: the fake archive serving the items, the dispatcher and the pages receiving the messages.
+ * The global config points to the fake archive with an empty cache. It's restored when the test ends
+ **/
+type harness struct {
+	archive  *fakeia.Server
+	mq       *mq.Dispatcher
+	history  *buildHistory
+	mu       sync.Mutex
+	messages map[string][]dto.Dto // sync messages received by the pages
+}
+
+func newHarness(t *testing.T, items ...fakeia.Item) *harness {
+	restoreConfig(t)
+	h := &harness{}
+	h.archive = fakeia.NewServer(items...)
+	t.Cleanup(h.archive.Close)
+	config.Instance().SetIaBaseUrl(h.archive.URL)
+	config.Instance().SetCacheDir(t.TempDir())
+	h.mq = mq.NewDispatcher()
+	h.history = &buildHistory{}
+	h.messages = map[string][]dto.Dto{}
+	for _, page := range testPages {
+		page := page
+		h.mq.RegisterListener(page, func(m *mq.Message) {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			h.messages[page] = append(h.messages[page], m.Dto)
+		})
+	}
+	return h
+}
+
+// restore the global config changed by the test when it ends
+func restoreConfig(t *testing.T) {
+	saved := config.Instance().GetCopy()
+	t.Cleanup(func() { *config.Instance() = saved })
+}
+
+// The messages received by the page since the last call, sync and async ones
+func (h *harness) received(page string) []dto.Dto {
+	h.mu.Lock()
+	messages := h.messages[page]
+	delete(h.messages, page)
+	h.mu.Unlock()
+	for m := h.mq.GetMessage(page); m != nil; m = h.mq.GetMessage(page) {
+		messages = append(messages, m.Dto)
+	}
+	return messages
+}
+
+// The items received by the SearchPage in the search result order
+func (h *harness) items() []*dto.IAItem {
+	items := []*dto.IAItem{}
+	for _, m := range h.received(mq.SearchPage) {
+		if item, ok := m.(*dto.IAItem); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Search the fake archive. Returns the items found
+func (h *harness) search(condition dto.SearchCondition) []*dto.IAItem {
+	NewSearchController(h.mq, h.history).search(&dto.SearchCommand{Condition: condition})
+	return h.items()
+}
+
+// The audiobook settings: a copy of the config
+func (h *harness) config() *config.Config {
+	c := config.Instance().GetCopy()
+	return &c
+}
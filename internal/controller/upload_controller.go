@@ -61,27 +61,26 @@ func (c *UploadController) absScan(cmd *dto.AbsScanCommand) {
 	password := ab.Config.GetAudiobookshelfPassword()
 	libraryName := ab.Config.GetAudiobookshelfLibrary()
 
-
 	if url != "" && username != "" && password != "" && libraryName != "" {
 		absClient := audiobookshelf.NewClient(url)
 		err := absClient.Login(username, password)
 		if err != nil {
-			logger.Error("Can't login to audiobookshelf server: " + err.Error())
+			c.fail("Scan", ab, "Can't login to audiobookshelf server: ", err)
 			return
 		}
 		libraries, err := absClient.GetLibraries()
 		if err != nil {
-			logger.Error("Can't get a list of libraries from audiobookshelf server: " + err.Error())
+			c.fail("Scan", ab, "Can't get a list of libraries from audiobookshelf server: ", err)
 			return
 		}
 		libraryID, err := absClient.GetLibraryId(libraries, libraryName)
 		if err != nil {
-			logger.Error("Can't find audiobookshlf library by name: " + err.Error())
+			c.fail("Scan", ab, "Can't find audiobookshlf library by name: ", err)
 			return
 		}
 		err = absClient.ScanLibrary(libraryID)
 		if err != nil {
-			logger.Error("Can't launch library scan on audiobookshelf server: " + err.Error())
+			c.fail("Scan", ab, "Can't launch library scan on audiobookshelf server: ", err)
 			return
 		}
 		logger.Info("A scan launched for library " + libraryName + " on audiobookshelf server")
@@ -104,22 +103,25 @@ func (c *UploadController) absUpload(cmd *dto.AbsUploadCommand) {
 		absClient := audiobookshelf.NewClient(url)
 		err := absClient.Login(username, password)
 		if err != nil {
-			logger.Error("Can't login to audiobookshelf server: " + err.Error())
+			c.fail("Upload", c.ab, "Can't login to audiobookshelf server: ", err)
 			return
 		}
 		libraries, err := absClient.GetLibraries()
 		if err != nil {
-			logger.Error("Can't get a list of libraries from audiobookshelf server: " + err.Error())
+			c.fail("Upload", c.ab, "Can't get a list of libraries from audiobookshelf server: ", err)
 			return
 		}
 		libraryID, err := absClient.GetLibraryId(libraries, libraryName)
 		if err != nil {
-			logger.Error("Can't find audiobookshelf library by name: " + err.Error())
+			c.fail("Upload", c.ab, "Can't find audiobookshelf library by name: ", err)
 			return
 		}
 		folders, err := absClient.GetFolders(libraries, libraryName)
-		if err != nil || len(folders) == 0 {
-			logger.Error("Can't get a folder for library: " + err.Error())
+		if err == nil && len(folders) == 0 {
+			err = fmt.Errorf("the library %s has no folders", libraryName)
+		}
+		if err != nil {
+			c.fail("Upload", c.ab, "Can't get a folder for library: ", err)
 			return
 		}
 		// TODO: Check if a folder selector is needed here. Let's use first folder in a library for upload
@@ -129,14 +131,21 @@ func (c *UploadController) absUpload(cmd *dto.AbsUploadCommand) {
 		c.filesUpload = make([]fileUpload, len(c.ab.Parts))
 		go c.updateTotalUploadProgress()
 		err = absClient.UploadBook(c.ab, libraryID, folderID, c.updateFileUplodProgress)
+		c.stopFlag = true
 		if err != nil {
-			logger.Error("Can't upload the audiobook to audiobookshelf server: " + err.Error())
+			c.fail("Upload", c.ab, "Can't upload the audiobook to audiobookshelf server: ", err)
+			return
 		}
-		c.stopFlag = true
 	}
 	c.mq.SendMessage(mq.UploadController, mq.BuildPage, &dto.UploadComplete{Audiobook: cmd.Audiobook}, true)
 }
 
+// log the error and stop the pipeline
+func (c *UploadController) fail(process string, ab *dto.Audiobook, message string, err error) {
+	logger.Error(message + err.Error())
+	c.mq.SendMessage(mq.UploadController, mq.BuildPage, &dto.ProcessFailed{Process: process, Audiobook: ab, Error: message + err.Error()}, true)
+}
+
 func (c *UploadController) updateFileUplodProgress(fileId int, fileName string, size int64, pos int64, percent int) {
 
 	if c.filesUpload[fileId].progress != percent {
@@ -0,0 +1,278 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/ia"
+	"abb_ia/internal/logger"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+)
+
+// max number of the identifiers remembered per watch. Only the newest uploads are checked
+const maxSeenIDs = 1000
+
+/**
+ * Watches are the saved searches checked for new uploads periodically (every WatchIntervalHours) or on demand.
+ * The first check remembers the items found. The next checks collect the items not seen before
+ **/
+type WatchController struct {
+	mq        *mq.Dispatcher
+	mu        sync.Mutex // the watches file is updated by the UI commands and by the scheduled checks
+	scheduled bool       // check the watches every WatchIntervalHours. The TUI and abb_ia watch only, not a single build
+	nextCheck time.Time
+}
+
+func NewWatchController(dispatcher *mq.Dispatcher) *WatchController {
+	c := &WatchController{}
+	c.mq = dispatcher
+	c.mq.RegisterListener(mq.WatchController, c.dispatchMessage)
+	// let the other components initialize before the first scheduled check
+	c.nextCheck = time.Now().Add(time.Minute)
+	return c
+}
+
+func (c *WatchController) checkMQ() {
+	m := c.mq.GetMessage(mq.WatchController)
+	if m != nil {
+		c.dispatchMessage(m)
+	}
+	c.checkSchedule()
+}
+
+func (c *WatchController) dispatchMessage(m *mq.Message) {
+	switch dto := m.Dto.(type) {
+	case *dto.GetWatchesCommand:
+		go c.getWatches()
+	case *dto.SaveWatchCommand:
+		go c.saveWatch(dto)
+	case *dto.DeleteWatchCommand:
+		go c.deleteWatch(dto)
+	case *dto.CheckWatchesCommand:
+		go c.checkWatches(dto.Name, 0)
+	case *dto.ClearWatchItemsCommand:
+		go c.clearWatchItems(dto)
+	default:
+		m.UnsupportedTypeError(mq.WatchController)
+	}
+}
+
+// check the watches not checked for WatchIntervalHours. Looked at once a minute
+func (c *WatchController) checkSchedule() {
+	interval := config.Instance().GetWatchIntervalHours()
+	if !c.scheduled || interval <= 0 || time.Now().Before(c.nextCheck) {
+		return
+	}
+	c.nextCheck = time.Now().Add(time.Minute)
+	go c.checkWatches("", time.Duration(interval)*time.Hour)
+}
+
+func (c *WatchController) getWatches() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	if err != nil {
+		logger.Error(mq.WatchController + ": Can't load the watches: " + err.Error())
+		return
+	}
+	c.mq.SendMessage(mq.WatchController, mq.SearchPage, &dto.WatchList{Watches: watches}, false)
+}
+
+// add a new watch or update the watch with the same name
+func (c *WatchController) saveWatch(cmd *dto.SaveWatchCommand) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	if err != nil {
+		logger.Error(mq.WatchController + ": Can't load the watches: " + err.Error())
+		return
+	}
+	w := cmd.Watch
+	i := watchIndex(watches, w.Name)
+	if i < 0 {
+		watches = append(watches, w)
+	} else {
+		// the items seen are still valid if the search is the same
+		if watches[i].Condition == w.Condition {
+			w.LastCheck = watches[i].LastCheck
+			w.SeenIDs = watches[i].SeenIDs
+			w.NewItems = watches[i].NewItems
+		}
+		watches[i] = w
+	}
+	logger.Info("Watch saved: " + w.Name)
+	c.storeWatches(watches)
+}
+
+func (c *WatchController) deleteWatch(cmd *dto.DeleteWatchCommand) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	if err != nil {
+		logger.Error(mq.WatchController + ": Can't load the watches: " + err.Error())
+		return
+	}
+	if i := watchIndex(watches, cmd.Name); i >= 0 {
+		watches = append(watches[:i], watches[i+1:]...)
+		logger.Info("Watch deleted: " + cmd.Name)
+	}
+	c.storeWatches(watches)
+}
+
+func (c *WatchController) clearWatchItems(cmd *dto.ClearWatchItemsCommand) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	if err != nil {
+		logger.Error(mq.WatchController + ": Can't load the watches: " + err.Error())
+		return
+	}
+	if i := watchIndex(watches, cmd.Name); i >= 0 {
+		items := []dto.WatchItem{}
+		for _, item := range watches[i].NewItems {
+			if !utils.Contains(cmd.IDs, item.ID) {
+				items = append(items, item)
+			}
+		}
+		watches[i].NewItems = items
+	}
+	c.storeWatches(watches)
+}
+
+// save the watches and send the updated list to the UI
+func (c *WatchController) storeWatches(watches []dto.Watch) {
+	if err := saveWatches(config.Instance().GetWatchesFile(), watches); err != nil {
+		logger.Error(mq.WatchController + ": Can't save the watches: " + err.Error())
+	}
+	c.mq.SendMessage(mq.WatchController, mq.SearchPage, &dto.WatchList{Watches: watches}, false)
+}
+
+// Check the watch (all the watches if the name is empty) not checked for the olderThan duration
+func (c *WatchController) checkWatches(name string, olderThan time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	if err != nil {
+		logger.Error(mq.WatchController + ": Can't load the watches: " + err.Error())
+		return
+	}
+	due := []int{}
+	for i, w := range watches {
+		if (name == "" || w.Name == name) && time.Since(w.LastCheck) >= olderThan {
+			due = append(due, i)
+		}
+	}
+	if len(due) == 0 {
+		if name != "" {
+			logger.Error(mq.WatchController + ": Watch not found: " + name)
+		}
+		if olderThan == 0 {
+			// checked on demand. Let the UI know
+			c.mq.SendMessage(mq.WatchController, mq.SearchPage, &dto.WatchesChecked{Watches: watches}, false)
+		}
+		return
+	}
+
+	c.mq.SendMessage(mq.WatchController, mq.Footer, &dto.UpdateStatus{Message: "Checking the watches for new items..."}, false)
+	c.mq.SendMessage(mq.WatchController, mq.Footer, &dto.SetBusyIndicator{Busy: true}, false)
+	client := newIAClient(config.Instance())
+	// the cached search responses would hide the new uploads
+	client.SetForceRefresh(true)
+	newItems := 0
+	for _, i := range due {
+		n := checkWatch(client, &watches[i])
+		logger.Info(fmt.Sprintf("Watch %s checked: %d new items", watches[i].Name, n))
+		newItems += n
+	}
+	if err := saveWatches(config.Instance().GetWatchesFile(), watches); err != nil {
+		logger.Error(mq.WatchController + ": Can't save the watches: " + err.Error())
+	}
+	c.mq.SendMessage(mq.WatchController, mq.Footer, &dto.SetBusyIndicator{Busy: false}, false)
+	status := ""
+	if newItems > 0 {
+		status = fmt.Sprintf("New items found by the watches: %d", newItems)
+	}
+	c.mq.SendMessage(mq.WatchController, mq.Footer, &dto.UpdateStatus{Message: status}, false)
+	c.mq.SendMessage(mq.WatchController, mq.SearchPage, &dto.WatchesChecked{Watches: watches, NewItems: newItems}, false)
+}
+
+// Search for the newest uploads matching the watch condition and collect the items not seen before.
+// The pages are listed until an item seen already is found, so a bulk upload bigger than a page is not missed.
+// Returns the number of new items found
+func checkWatch(client *ia_client.IAClient, w *dto.Watch) int {
+	filter := searchFilter(w.Condition)
+	resp := client.SearchByFilter(filter, "publicdate", "desc")
+	if resp == nil || len(resp.Response.Docs) == 0 {
+		// IA is not available or nothing is uploaded yet. Don't take it as the first check
+		logger.Info("Nothing found for the watch " + w.Name)
+		return 0
+	}
+	firstCheck := w.LastCheck.IsZero()
+	newItems := 0
+	listed := 0
+	for resp != nil && len(resp.Response.Docs) > 0 {
+		seen := false
+		for _, doc := range resp.Response.Docs {
+			listed++
+			if utils.Contains(w.SeenIDs, doc.Identifier) {
+				seen = true
+				continue
+			}
+			if firstCheck || doc.Mediatype == "collection" {
+				w.SeenIDs = append(w.SeenIDs, doc.Identifier)
+				continue
+			}
+			item, err := itemDetails(client, doc, w.Condition)
+			if err != nil {
+				// try again next time
+				logger.Error(mq.WatchController + ": Failed to fetch item details: " + err.Error())
+				continue
+			}
+			w.SeenIDs = append(w.SeenIDs, doc.Identifier)
+			if item == nil {
+				// no audio files or the runtime doesn't match the condition
+				continue
+			}
+			w.NewItems = append(w.NewItems, dto.WatchItem{ID: item.ID, Creator: item.Creator, Title: item.Title, Found: time.Now()})
+			newItems++
+		}
+		// the items are sorted by the upload date, so the next pages are older than the item seen.
+		// The first check remembers the newest page only
+		if seen || firstCheck || listed >= maxSeenIDs {
+			break
+		}
+		resp = client.GetNextPageByFilter(filter, "publicdate", "desc")
+	}
+	if len(w.SeenIDs) > maxSeenIDs {
+		w.SeenIDs = w.SeenIDs[len(w.SeenIDs)-maxSeenIDs:]
+	}
+	w.LastCheck = time.Now()
+	return newItems
+}
+
+func watchIndex(watches []dto.Watch, name string) int {
+	for i, w := range watches {
+		if w.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func loadWatches(fileName string) ([]dto.Watch, error) {
+	watches := []dto.Watch{}
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		return watches, nil
+	}
+	err := utils.LoadJson(fileName, &watches)
+	return watches, err
+}
+
+func saveWatches(fileName string, watches []dto.Watch) error {
+	return utils.DumpJson(fileName, watches)
+}
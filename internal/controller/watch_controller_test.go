@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/fakeia"
+	ia_client "abb_ia/internal/ia"
+	"abb_ia/internal/mq"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckWatches(t *testing.T) {
+	h := newHarness(t,
+		fakeia.Item{Identifier: "fake_radio_ep1", Title: "Fake Radio Episode 1", Creator: "Fake Radio",
+			Files: []fakeia.File{{Name: "ep1.mp3", Format: "VBR MP3", Length: "60", Size: 1024}}},
+		fakeia.Item{Identifier: "fake_radio_ep2", Title: "Fake Radio Episode 2", Creator: "Fake Radio",
+			Files: []fakeia.File{{Name: "ep2.mp3", Format: "VBR MP3", Length: "60", Size: 1024}}},
+		fakeia.Item{Identifier: "fake_radio_pics", Title: "Fake Radio Pictures", Creator: "Fake Radio",
+			Files: []fakeia.File{{Name: "cover.jpg", Format: "JPEG", Size: 1024}}},
+	)
+
+	config.Instance().SetWatchesFile(filepath.Join(t.TempDir(), "watches.json"))
+
+	c := NewWatchController(h.mq)
+	var checked *dto.WatchesChecked
+	lastChecked := func() *dto.WatchesChecked {
+		for _, m := range h.received(mq.SearchPage) {
+			if wc, ok := m.(*dto.WatchesChecked); ok {
+				checked = wc
+			}
+		}
+		return checked
+	}
+
+	// the first check remembers the items uploaded already
+	c.saveWatch(&dto.SaveWatchCommand{Watch: dto.Watch{Name: "Fake Radio", Condition: dto.SearchCondition{Author: "Fake Radio"}}})
+	c.checkWatches("", 0)
+	if assert.NotNil(t, lastChecked()) && assert.Equal(t, 1, len(checked.Watches)) {
+		assert.Equal(t, 0, checked.NewItems)
+		assert.False(t, checked.Watches[0].LastCheck.IsZero())
+		assert.Equal(t, 3, len(checked.Watches[0].SeenIDs))
+	}
+
+	// pretend the episode 2 is uploaded after the first check
+	watches, err := loadWatches(config.Instance().GetWatchesFile())
+	assert.NoError(t, err)
+	watches[0].SeenIDs = []string{"fake_radio_ep1"}
+	assert.NoError(t, saveWatches(config.Instance().GetWatchesFile(), watches))
+
+	// checked recently. Not due for a scheduled check
+	checked = nil
+	c.checkWatches("", time.Hour)
+	assert.Nil(t, lastChecked())
+
+	c.checkWatches("Fake Radio", 0)
+	if assert.NotNil(t, lastChecked()) {
+		assert.Equal(t, 1, checked.NewItems)
+		w := checked.Watches[0]
+		// the item without audio files is not new
+		if assert.Equal(t, 1, len(w.NewItems)) {
+			assert.Equal(t, "fake_radio_ep2", w.NewItems[0].ID)
+			assert.Equal(t, "Fake Radio Episode 2", w.NewItems[0].Title)
+		}
+		assert.Equal(t, 3, len(w.SeenIDs))
+	}
+
+	// nothing new since the last check
+	c.checkWatches("", 0)
+	lastChecked()
+	assert.Equal(t, 0, checked.NewItems)
+	assert.Equal(t, 1, len(checked.Watches[0].NewItems))
+
+	c.clearWatchItems(&dto.ClearWatchItemsCommand{Name: "Fake Radio", IDs: []string{"fake_radio_ep2"}})
+	watches, err = loadWatches(config.Instance().GetWatchesFile())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(watches[0].NewItems))
+
+	c.deleteWatch(&dto.DeleteWatchCommand{Name: "Fake Radio"})
+	watches, err = loadWatches(config.Instance().GetWatchesFile())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(watches))
+}
+
+func TestCheckWatchPages(t *testing.T) {
+	// a bulk upload of three episodes bigger than a page. The newest ones first
+	items := []fakeia.Item{}
+	for _, n := range []string{"4", "3", "2", "1"} {
+		items = append(items, fakeia.Item{Identifier: "fake_radio_ep" + n, Title: "Fake Radio Episode " + n, Creator: "Fake Radio",
+			Files: []fakeia.File{{Name: "ep" + n + ".mp3", Format: "VBR MP3", Length: "60", Size: 1024}}})
+	}
+	h := newHarness(t, items...)
+
+	client := ia_client.New(2, false, false)
+	client.SetBaseURL(h.archive.URL)
+	w := &dto.Watch{Name: "Fake Radio", Condition: dto.SearchCondition{Author: "Fake Radio"}, LastCheck: time.Now().Add(-time.Hour), SeenIDs: []string{"fake_radio_ep1"}}
+	assert.Equal(t, 3, checkWatch(client, w))
+	ids := []string{}
+	for _, item := range w.NewItems {
+		ids = append(ids, item.ID)
+	}
+	assert.Equal(t, []string{"fake_radio_ep4", "fake_radio_ep3", "fake_radio_ep2"}, ids)
+
+	// the listing stops at the first page having an item seen already
+	assert.Equal(t, 0, checkWatch(client, w))
+	assert.Equal(t, 4, len(w.SeenIDs))
+}
+
+func TestBuildSettings(t *testing.T) {
+	c := config.Instance().GetCopy()
+	c.SetBitRate(128)
+	c.SetReEncodeFiles(true)
+	s := dto.NewBuildSettings(&c)
+	s.BitRateKbs = 64
+	s.ReEncodeFiles = false
+	s.OutputDir = ""
+	s.NormalizeLoudness = true
+	s.TargetLoudness = -18
+	s.TrimSilence = true
+	s.SilenceMaxTrim = 5
+
+	b := config.Instance().GetCopy()
+	b.SetOutputdDir("output")
+	s.Apply(&b)
+	assert.Equal(t, 64, b.GetBitRate())
+	assert.False(t, b.IsReEncodeFiles())
+	assert.True(t, b.IsNormalizeLoudness())
+	assert.Equal(t, float64(-18), b.GetTargetLoudness())
+	assert.True(t, b.IsTrimSilence())
+	assert.Equal(t, float64(5), b.GetSilenceMaxTrim())
+	// empty output dir means the configured one
+	assert.Equal(t, "output", b.GetOutputDir())
+}
+
+func TestCheckScheduleDisabled(t *testing.T) {
+	// a single headless build doesn't check the watches
+	c := NewWatchController(mq.NewDispatcher())
+	c.nextCheck = time.Now().Add(-time.Minute)
+	next := c.nextCheck
+	c.checkSchedule()
+	assert.Equal(t, next, c.nextCheck)
+}
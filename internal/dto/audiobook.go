@@ -16,6 +16,7 @@ type Audiobook struct {
 	SeriesNo      string
 	Narrator      string
 	Year          string
+	Language      string
 	CoverURL      string
 	CoverFile     string
 	IaURL         string
@@ -27,14 +28,23 @@ type Audiobook struct {
 	TotalSize     int64
 	Parts         []Part
 	IAItem        *IAItem
+	IAItems       []*IAItem    // source items of a book merged from several IA items, in the book order
+	SourceItems   []SourceItem // IA items the book is built from and their licenses
 	Config        *config.Config
 }
 
+// An IA item the audiobook is built from
+type SourceItem struct {
+	ID         string
+	Title      string
+	IaURL      string
+	LicenseUrl string
+}
+
 type Part struct {
 	Number       int
-	AACFile      string
-	M4BFile      string
-	FListFile    string
+	OutputFile   string
+	FListFile    string // the encoded chapters list
 	MetadataFile string
 	Format       string
 	Size         int64
@@ -43,13 +53,16 @@ type Part struct {
 }
 
 type Chapter struct {
-	Number   int
-	Name     string
-	Size     int64
-	Duration float64
-	Start    float64
-	End      float64
-	Files    []Mp3File
+	Number      int
+	Name        string
+	Size        int64
+	Duration    float64
+	Start       float64
+	End         float64
+	ItemID      string // IA item the chapter comes from
+	Files       []Mp3File
+	FListFile   string // the chapter audio files list
+	EncodedFile string // the chapter encoded with the output format codec
 }
 
 type Mp3File struct {
@@ -57,12 +70,55 @@ type Mp3File struct {
 	FileName string
 	Size     int64
 	Duration float64
+	Md5      string
+	Sha1     string
+	Crc32    string
+	ItemID   string // IA item the file is downloaded from
 }
 
 func (ab *Audiobook) String() string {
 	return fmt.Sprintf("%T: %s", ab, ab.Title)
 }
 
+// IA items the book is built from. A single item unless the book is merged from several items
+func (ab *Audiobook) GetIAItems() []*IAItem {
+	if len(ab.IAItems) > 0 {
+		return ab.IAItems
+	}
+	return []*IAItem{ab.IAItem}
+}
+
+// Source IA item by its identifier. The book item if there is no such source item
+func (ab *Audiobook) GetIAItem(id string) *IAItem {
+	for _, item := range ab.IAItems {
+		if item.ID == id {
+			return item
+		}
+	}
+	return ab.IAItem
+}
+
+// Distinct licenses of the source items
+func (ab *Audiobook) GetLicenses() []string {
+	licenses := []string{}
+	for _, s := range ab.SourceItems {
+		found := false
+		for _, l := range licenses {
+			if l == s.LicenseUrl {
+				found = true
+				break
+			}
+		}
+		if s.LicenseUrl != "" && !found {
+			licenses = append(licenses, s.LicenseUrl)
+		}
+	}
+	if len(licenses) == 0 && ab.LicenseUrl != "" {
+		licenses = append(licenses, ab.LicenseUrl)
+	}
+	return licenses
+}
+
 func (ab *Audiobook) GetChapter(chapterNumber int) (*Chapter, error) {
 	for _, part := range ab.Parts {
 		for _, chapter := range part.Chapters {
@@ -1,6 +1,10 @@
 package dto
 
-import "fmt"
+import (
+	"fmt"
+
+	"abb_ia/internal/config"
+)
 
 type BuildCommand struct {
 	Audiobook *Audiobook
@@ -39,3 +43,78 @@ type BuildComplete struct {
 func (c *BuildComplete) String() string {
 	return fmt.Sprintf("BuildComplete: %s", c.Audiobook.String())
 }
+
+// The settings an audiobook is built with (a watch or a build history entry)
+type BuildSettings struct {
+	ReEncodeFiles          bool
+	BitRateKbs             int
+	SampleRateHz           int
+	NormalizeLoudness      bool
+	TargetLoudness         float64
+	TargetTruePeak         float64
+	TrimSilence            bool
+	SilenceThresholdDb     float64
+	SilenceMinDuration     float64
+	SilenceMaxTrim         float64
+	MaxFileSizeMb          int
+	FileOrder              string
+	OutputFormat           string
+	CopyToOutputDir        bool
+	OutputDir              string
+	UploadToAudiobookshelf bool
+	ScanAudiobookshelf     bool
+}
+
+// build settings taken from the application config
+func NewBuildSettings(c *config.Config) BuildSettings {
+	return BuildSettings{
+		ReEncodeFiles:          c.IsReEncodeFiles(),
+		BitRateKbs:             c.GetBitRate(),
+		SampleRateHz:           c.GetSampleRate(),
+		NormalizeLoudness:      c.IsNormalizeLoudness(),
+		TargetLoudness:         c.GetTargetLoudness(),
+		TargetTruePeak:         c.GetTargetTruePeak(),
+		TrimSilence:            c.IsTrimSilence(),
+		SilenceThresholdDb:     c.GetSilenceThreshold(),
+		SilenceMinDuration:     c.GetSilenceMinDuration(),
+		SilenceMaxTrim:         c.GetSilenceMaxTrim(),
+		MaxFileSizeMb:          c.GetMaxFileSizeMb(),
+		FileOrder:              c.GetFileOrder(),
+		OutputFormat:           c.GetOutputFormat(),
+		CopyToOutputDir:        c.IsCopyToOutputDir(),
+		OutputDir:              c.GetOutputDir(),
+		UploadToAudiobookshelf: c.IsUploadToAudiobookshef(),
+		ScanAudiobookshelf:     c.IsScanAudiobookshef(),
+	}
+}
+
+// override the build settings of the config (a copy of the application config)
+func (s BuildSettings) Apply(c *config.Config) {
+	c.SetReEncodeFiles(s.ReEncodeFiles)
+	c.SetBitRate(s.BitRateKbs)
+	c.SetSampleRate(s.SampleRateHz)
+	c.SetNormalizeLoudness(s.NormalizeLoudness)
+	if s.TargetLoudness != 0 {
+		c.SetTargetLoudness(s.TargetLoudness)
+		c.SetTargetTruePeak(s.TargetTruePeak)
+	}
+	c.SetTrimSilence(s.TrimSilence)
+	if s.SilenceMinDuration != 0 {
+		c.SetSilenceThreshold(s.SilenceThresholdDb)
+		c.SetSilenceMinDuration(s.SilenceMinDuration)
+		c.SetSilenceMaxTrim(s.SilenceMaxTrim)
+	}
+	c.SetMaxFileSizeMb(s.MaxFileSizeMb)
+	if s.FileOrder != "" {
+		c.SetFileOrder(s.FileOrder)
+	}
+	if s.OutputFormat != "" {
+		c.SetOutputFormat(s.OutputFormat)
+	}
+	c.SetCopyToOutputDir(s.CopyToOutputDir)
+	if s.OutputDir != "" {
+		c.SetOutputdDir(s.OutputDir)
+	}
+	c.SetUploadToAudiobookshelf(s.UploadToAudiobookshelf)
+	c.SetScanAudiobookshelf(s.ScanAudiobookshelf)
+}
@@ -12,3 +12,14 @@ type StopCommand struct {
 func (c *StopCommand) String() string {
 	return fmt.Sprintf("%T: Process: %s, Reason: %s", c, c.Process, c.Reason)
 }
+
+// A stage of the audiobook pipeline (Encoding, Build, Copy, Upload, Scan) failed. The next stages are not started
+type ProcessFailed struct {
+	Process   string
+	Audiobook *Audiobook
+	Error     string
+}
+
+func (c *ProcessFailed) String() string {
+	return fmt.Sprintf("%T: Process: %s, Error: %s", c, c.Process, c.Error)
+}
@@ -28,12 +28,33 @@ func (c *FileDownloadProgress) String() string {
 	return fmt.Sprintf("FileDownloadProgress: %d, %s, %d", c.FileId, c.FileName, c.Percent)
 }
 
+type FileVerificationResult struct {
+	FileId   int
+	FileName string
+	Status   string
+}
+
+func (c *FileVerificationResult) String() string {
+	return fmt.Sprintf("FileVerificationResult: %d, %s, %s", c.FileId, c.FileName, c.Status)
+}
+
+type FileDownloadError struct {
+	FileId   int
+	FileName string
+	Error    string
+}
+
+func (c *FileDownloadError) String() string {
+	return fmt.Sprintf("FileDownloadError: %d, %s, %s", c.FileId, c.FileName, c.Error)
+}
+
 type TotalDownloadProgress struct {
 	Elapsed string // time since started
 	Percent int
 	Files   string // files downloaded
 	Bytes   string // total bytes downloaded
 	Speed   string // download speed bytes/s
+	Limit   string // download speed limit in effect. Empty if unlimited
 	ETA     string // ETA in seconds
 }
 
@@ -48,3 +69,28 @@ type DownloadComplete struct {
 func (c *DownloadComplete) String() string {
 	return fmt.Sprintf("DownloadComplete: %s", c.Audiobook.String())
 }
+
+type DownloadFailed struct {
+	Audiobook *Audiobook
+	Files     []string // names of the files failed to download
+}
+
+func (c *DownloadFailed) String() string {
+	return fmt.Sprintf("DownloadFailed: %s, %d files", c.Audiobook.String(), len(c.Files))
+}
+
+type RetryDownloadCommand struct {
+	Audiobook *Audiobook
+}
+
+func (c *RetryDownloadCommand) String() string {
+	return fmt.Sprintf("RetryDownloadCommand: %s", c.Audiobook.String())
+}
+
+type SkipFailedFilesCommand struct {
+	Audiobook *Audiobook
+}
+
+func (c *SkipFailedFilesCommand) String() string {
+	return fmt.Sprintf("SkipFailedFilesCommand: %s", c.Audiobook.String())
+}
@@ -20,6 +20,29 @@ func (c *EncodingFileProgress) String() string {
 	return fmt.Sprintf("EncodingFileProgress: %d, %s, %d", c.FileId, c.FileName, c.Percent)
 }
 
+// EBU R128 integrated loudness of a file before and after the normalization, LUFS
+type EncodingFileLoudness struct {
+	FileId     int
+	Measured   float64
+	Corrected  float64
+	Normalized bool // false until the file is normalized
+}
+
+func (c *EncodingFileLoudness) String() string {
+	return fmt.Sprintf("EncodingFileLoudness: %d, %.1f, %.1f, %t", c.FileId, c.Measured, c.Corrected, c.Normalized)
+}
+
+// leading and trailing silence trimmed from a file, seconds
+type EncodingFileTrimmed struct {
+	FileId   int
+	Trimmed  float64
+	Duration float64 // the file duration after trimming
+}
+
+func (c *EncodingFileTrimmed) String() string {
+	return fmt.Sprintf("EncodingFileTrimmed: %d, %.1f, %.1f", c.FileId, c.Trimmed, c.Duration)
+}
+
 type EncodingProgress struct {
 	Elapsed string // time since started
 	Percent int
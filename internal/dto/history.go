@@ -0,0 +1,61 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+// An audiobook built by abb_ia
+type HistoryEntry struct {
+	ID        string   // IA item identifier (the first item for the merged items)
+	ItemIDs   []string // all the items the audiobook is built from, in the merge order
+	Author    string
+	Title     string
+	BuildDate time.Time
+	Parts     []HistoryPart
+	Settings  BuildSettings
+}
+
+type HistoryPart struct {
+	Path string // the output file (m4b, opus, mp3)
+	Size int64
+	Sha1 string
+}
+
+func (e *HistoryEntry) String() string {
+	return fmt.Sprintf("%T: %s - %s", e, e.Author, e.Title)
+}
+
+type GetHistoryCommand struct {
+}
+
+func (c *GetHistoryCommand) String() string {
+	return "GetHistoryCommand"
+}
+
+type DeleteHistoryCommand struct {
+	ID        string
+	BuildDate time.Time
+}
+
+func (c *DeleteHistoryCommand) String() string {
+	return fmt.Sprintf("DeleteHistoryCommand: %s, %s", c.ID, c.BuildDate.Format(time.RFC3339))
+}
+
+type HistoryList struct {
+	Entries []HistoryEntry
+}
+
+func (c *HistoryList) String() string {
+	return fmt.Sprintf("HistoryList: %d entries", len(c.Entries))
+}
+
+// Search for the history entry items. Open the Create Audiobook dialog with the entry settings if Rebuild is set
+type OpenHistoryEntryCommand struct {
+	Entry   HistoryEntry
+	Rebuild bool
+}
+
+func (c *OpenHistoryEntryCommand) String() string {
+	return fmt.Sprintf("OpenHistoryEntryCommand: %s, rebuild: %t", c.Entry.ID, c.Rebuild)
+}
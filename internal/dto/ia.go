@@ -10,8 +10,15 @@ type IAItem struct {
 	CoverUrl    string
 	IaURL       string
 	LicenseUrl  string
+	Year        string
+	Language    string
+	Subjects    []string
+	Narrator    string // LibriVox reader
 	Server      string
 	Dir         string
+	Collection  bool // the item is an IA collection and can be opened to list its members
+	Restricted  bool // the item files can be downloaded by logged in IA users only
+	Built       bool // an audiobook has been built from the item already (see the build history)
 	TotalLength float64
 	TotalSize   int64
 	AudioFiles  []AudioFile
@@ -22,12 +29,48 @@ func (i *IAItem) String() string {
 	return fmt.Sprintf("%T: %s", i, i.Title)
 }
 
+// Merge several IA items (parts of a long serial for ex.) into one item having the files of all the items in the given order.
+// The metadata (title, creator, cover) is taken from the first item
+func MergeIAItems(items []*IAItem) *IAItem {
+	if len(items) == 0 {
+		return nil
+	}
+	merged := *items[0]
+	merged.ID = items[0].ID + "_merged"
+	merged.AudioFiles = []AudioFile{}
+	merged.ImageFiles = []ImageFile{}
+	merged.TotalSize = 0
+	merged.TotalLength = 0
+	for _, item := range items {
+		for _, f := range item.AudioFiles {
+			if f.ItemID == "" {
+				f.ItemID = item.ID
+			}
+			merged.AudioFiles = append(merged.AudioFiles, f)
+		}
+		merged.ImageFiles = append(merged.ImageFiles, item.ImageFiles...)
+		merged.TotalSize += item.TotalSize
+		merged.TotalLength += item.TotalLength
+		merged.Restricted = merged.Restricted || item.Restricted
+		if merged.CoverUrl == "" {
+			merged.CoverUrl = item.CoverUrl
+		}
+	}
+	return &merged
+}
+
 type AudioFile struct {
 	Name   string
 	Title  string
 	Format string
+	Track  string // IA track metadata ("3", "03", "3/12")
+	Album  string // IA album metadata. Distinguishes the discs of a multi-disc item
 	Length float64
 	Size   int64
+	Md5    string
+	Sha1   string
+	Crc32  string
+	ItemID string // IA item the file belongs to
 }
 
 func (f *AudioFile) String() string {
@@ -6,14 +6,23 @@ import (
 )
 
 type SearchCondition struct {
-	Author    string
-	Title     string
-	SortBy    string
-	SortOrder string
+	Author     string
+	Title      string
+	Collection string // IA collection identifier. If set, the collection members are listed
+	Subject    string
+	Language   string
+	YearFrom   int
+	YearTo     int
+	MinRuntime int    // minutes
+	MaxRuntime int    // minutes
+	Query      string // free-form IA (Lucene) query
+	SortBy     string
+	SortOrder  string
 }
 
 type SearchCommand struct {
-	Condition SearchCondition
+	Condition    SearchCondition
+	ForceRefresh bool // don't use the cached IA responses
 }
 
 func (c *SearchCommand) String() string {
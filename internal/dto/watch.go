@@ -0,0 +1,83 @@
+package dto
+
+import (
+	"fmt"
+	"time"
+)
+
+// Saved search checked for new uploads (an uploader or a collection followed by the user)
+type Watch struct {
+	Name      string
+	Condition SearchCondition
+	AutoBuild bool          // build the new items automatically (abb_ia watch)
+	Settings  BuildSettings // build settings of the new items
+	LastCheck time.Time
+	SeenIDs   []string    // the items found by the previous checks
+	NewItems  []WatchItem // the items found since the user saw the list last time
+}
+
+type WatchItem struct {
+	ID      string
+	Creator string
+	Title   string
+	Found   time.Time
+}
+
+type GetWatchesCommand struct {
+}
+
+func (c *GetWatchesCommand) String() string {
+	return "GetWatchesCommand"
+}
+
+type SaveWatchCommand struct {
+	Watch Watch
+}
+
+func (c *SaveWatchCommand) String() string {
+	return fmt.Sprintf("SaveWatchCommand: %s", c.Watch.Name)
+}
+
+type DeleteWatchCommand struct {
+	Name string
+}
+
+func (c *DeleteWatchCommand) String() string {
+	return fmt.Sprintf("DeleteWatchCommand: %s", c.Name)
+}
+
+// Check the watch for new items. All the watches are checked if the name is empty
+type CheckWatchesCommand struct {
+	Name string
+}
+
+func (c *CheckWatchesCommand) String() string {
+	return fmt.Sprintf("CheckWatchesCommand: %s", c.Name)
+}
+
+// Remove the items from the watch new items list (shown to the user or built)
+type ClearWatchItemsCommand struct {
+	Name string
+	IDs  []string
+}
+
+func (c *ClearWatchItemsCommand) String() string {
+	return fmt.Sprintf("ClearWatchItemsCommand: %s, %d items", c.Name, len(c.IDs))
+}
+
+type WatchList struct {
+	Watches []Watch
+}
+
+func (c *WatchList) String() string {
+	return fmt.Sprintf("WatchList: %d watches", len(c.Watches))
+}
+
+type WatchesChecked struct {
+	Watches  []Watch
+	NewItems int // found by this check
+}
+
+func (c *WatchesChecked) String() string {
+	return fmt.Sprintf("WatchesChecked: %d watches, %d new items", len(c.Watches), c.NewItems)
+}
@@ -0,0 +1,123 @@
+package fakeia
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const defaultFileSize = 32 * 1024
+
+//go:embed fixtures/items.json
+var fixtures embed.FS
+
+// An archive.org item served by the fake archive
+type Item struct {
+	Identifier  string   `json:"identifier"`
+	Title       string   `json:"title"`
+	Creator     string   `json:"creator"`
+	Description string   `json:"description"`
+	Mediatype   string   `json:"mediatype"` // "audio" if not set
+	Collection  []string `json:"collection"`
+	Subject     []string `json:"subject"`
+	Language    string   `json:"language"`
+	Year        int      `json:"year"`
+	Licenseurl  string   `json:"licenseurl"`
+	Restricted  bool     `json:"restricted"` // files can be downloaded with the credentials only
+	Files       []File   `json:"files"`
+}
+
+// An item file. The content is read from Path if set. Otherwise Size pseudo-random bytes are generated
+type File struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Title  string `json:"title"`
+	Track  string `json:"track"`
+	Album  string `json:"album"`
+	Length string `json:"length"` // seconds or [hh:]mm:ss, as archive.org returns it
+	Size   int    `json:"size"`
+	Path   string `json:"path"`
+	// the file this one is derived from. Empty for the original files
+	Original string `json:"original"`
+
+	content []byte
+	md5     string
+	sha1    string
+	crc32   string
+}
+
+// Items bundled with the package
+func DefaultItems() []Item {
+	buf, err := fixtures.ReadFile("fixtures/items.json")
+	if err != nil {
+		panic(err)
+	}
+	items := []Item{}
+	if err := json.Unmarshal(buf, &items); err != nil {
+		panic(err)
+	}
+	return items
+}
+
+// Load items from a JSON fixture file. Relative file paths are resolved against the fixture file directory
+func LoadItems(fixtureFile string) ([]Item, error) {
+	buf, err := os.ReadFile(fixtureFile)
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	if err := json.Unmarshal(buf, &items); err != nil {
+		return nil, fmt.Errorf("can't parse %s: %w", fixtureFile, err)
+	}
+	dir := filepath.Dir(fixtureFile)
+	for i := range items {
+		for j := range items[i].Files {
+			f := &items[i].Files[j]
+			if f.Path != "" && !filepath.IsAbs(f.Path) {
+				f.Path = filepath.Join(dir, f.Path)
+			}
+		}
+	}
+	return items, nil
+}
+
+// Read or generate the file content and calculate the checksums
+func (f *File) load(itemId string) error {
+	if f.Path != "" {
+		buf, err := os.ReadFile(f.Path)
+		if err != nil {
+			return err
+		}
+		f.content = buf
+	} else {
+		size := f.Size
+		if size <= 0 {
+			size = defaultFileSize
+		}
+		// the same item/file name always gives the same content
+		seed := int64(crc32.ChecksumIEEE([]byte(itemId + "/" + f.Name)))
+		f.content = make([]byte, size)
+		rand.New(rand.NewSource(seed)).Read(f.content)
+	}
+	f.Size = len(f.content)
+	md5Sum := md5.Sum(f.content)
+	f.md5 = hex.EncodeToString(md5Sum[:])
+	sha1Sum := sha1.Sum(f.content)
+	f.sha1 = hex.EncodeToString(sha1Sum[:])
+	f.crc32 = fmt.Sprintf("%08x", crc32.ChecksumIEEE(f.content))
+	return nil
+}
+
+func (item *Item) mediatype() string {
+	if item.Mediatype == "" {
+		return "audio"
+	}
+	return item.Mediatype
+}
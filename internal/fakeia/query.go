@@ -0,0 +1,131 @@
+package fakeia
+
+import (
+	"strconv"
+	"strings"
+)
+
+/**
+ * A tiny subset of the archive.org (Lucene) query syntax, enough for the queries abb_ia sends:
+ * field:(words) AND field:(a OR b) AND year:[1945 TO *] AND (nested query)
+ * Unsupported clauses (NOT, OR between clauses, unknown fields) don't filter anything out
+ **/
+func (item *Item) matches(query string) bool {
+	for _, clause := range splitClauses(query) {
+		if !item.matchesClause(clause) {
+			return false
+		}
+	}
+	return true
+}
+
+// split the query by top level " AND "
+func splitClauses(query string) []string {
+	clauses := []string{}
+	depth := 0
+	start := 0
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ' ':
+			if depth == 0 && strings.HasPrefix(query[i:], " AND ") {
+				clauses = append(clauses, strings.TrimSpace(query[start:i]))
+				start = i + len(" AND ")
+				i = start - 1
+			}
+		}
+	}
+	clauses = append(clauses, strings.TrimSpace(query[start:]))
+	return clauses
+}
+
+func (item *Item) matchesClause(clause string) bool {
+	if clause == "" || strings.HasPrefix(clause, "NOT ") {
+		return true
+	}
+	if strings.HasPrefix(clause, "(") && strings.HasSuffix(clause, ")") {
+		return item.matches(clause[1 : len(clause)-1])
+	}
+	field, value, found := strings.Cut(clause, ":")
+	if !found {
+		return true
+	}
+	field = strings.ToLower(strings.TrimSpace(field))
+	value = strings.TrimSpace(value)
+
+	if field == "year" {
+		return item.matchesYear(value)
+	}
+
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "("), ")")
+	alternatives := strings.Split(value, " OR ")
+	for _, alternative := range alternatives {
+		if item.matchesValue(field, alternative) {
+			return true
+		}
+	}
+	return false
+}
+
+func (item *Item) matchesValue(field string, value string) bool {
+	value = strings.ToLower(strings.Trim(strings.TrimSpace(value), "\""))
+	switch field {
+	case "identifier":
+		return strings.EqualFold(item.Identifier, value)
+	case "mediatype":
+		return item.mediatype() == value
+	case "collection":
+		for _, c := range item.Collection {
+			if strings.EqualFold(c, value) {
+				return true
+			}
+		}
+		return false
+	case "creator":
+		return containsWords(item.Creator, value)
+	case "title":
+		return containsWords(item.Title, value)
+	case "description":
+		return containsWords(item.Description, value)
+	case "language":
+		return containsWords(item.Language, value)
+	case "subject":
+		return containsWords(strings.Join(item.Subject, " "), value)
+	default:
+		return true
+	}
+}
+
+// year:[1945 TO 1955], year:[* TO 1955] or year:1949
+func (item *Item) matchesYear(value string) bool {
+	if !strings.HasPrefix(value, "[") {
+		year, err := strconv.Atoi(strings.Trim(value, "()"))
+		return err != nil || item.Year == year
+	}
+	from, to, found := strings.Cut(strings.Trim(value, "[]"), " TO ")
+	if !found {
+		return true
+	}
+	if y, err := strconv.Atoi(strings.TrimSpace(from)); err == nil && item.Year < y {
+		return false
+	}
+	if y, err := strconv.Atoi(strings.TrimSpace(to)); err == nil && item.Year > y {
+		return false
+	}
+	return true
+}
+
+// every word of the value (wildcards are ignored) is a part of the text
+func containsWords(text string, value string) bool {
+	text = strings.ToLower(text)
+	for _, word := range strings.Fields(value) {
+		word = strings.Trim(word, "*\"")
+		if word != "" && !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,405 @@
+package fakeia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultAddr = "localhost:8765"
+	searchRows  = 50
+	scrapeCount = 100
+)
+
+// all the files have the same modification time so the ETag/Last-Modified validators never change
+var modTime = time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+/**
+ * Handler is a fake archive.org. It serves the subset of the archive.org API used by abb_ia:
+ *   /advancedsearch.php?q=...&rows=..&page=..&output=json
+ *   /services/search/v1/scrape?q=...&count=..&cursor=..
+ *   /details/<id>?output=json
+ *   /files/<id>/<file name>  (item server and dir returned by /details)
+ **/
+type Handler struct {
+	items    []*Item
+	mu       sync.Mutex
+	requests map[string]int
+	failures map[string]int
+	// credentials for restricted items
+	authorization string
+	cookie        string
+	latency       time.Duration
+}
+
+// Fake archive listening on a random local port. Use the URL as IA base URL
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+func NewHandler(items []Item) (*Handler, error) {
+	h := &Handler{}
+	h.requests = make(map[string]int)
+	h.failures = make(map[string]int)
+	for i := range items {
+		item := items[i]
+		item.Files = append([]File{}, item.Files...)
+		for j := range item.Files {
+			if err := item.Files[j].load(item.Identifier); err != nil {
+				return nil, fmt.Errorf("can't load %s/%s: %w", item.Identifier, item.Files[j].Name, err)
+			}
+		}
+		h.items = append(h.items, &item)
+	}
+	return h, nil
+}
+
+// Start a fake archive with the given items or with the bundled fixtures if there are none
+func NewServer(items ...Item) *Server {
+	if len(items) == 0 {
+		items = DefaultItems()
+	}
+	h, err := NewHandler(items)
+	if err != nil {
+		panic(err)
+	}
+	return &Server{Server: httptest.NewServer(h), Handler: h}
+}
+
+// Respond "503 Service Unavailable" to the next n download requests of the file
+func (h *Handler) FailDownloads(itemId string, fileName string, n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.failures[itemId+"/"+fileName] = n
+}
+
+// Accept IA S3-like API keys ("Authorization: LOW <access>:<secret>") or the cookie for restricted items
+func (h *Handler) SetCredentials(accessKey string, secretKey string, cookie string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authorization = ""
+	if accessKey != "" && secretKey != "" {
+		h.authorization = "LOW " + accessKey + ":" + secretKey
+	}
+	h.cookie = cookie
+}
+
+// Delay the item details responses by a random time up to the latency to simulate a slow network
+func (h *Handler) SetLatency(latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latency = latency
+}
+
+// Number of requests received for the URL path, e.g. "/advancedsearch.php" or "/files/<id>/<file name>"
+func (h *Handler) Requests(path string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests[path]
+}
+
+// Checksums of the file content: md5, sha1, crc32
+func (h *Handler) Checksums(itemId string, fileName string) (string, string, string) {
+	_, f := h.file(itemId, fileName)
+	if f == nil {
+		return "", "", ""
+	}
+	return f.md5, f.sha1, f.crc32
+}
+
+// Content of the file served by the fake archive
+func (h *Handler) Content(itemId string, fileName string) []byte {
+	_, f := h.file(itemId, fileName)
+	if f == nil {
+		return nil
+	}
+	return f.content
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.requests[r.URL.Path]++
+	latency := h.latency
+	h.mu.Unlock()
+
+	switch {
+	case r.URL.Path == "/advancedsearch.php":
+		h.search(w, r)
+	case r.URL.Path == "/services/search/v1/scrape":
+		h.scrape(w, r)
+	case strings.HasPrefix(r.URL.Path, "/details/"):
+		if latency > 0 {
+			time.Sleep(time.Duration(rand.Int63n(int64(latency))))
+		}
+		h.details(w, r)
+	case strings.HasPrefix(r.URL.Path, "/files/"):
+		h.download(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	rows := queryInt(r, "rows", searchRows)
+	page := queryInt(r, "page", 1)
+	found := h.find(query)
+	start := (page - 1) * rows
+	docs := searchDocs(found, start, rows)
+
+	result := map[string]any{
+		"responseHeader": map[string]any{
+			"status": 0,
+			"QTime":  1,
+			"params": map[string]any{"query": query, "wt": "json", "rows": rows, "start": start},
+		},
+		"response": map[string]any{
+			"numFound": len(found),
+			"start":    start,
+			"docs":     docs,
+		},
+	}
+	writeJson(w, result)
+}
+
+// The cursor is just an offset of the next batch
+func (h *Handler) scrape(w http.ResponseWriter, r *http.Request) {
+	found := h.find(r.URL.Query().Get("q"))
+	count := queryInt(r, "count", scrapeCount)
+	start := queryInt(r, "cursor", 0)
+	docs := searchDocs(found, start, count)
+
+	result := map[string]any{
+		"items": docs,
+		"count": len(docs),
+		"total": len(found),
+	}
+	if start+len(docs) < len(found) {
+		result["cursor"] = strconv.Itoa(start + len(docs))
+	}
+	writeJson(w, result)
+}
+
+func (h *Handler) details(w http.ResponseWriter, r *http.Request) {
+	itemId := strings.Trim(strings.TrimPrefix(r.URL.Path, "/details/"), "/")
+	item := h.item(itemId)
+	if item == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if r.URL.Query().Get("output") != "json" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, "<html><head><title>%s</title></head><body><h1>%s</h1><p>%s</p></body></html>", item.Title, item.Title, item.Description)
+		return
+	}
+
+	dir := "/files/" + item.Identifier
+	files := map[string]any{}
+	itemSize := 0
+	image := ""
+	for _, f := range item.Files {
+		file := map[string]any{
+			"source": "original",
+			"format": f.Format,
+			"length": f.Length,
+			"mtime":  strconv.FormatInt(modTime.Unix(), 10),
+			"size":   strconv.Itoa(f.Size),
+			"md5":    f.md5,
+			"crc32":  f.crc32,
+			"sha1":   f.sha1,
+			"title":  f.Title,
+			"track":  f.Track,
+			"album":  f.Album,
+		}
+		if f.Original != "" {
+			file["source"] = "derivative"
+			file["original"] = f.Original
+		}
+		files["/"+f.Name] = file
+		itemSize += f.Size
+		if image == "" && f.Format == "JPEG" {
+			image = scheme(r) + "://" + r.Host + dir + "/" + f.Name
+		}
+	}
+
+	metadata := map[string]any{
+		"identifier":  []string{item.Identifier},
+		"title":       []string{item.Title},
+		"mediatype":   []string{item.mediatype()},
+		"publicdate":  []string{modTime.Format("2006-01-02 15:04:05")},
+		"addeddate":   []string{modTime.Format("2006-01-02 15:04:05")},
+		"collection":  item.Collection,
+		"subject":     item.Subject,
+		"description": []string{},
+		"creator":     []string{},
+		"date":        []string{},
+		"licenseurl":  []string{},
+		"language":    []string{},
+	}
+	if item.Restricted {
+		metadata["access-restricted-item"] = []string{"true"}
+	}
+	if item.Description != "" {
+		metadata["description"] = []string{item.Description}
+	}
+	if item.Creator != "" {
+		metadata["creator"] = []string{item.Creator}
+	}
+	if item.Year > 0 {
+		metadata["date"] = []string{strconv.Itoa(item.Year)}
+	}
+	if item.Licenseurl != "" {
+		metadata["licenseurl"] = []string{item.Licenseurl}
+	}
+	if item.Language != "" {
+		metadata["language"] = []string{item.Language}
+	}
+
+	result := map[string]any{
+		// files are served by the same server. IA returns a storage node name here
+		"server":   r.Host,
+		"dir":      dir,
+		"metadata": metadata,
+		"files":    files,
+		"misc":     map[string]any{"image": image},
+		"item":     map[string]any{"downloads": 0, "item_size": itemSize, "files_count": len(item.Files)},
+	}
+	writeJson(w, result)
+}
+
+// Files support Range/If-Range requests so interrupted downloads can be resumed
+func (h *Handler) download(w http.ResponseWriter, r *http.Request) {
+	itemId, fileName, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/files/"), "/")
+	item, f := h.file(itemId, fileName)
+	if f == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if item.Restricted && !h.authorized(r) {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	h.mu.Lock()
+	key := item.Identifier + "/" + f.Name
+	fail := h.failures[key] > 0
+	if fail {
+		h.failures[key]--
+	}
+	h.mu.Unlock()
+	if fail {
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("ETag", "\""+f.md5+"\"")
+	http.ServeContent(w, r, f.Name, modTime, bytes.NewReader(f.content))
+}
+
+func (h *Handler) authorized(r *http.Request) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.authorization != "" && r.Header.Get("Authorization") == h.authorization {
+		return true
+	}
+	return h.cookie != "" && r.Header.Get("Cookie") == h.cookie
+}
+
+func (h *Handler) find(query string) []*Item {
+	found := []*Item{}
+	for _, item := range h.items {
+		if item.matches(query) {
+			found = append(found, item)
+		}
+	}
+	return found
+}
+
+func (h *Handler) item(itemId string) *Item {
+	for _, item := range h.items {
+		if item.Identifier == itemId {
+			return item
+		}
+	}
+	return nil
+}
+
+func (h *Handler) file(itemId string, fileName string) (*Item, *File) {
+	item := h.item(itemId)
+	if item == nil {
+		return nil, nil
+	}
+	for i := range item.Files {
+		if item.Files[i].Name == fileName {
+			return item, &item.Files[i]
+		}
+	}
+	return item, nil
+}
+
+// Search result documents
+func searchDocs(items []*Item, start int, rows int) []map[string]any {
+	docs := []map[string]any{}
+	for i := start; i >= 0 && i < len(items) && i < start+rows; i++ {
+		item := items[i]
+		doc := map[string]any{
+			"identifier": item.Identifier,
+			"title":      item.Title,
+			"mediatype":  item.mediatype(),
+			"collection": item.Collection,
+		}
+		if item.Creator != "" {
+			doc["creator"] = item.Creator
+		}
+		if item.Description != "" {
+			doc["description"] = item.Description
+		}
+		if len(item.Subject) > 0 {
+			doc["subject"] = item.Subject
+		}
+		if item.Language != "" {
+			doc["language"] = item.Language
+		}
+		if item.Year > 0 {
+			doc["year"] = item.Year
+		}
+		if item.Licenseurl != "" {
+			doc["licenseurl"] = item.Licenseurl
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(name))
+	if err != nil || v < 0 {
+		return defaultValue
+	}
+	return v
+}
+
+func scheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,114 @@
+package fakeia_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"abb_ia/internal/fakeia"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type searchResult struct {
+	Response struct {
+		NumFound int `json:"numFound"`
+		Docs     []struct {
+			Identifier string `json:"identifier"`
+		} `json:"docs"`
+	} `json:"response"`
+}
+
+func search(t *testing.T, s *fakeia.Server, query string) []string {
+	resp, err := http.Get(s.URL + "/advancedsearch.php?output=json&rows=50&page=1&q=" + url.QueryEscape(query))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	result := &searchResult{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(result))
+	ids := []string{}
+	for _, doc := range result.Response.Docs {
+		ids = append(ids, doc.Identifier)
+	}
+	return ids
+}
+
+func TestSearchQuery(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"mediatype:(audio)", []string{"OTRR_Fake_Show_Singles", "Fake_Science_Lectures", "Fake_Lending_Audiobook"}},
+		{"creator:(Old Time Radio Researchers) AND title:(Single Episodes) AND mediatype:(audio)", []string{"OTRR_Fake_Show_Singles"}},
+		{"identifier:(Fake_Science_Lectures) AND mediatype:(audio)", []string{"Fake_Science_Lectures"}},
+		{"collection:(fake_otr_collection) AND mediatype:(audio OR collection)", []string{"OTRR_Fake_Show_Singles", "Fake_Science_Lectures"}},
+		{"mediatype:(collection)", []string{"fake_otr_collection"}},
+		{"subject:(detective) AND language:(eng) AND mediatype:(audio)", []string{"OTRR_Fake_Show_Singles"}},
+		{"year:[1940 TO *] AND mediatype:(audio)", []string{"OTRR_Fake_Show_Singles", "Fake_Science_Lectures"}},
+		{"year:[1950 TO *] AND mediatype:(audio)", []string{"Fake_Science_Lectures"}},
+		{"year:[* TO 1950] AND (subject:(radio)) AND mediatype:(audio)", []string{"OTRR_Fake_Show_Singles"}},
+		{"title:(nothing like this) AND mediatype:(audio)", []string{}},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, search(t, s, test.query), test.query)
+	}
+}
+
+func TestDownload(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+
+	fileURL := s.URL + "/files/Fake_Science_Lectures/lecture_01.ogg"
+	content := s.Content("Fake_Science_Lectures", "lecture_01.ogg")
+	assert.NotEmpty(t, content)
+
+	req, _ := http.NewRequest("GET", fileURL, nil)
+	req.Header.Set("Range", "bytes=100-")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusPartialContent, resp.StatusCode)
+	assert.Equal(t, int64(len(content)-100), resp.ContentLength)
+
+	s.FailDownloads("Fake_Science_Lectures", "lecture_01.ogg", 1)
+	resp, err = http.Get(fileURL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	resp, err = http.Get(fileURL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, s.Requests("/files/Fake_Science_Lectures/lecture_01.ogg"))
+}
+
+func TestRestrictedDownload(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	s.SetCredentials("access", "secret", "logged-in-user=user; logged-in-sig=sig")
+	fileURL := s.URL + "/files/Fake_Lending_Audiobook/fake_lending_01.mp3"
+
+	tests := []struct {
+		header string
+		value  string
+		want   int
+	}{
+		{"", "", http.StatusForbidden},
+		{"Authorization", "LOW access:wrong", http.StatusForbidden},
+		{"Authorization", "LOW access:secret", http.StatusOK},
+		{"Cookie", "logged-in-user=user; logged-in-sig=sig", http.StatusOK},
+	}
+	for _, test := range tests {
+		req, _ := http.NewRequest("GET", fileURL, nil)
+		if test.header != "" {
+			req.Header.Set(test.header, test.value)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, test.want, resp.StatusCode, test.value)
+	}
+}
@@ -1,16 +1,22 @@
 package ffmpeg
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"os/exec"
 
 	"abb_ia/internal/logger"
 )
 
 type FFmpeg struct {
-	input  input
-	output output
-	params params
-	cmd    *exec.Cmd
+	input    input
+	output   output
+	params   params
+	cmd      *exec.Cmd
+	progress func(Progress)
+	stderr   bytes.Buffer
+	ctx      context.Context
 }
 
 type input struct {
@@ -54,8 +60,16 @@ func (f *FFmpeg) Params(args string) *FFmpeg {
 	return f
 }
 
-func (f *FFmpeg) SendProgressTo(url string) *FFmpeg {
-	f.params.args += " -progress " + url
+// report the progress stats to the callback. ffmpeg writes them to the stdout pipe
+func (f *FFmpeg) OnProgress(callback func(Progress)) *FFmpeg {
+	f.params.args += " -progress pipe:1"
+	f.progress = callback
+	return f
+}
+
+// kill the process when the context is done
+func (f *FFmpeg) Context(ctx context.Context) *FFmpeg {
+	f.ctx = ctx
 	return f
 }
 
@@ -75,16 +89,36 @@ func (f *FFmpeg) Run() (string, *exitErr) {
 		args = args.AppendArgs("-i").AppendFileName(fileName)
 	}
 	args = args.AppendArgs(f.output.args).AppendFileName(f.output.fileName)
-	f.cmd = exec.Command(cmd, args.String()...)
+	ctx := f.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	f.cmd = exec.CommandContext(ctx, cmd, args.String()...)
 	logger.Debug("FFMPEG cmd: " + f.cmd.String())
+	f.cmd.Stderr = &f.stderr
+	if f.progress != nil {
+		return f.runWithProgress()
+	}
 	out, err := f.cmd.Output()
 	if err != nil {
-		return string(out), ExitErr(err)
+		return string(out), f.exitErr(err)
 	} else {
 		return string(out), nil
 	}
 }
 
+// ffmpeg log output of the finished process (loudnorm filter stats for ex.)
+func (f *FFmpeg) Stderr() string {
+	return f.stderr.String()
+}
+
+func (f *FFmpeg) exitErr(err error) *exitErr {
+	if ee, ok := err.(*exec.ExitError); ok {
+		ee.Stderr = f.stderr.Bytes()
+	}
+	return ExitErr(err)
+}
+
 func (f *FFmpeg) Kill() error {
 	if f.cmd != nil && f.cmd.Process != nil {
 		return f.cmd.Process.Kill()
@@ -92,3 +126,25 @@ func (f *FFmpeg) Kill() error {
 		return nil
 	}
 }
+
+func (f *FFmpeg) runWithProgress() (string, *exitErr) {
+	stdout, err := f.cmd.StdoutPipe()
+	if err != nil {
+		return "", ExitErr(err)
+	}
+	if err := f.cmd.Start(); err != nil {
+		return "", ExitErr(err)
+	}
+	parser := &progressParser{}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if parser.parseLine(scanner.Text()) {
+			f.progress(parser.progress)
+		}
+	}
+	err = f.cmd.Wait()
+	if err != nil {
+		return "", f.exitErr(err)
+	}
+	return "", nil
+}
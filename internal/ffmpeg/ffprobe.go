@@ -38,6 +38,14 @@ type Metadata struct {
 			Date     string `json:"date"`
 		} `json:"tags"`
 	} `json:"format"`
+	Streams []struct {
+		CodecName string `json:"codec_name"`
+		CodecType string `json:"codec_type"`
+		Tags      struct {
+			Title  string `json:"title"`
+			TitleU string `json:"TITLE"`
+		} `json:"tags"`
+	} `json:"streams"`
 }
 
 func NewFFProbe(fileName string) (*FFProbe, error) {
@@ -65,11 +73,18 @@ func (p *FFProbe) Duration() float64 {
 }
 
 func (p *FFProbe) Title() string {
-	if p.metadata.Format.Tags.Title == "" {
-		return filepath.Base(p.metadata.Format.Filename)
-	} else {
+	if p.metadata.Format.Tags.Title != "" {
 		return p.metadata.Format.Tags.Title
 	}
+	// Ogg Vorbis and Opus keep the tags in the audio stream
+	for _, s := range p.metadata.Streams {
+		if s.CodecType == "audio" && s.Tags.Title != "" {
+			return s.Tags.Title
+		} else if s.CodecType == "audio" && s.Tags.TitleU != "" {
+			return s.Tags.TitleU
+		}
+	}
+	return filepath.Base(p.metadata.Format.Filename)
 }
 
 func (p *FFProbe) Size() int64 {
@@ -92,3 +107,58 @@ func (p *FFProbe) Format() string {
 func (p *FFProbe) BitRate() string {
 	return p.metadata.Format.BitRate
 }
+
+// samples per AAC frame
+const aacFrameSamples = 1024
+
+/**
+ * Duration of an encoded audio file, seconds.
+ * ffprobe estimates the duration of a raw ADTS AAC stream from its bit rate. That's a few seconds off for a long file,
+ * so the AAC frames are counted instead. The durations of the other formats are taken from the container
+ **/
+func AudioDuration(fileName string) (float64, error) {
+	if !strings.EqualFold(filepath.Ext(fileName), ".aac") {
+		p, err := NewFFProbe(fileName)
+		if err != nil {
+			return 0, err
+		}
+		return p.Duration(), nil
+	}
+	args := NewArgs().
+		AppendArgs("-loglevel error").
+		AppendArgs("-select_streams a:0").
+		AppendArgs("-count_packets").
+		AppendArgs("-show_entries stream=sample_rate,nb_read_packets").
+		AppendArgs("-of json").
+		AppendFileName(fileName)
+	out, err := exec.Command("ffprobe", args.String()...).Output()
+	if err != nil {
+		return 0, err
+	}
+	return aacDuration(out)
+}
+
+// the duration of the AAC frames counted by ffprobe
+func aacDuration(probe []byte) (float64, error) {
+	var m struct {
+		Streams []struct {
+			SampleRate    string `json:"sample_rate"`
+			NbReadPackets string `json:"nb_read_packets"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(probe, &m); err != nil {
+		return 0, err
+	}
+	if len(m.Streams) == 0 {
+		return 0, fmt.Errorf("no audio stream found")
+	}
+	sampleRate, err := strconv.Atoi(m.Streams[0].SampleRate)
+	if err != nil || sampleRate == 0 {
+		return 0, fmt.Errorf("wrong sample rate: %q", m.Streams[0].SampleRate)
+	}
+	packets, err := strconv.Atoi(m.Streams[0].NbReadPackets)
+	if err != nil {
+		return 0, fmt.Errorf("wrong packet count: %q", m.Streams[0].NbReadPackets)
+	}
+	return float64(packets*aacFrameSamples) / float64(sampleRate), nil
+}
@@ -0,0 +1,17 @@
+package ffmpeg
+
+import "testing"
+
+func TestAacDuration(t *testing.T) {
+	// 30 minutes at 44.1 kHz
+	d, err := aacDuration([]byte(`{"programs": [], "streams": [{"sample_rate": "44100", "nb_read_packets": "77520"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d != 1800.0108843537414 {
+		t.Errorf("aacDuration() = %v, want %v", d, 1800.0108843537414)
+	}
+	if _, err := aacDuration([]byte(`{"streams": []}`)); err == nil {
+		t.Errorf("aacDuration() error = nil for no audio stream")
+	}
+}
@@ -0,0 +1,87 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// loudness range target of the loudnorm filter (LU)
+const loudnessRange = 11
+
+// EBU R128 loudness stats reported by the loudnorm filter
+type Loudness struct {
+	InputI       float64 // integrated loudness, LUFS
+	InputTP      float64 // true peak, dBTP
+	InputLRA     float64 // loudness range, LU
+	InputThresh  float64
+	OutputI      float64
+	OutputTP     float64
+	OutputLRA    float64
+	OutputThresh float64
+	TargetOffset float64
+}
+
+// loudnorm filter. The first pass measures the loudness (measured is nil),
+// the second one applies the measured values to normalize the audio linearly
+func LoudnormFilter(target float64, truePeak float64, measured *Loudness) string {
+	filter := fmt.Sprintf("loudnorm=I=%s:TP=%s:LRA=%d", formatFloat(target), formatFloat(truePeak), loudnessRange)
+	if measured != nil {
+		filter += fmt.Sprintf(":measured_I=%s:measured_TP=%s:measured_LRA=%s:measured_thresh=%s:offset=%s:linear=true",
+			formatFloat(measured.InputI), formatFloat(measured.InputTP), formatFloat(measured.InputLRA), formatFloat(measured.InputThresh), formatFloat(measured.TargetOffset))
+	}
+	return filter + ":print_format=json"
+}
+
+// parse the loudnorm stats printed to the ffmpeg log (-loglevel info)
+func ParseLoudness(log string) (*Loudness, error) {
+	i := strings.LastIndex(log, "Parsed_loudnorm")
+	if i < 0 {
+		return nil, fmt.Errorf("no loudnorm stats found")
+	}
+	start := strings.Index(log[i:], "{")
+	end := strings.Index(log[i:], "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("no loudnorm stats found")
+	}
+	stats := map[string]string{}
+	if err := json.Unmarshal([]byte(log[i+start:i+end+1]), &stats); err != nil {
+		return nil, fmt.Errorf("can't parse loudnorm stats: %v", err)
+	}
+	l := &Loudness{}
+	fields := map[string]*float64{
+		"input_i":       &l.InputI,
+		"input_tp":      &l.InputTP,
+		"input_lra":     &l.InputLRA,
+		"input_thresh":  &l.InputThresh,
+		"output_i":      &l.OutputI,
+		"output_tp":     &l.OutputTP,
+		"output_lra":    &l.OutputLRA,
+		"output_thresh": &l.OutputThresh,
+		"target_offset": &l.TargetOffset,
+	}
+	for name, field := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(stats[name]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong loudnorm %s value: %q", name, stats[name])
+		}
+		*field = v
+	}
+	return l, nil
+}
+
+// false for silent audio (-inf LUFS). It can't be normalized
+func (l *Loudness) IsValid() bool {
+	for _, v := range []float64{l.InputI, l.InputTP, l.InputLRA, l.InputThresh, l.TargetOffset} {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
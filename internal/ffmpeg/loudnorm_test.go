@@ -0,0 +1,53 @@
+package ffmpeg
+
+import "testing"
+
+func TestParseLoudness(t *testing.T) {
+	log := `Input #0, mp3, from 'episode.mp3':
+  Duration: 00:29:41.04, start: 0.025057, bitrate: 64 kb/s
+[Parsed_loudnorm_0 @ 0x5581]
+{
+	"input_i" : "-27.61",
+	"input_tp" : "-4.47",
+	"input_lra" : "18.06",
+	"input_thresh" : "-39.20",
+	"output_i" : "-16.58",
+	"output_tp" : "-1.50",
+	"output_lra" : "14.78",
+	"output_thresh" : "-27.71",
+	"normalization_type" : "dynamic",
+	"target_offset" : "0.58"
+}
+`
+	l, err := ParseLoudness(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Loudness{InputI: -27.61, InputTP: -4.47, InputLRA: 18.06, InputThresh: -39.2, OutputI: -16.58, OutputTP: -1.5, OutputLRA: 14.78, OutputThresh: -27.71, TargetOffset: 0.58}
+	if *l != want {
+		t.Errorf("ParseLoudness() = %+v, want %+v", *l, want)
+	}
+	if !l.IsValid() {
+		t.Errorf("IsValid() = false, want true")
+	}
+
+	filter := LoudnormFilter(-16, -1.5, l)
+	wantFilter := "loudnorm=I=-16:TP=-1.5:LRA=11:measured_I=-27.61:measured_TP=-4.47:measured_LRA=18.06:measured_thresh=-39.2:offset=0.58:linear=true:print_format=json"
+	if filter != wantFilter {
+		t.Errorf("LoudnormFilter() = %s, want %s", filter, wantFilter)
+	}
+
+	// silence
+	l, err = ParseLoudness(`[Parsed_loudnorm_0 @ 0x5581] {"input_i" : "-inf", "input_tp" : "-inf", "input_lra" : "0.00", "input_thresh" : "-70.00",
+		"output_i" : "-inf", "output_tp" : "-inf", "output_lra" : "0.00", "output_thresh" : "-70.00", "target_offset" : "inf"}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.IsValid() {
+		t.Errorf("IsValid() = true for silence")
+	}
+
+	if _, err := ParseLoudness("Error opening input file"); err == nil {
+		t.Errorf("ParseLoudness() error = nil for no stats")
+	}
+}
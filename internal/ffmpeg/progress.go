@@ -0,0 +1,47 @@
+package ffmpeg
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ffmpeg progress stats (-progress output)
+type Progress struct {
+	Bytes    int64   // total_size
+	Seconds  float64 // out_time_us
+	Speed    float64 // speed
+	Complete bool    // progress=end
+}
+
+// Incremental parser of the ffmpeg -progress key=value lines.
+// The stats are reported in blocks terminated by progress=continue or progress=end
+type progressParser struct {
+	progress Progress
+}
+
+// parse a line. Returns true if the line completes a block of the stats
+func (p *progressParser) parseLine(line string) bool {
+	key, value, found := strings.Cut(strings.TrimSpace(line), "=")
+	if !found {
+		return false
+	}
+	value = strings.TrimSpace(value)
+	switch key {
+	case "total_size":
+		if bytes, err := strconv.ParseInt(value, 10, 64); err == nil {
+			p.progress.Bytes = bytes
+		}
+	case "out_time_us":
+		if us, err := strconv.ParseFloat(value, 64); err == nil {
+			p.progress.Seconds = us / 1000000
+		}
+	case "speed":
+		if speed, err := strconv.ParseFloat(strings.TrimSuffix(value, "x"), 64); err == nil {
+			p.progress.Speed = speed
+		}
+	case "progress":
+		p.progress.Complete = value == "end"
+		return true
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package ffmpeg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProgressParser(t *testing.T) {
+	output := `bitrate= 128.0kbits/s
+total_size=262144
+out_time_us=16384000
+out_time=00:00:16.384000
+speed=32.7x
+progress=continue
+bitrate= 128.0kbits/s
+total_size=524288
+out_time_us=32768000
+speed=N/A
+progress=end
+`
+	blocks := []Progress{}
+	p := &progressParser{}
+	for _, line := range strings.Split(output, "\n") {
+		if p.parseLine(line) {
+			blocks = append(blocks, p.progress)
+		}
+	}
+
+	want := []Progress{
+		{Bytes: 262144, Seconds: 16.384, Speed: 32.7, Complete: false},
+		// unparsable values keep the previous ones
+		{Bytes: 524288, Seconds: 32.768, Speed: 32.7, Complete: true},
+	}
+	if len(blocks) != len(want) {
+		t.Fatalf("parseLine() reported %d blocks, want %d", len(blocks), len(want))
+	}
+	for i := range want {
+		if blocks[i] != want[i] {
+			t.Errorf("block %d = %+v, want %+v", i, blocks[i], want[i])
+		}
+	}
+}
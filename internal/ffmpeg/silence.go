@@ -0,0 +1,85 @@
+package ffmpeg
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// a silence closer to the file beginning or end than this is the leading or the trailing one, seconds
+const silenceEdge = 0.1
+
+// silent interval of an audio file reported by the silencedetect filter, seconds
+type Silence struct {
+	Start float64
+	End   float64 // -1 if the silence lasts till the end of the file
+}
+
+var (
+	silenceStartRe = regexp.MustCompile(`silence_start: (-?[0-9.]+)`)
+	silenceEndRe   = regexp.MustCompile(`silence_end: (-?[0-9.]+)`)
+)
+
+// silencedetect filter. The silence is the audio below thresholdDb lasting minDuration seconds at least
+func SilenceDetectFilter(thresholdDb float64, minDuration float64) string {
+	return fmt.Sprintf("silencedetect=noise=%sdB:d=%s", formatFloat(thresholdDb), formatFloat(minDuration))
+}
+
+// parse the silent intervals printed to the ffmpeg log (-loglevel info)
+func ParseSilences(log string) []Silence {
+	silences := []Silence{}
+	starts := silenceStartRe.FindAllStringSubmatchIndex(log, -1)
+	ends := silenceEndRe.FindAllStringSubmatch(log, -1)
+	for i, start := range starts {
+		s := Silence{End: -1}
+		s.Start, _ = strconv.ParseFloat(log[start[2]:start[3]], 64)
+		if s.Start < 0 {
+			s.Start = 0
+		}
+		if i < len(ends) {
+			s.End, _ = strconv.ParseFloat(ends[i][1], 64)
+		}
+		silences = append(silences, s)
+	}
+	return silences
+}
+
+// The part of the file to keep. The leading and the trailing silence are cut, maxTrim seconds max from each end.
+// silenceremove can't limit the length of the audio removed, so the silence found by silencedetect is cut with atrim
+func TrimRange(silences []Silence, duration float64, maxTrim float64) (start float64, end float64) {
+	start, end = 0, duration
+	if len(silences) == 0 || duration <= 0 {
+		return start, end
+	}
+	first := silences[0]
+	if first.Start <= silenceEdge {
+		start = silenceEnd(first, duration)
+		if start > maxTrim {
+			start = maxTrim
+		}
+	}
+	last := silences[len(silences)-1]
+	if silenceEnd(last, duration) >= duration-silenceEdge {
+		end = last.Start
+		if end < duration-maxTrim {
+			end = duration - maxTrim
+		}
+	}
+	// nothing but silence. Keep the file as it is
+	if end <= start {
+		return 0, duration
+	}
+	return start, end
+}
+
+func silenceEnd(s Silence, duration float64) float64 {
+	if s.End < 0 || s.End > duration {
+		return duration
+	}
+	return s.End
+}
+
+// atrim filter to cut the audio outside of [start, end] seconds
+func TrimFilter(start float64, end float64) string {
+	return fmt.Sprintf("atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS", start, end)
+}
@@ -0,0 +1,55 @@
+package ffmpeg
+
+import "testing"
+
+func TestParseSilences(t *testing.T) {
+	log := `Input #0, mp3, from 'episode.mp3':
+  Duration: 00:29:41.04, start: 0.025057, bitrate: 64 kb/s
+[silencedetect @ 0x55d1] silence_start: -0.025057
+[silencedetect @ 0x55d1] silence_end: 4.52 | silence_duration: 4.545057
+[silencedetect @ 0x55d1] silence_start: 812.3
+[silencedetect @ 0x55d1] silence_end: 813.1 | silence_duration: 0.8
+[silencedetect @ 0x55d1] silence_start: 1774.25
+`
+	got := ParseSilences(log)
+	want := []Silence{{Start: 0, End: 4.52}, {Start: 812.3, End: 813.1}, {Start: 1774.25, End: -1}}
+	if len(got) != len(want) {
+		t.Fatalf("ParseSilences() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseSilences()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestTrimRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		silences  []Silence
+		duration  float64
+		wantStart float64
+		wantEnd   float64
+	}{
+		{"no silence", []Silence{}, 100, 0, 100},
+		{"silence in the middle", []Silence{{Start: 40, End: 45}}, 100, 0, 100},
+		{"leading and trailing", []Silence{{Start: 0, End: 4.5}, {Start: 50, End: 51}, {Start: 97, End: -1}}, 100, 4.5, 97},
+		{"trailing silence end reported", []Silence{{Start: 97, End: 100}}, 100, 0, 97},
+		{"max trim", []Silence{{Start: 0, End: 30}, {Start: 60, End: 100}}, 100, 10, 90},
+		{"nothing but silence", []Silence{{Start: 0, End: -1}}, 15, 0, 15},
+		{"unknown duration", []Silence{{Start: 0, End: 2}}, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		start, end := TrimRange(tt.silences, tt.duration, 10)
+		if start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("%s: TrimRange() = %v, %v, want %v, %v", tt.name, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+
+	if f := TrimFilter(4.5, 97); f != "atrim=start=4.500:end=97.000,asetpts=PTS-STARTPTS" {
+		t.Errorf("TrimFilter() = %s", f)
+	}
+	if f := SilenceDetectFilter(-50, 0.5); f != "silencedetect=noise=-50dB:d=0.5" {
+		t.Errorf("SilenceDetectFilter() = %s", f)
+	}
+}
@@ -2,8 +2,6 @@ package ffmpeg
 
 import (
 	"os/exec"
-	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -56,44 +54,3 @@ func ExitErr(e error) *exitErr {
 func (e *exitErr) Error() string {
 	return e.errMessage
 }
-
-// parse ffmpeg progress stats
-var reTotalSize = regexp.MustCompile(`total_size=(\d+)`)
-var reOutTimeUs = regexp.MustCompile(`out_time_us=(\d+)`)
-var reSpeed = regexp.MustCompile(`speed=\s*(\d+)x`)
-var reComplete = regexp.MustCompile(`progress=end`)
-
-func ParseFFMPEGProgress(data string) (int64, float64, float64, bool) {
-
-	var bytesProcessed int64 = 0
-	var secondsProcessed float64 = 0
-	var encodingSpeed float64 = 0
-	var complete = false
-
-	totalSizeMatches := reTotalSize.FindAllStringSubmatch(data, -1)
-	if len(totalSizeMatches) > 0 {
-		lastTotalSize := totalSizeMatches[len(totalSizeMatches)-1][1]
-		bytesProcessed, _ = strconv.ParseInt(lastTotalSize, 10, 64)
-	}
-
-	outTimeUsMatches := reOutTimeUs.FindAllStringSubmatch(data, -1)
-	if len(outTimeUsMatches) > 0 {
-		lastOutTimeUs := outTimeUsMatches[len(outTimeUsMatches)-1][1]
-		msecProcessed, _ := strconv.ParseFloat(lastOutTimeUs, 64)
-		secondsProcessed = msecProcessed / 1000000
-	}
-
-	speedMatches := reSpeed.FindAllStringSubmatch(data, -1)
-	if len(speedMatches) > 0 {
-		lastSpeed := speedMatches[len(speedMatches)-1][1]
-		encodingSpeed, _ = strconv.ParseFloat(lastSpeed, 64)
-
-	}
-
-	completeMatches := reComplete.FindAllStringSubmatch(data, -1)
-	if len(completeMatches) > 0 {
-		complete = true
-	}
-
-	return bytesProcessed, secondsProcessed, encodingSpeed, complete
-}
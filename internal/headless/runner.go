@@ -0,0 +1,361 @@
+package headless
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/controller"
+	"abb_ia/internal/dto"
+	ia_client "abb_ia/internal/ia"
+	"abb_ia/internal/logger"
+	"abb_ia/internal/mq"
+)
+
+/**
+ * Runner drives the whole audiobook pipeline without the TUI:
+ * Search -> Download -> ?Encoding -> Chapters -> Build -> ?Copy -> ?Upload -> ?Scan -> Cleanup
+ *
+ * The controllers report their progress to the UI pages by name. In headless mode
+ * there are no pages, so the Runner takes over their MQ recipients and
+ * sends the next command to the controllers once a stage is complete.
+ **/
+type Runner struct {
+	mq              *mq.Dispatcher
+	itemURL         string
+	config          *config.Config
+	item            *dto.IAItem
+	done            chan error
+	watches         chan *dto.WatchesChecked
+	listener        sync.Once
+	mu              sync.Mutex // the build fields are reset by Watch while the listener goroutine reads them
+	lastPercent     map[string]int
+	stage           string // the stage running
+	stageController string
+	stageStart      time.Time // the stage timeout starts over with each stage
+}
+
+// MQ recipients served by the Runner instead of the TUI components
+var recipients = []string{
+	mq.Footer,
+	mq.SearchPage,
+	mq.DownloadPage,
+	mq.EncodingPage,
+	mq.ChaptersPage,
+	mq.BuildPage,
+}
+
+// the controllers able to stop a stage running too long
+var stoppable = map[string]bool{
+	mq.DownloadController: true,
+	mq.EncodingController: true,
+	mq.ChaptersController: true,
+	mq.BuildController:    true,
+	mq.CopyController:     true,
+}
+
+func NewRunner(dispatcher *mq.Dispatcher, itemId string) *Runner {
+	r := &Runner{}
+	r.mq = dispatcher
+	r.itemURL = ItemURL(itemId)
+	r.done = make(chan error, 1)
+	r.watches = make(chan *dto.WatchesChecked, 1)
+	r.lastPercent = make(map[string]int)
+	for _, recipient := range recipients {
+		r.mq.RegisterListener(recipient, r.dispatchMessage)
+	}
+	return r
+}
+
+// Convert an item identifier or an item details URL to the details URL
+func ItemURL(itemId string) string {
+	itemId = strings.TrimSpace(itemId)
+	if ia_client.ItemIdFromURL(itemId) != "" {
+		return itemId
+	}
+	baseURL := strings.TrimRight(config.Instance().GetIaBaseUrl(), "/")
+	if baseURL == "" {
+		baseURL = ia_client.IA_BASE_URL
+	}
+	return baseURL + "/details/" + strings.Trim(itemId, "/")
+}
+
+// Run the pipeline and block until the audiobook is built or an error occurs
+func (r *Runner) Run() error {
+	c := config.Instance().GetCopy()
+	return r.build(r.itemURL, &c)
+}
+
+// Build the item with the given settings. The items are built one by one
+func (r *Runner) build(itemURL string, c *config.Config) error {
+	r.listener.Do(func() { go r.startEventListener() })
+	r.mu.Lock()
+	r.itemURL = itemURL
+	r.config = c
+	r.item = nil
+	r.done = make(chan error, 1)
+	r.lastPercent = make(map[string]int)
+	done := r.done
+	r.mu.Unlock()
+	r.print("Searching for " + itemURL)
+	condition := dto.SearchCondition{Title: itemURL, SortBy: c.GetSortBy(), SortOrder: c.GetSortOrder()}
+	r.startStage("Search", mq.SearchPage, mq.SearchController, &dto.SearchCommand{Condition: condition})
+	return r.wait(done, time.Duration(c.GetStageTimeoutHours())*time.Hour)
+}
+
+// Wait for the build result. A stage running longer than the timeout is stopped and the build fails
+func (r *Runner) wait(done chan error, timeout time.Duration) error {
+	if timeout <= 0 {
+		return <-done
+	}
+	for {
+		r.mu.Lock()
+		stage := r.stage
+		start := r.stageStart
+		r.mu.Unlock()
+		select {
+		case err := <-done:
+			return err
+		case <-time.After(time.Until(start.Add(timeout))):
+			r.mu.Lock()
+			expired := r.stageStart.Equal(start)
+			r.mu.Unlock()
+			if expired {
+				r.stopStage()
+				return fmt.Errorf("%s stage timed out after %s", stage, timeout)
+			}
+		}
+	}
+}
+
+// send the command of the next stage to its controller
+func (r *Runner) startStage(stage string, from string, controller string, command dto.Dto) {
+	r.mu.Lock()
+	r.stage = stage
+	r.stageController = controller
+	r.stageStart = time.Now()
+	r.mu.Unlock()
+	r.mq.SendMessage(from, controller, command, true)
+}
+
+func (r *Runner) stopStage() {
+	r.mu.Lock()
+	stage := r.stage
+	controller := r.stageController
+	r.mu.Unlock()
+	if stoppable[controller] {
+		r.mq.SendMessage(mq.BuildPage, controller, &dto.StopCommand{Process: stage, Reason: "Timeout"}, true)
+	}
+}
+
+/**
+ * Check the watches for new uploads and build the new items of the auto build watches.
+ * Repeated every interval if it's not zero. Returns the number of the failed builds
+ **/
+func (r *Runner) Watch(interval time.Duration) int {
+	r.listener.Do(func() { go r.startEventListener() })
+	failed := 0
+	for {
+		r.print("Checking the watches for new items")
+		// forget the result of a scheduled check if any
+		select {
+		case <-r.watches:
+		default:
+		}
+		r.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.CheckWatchesCommand{}, true)
+		checked := <-r.watches
+		for _, w := range checked.Watches {
+			if len(w.NewItems) == 0 {
+				continue
+			}
+			r.print(fmt.Sprintf("%s: %d new item(s)", w.Name, len(w.NewItems)))
+			for _, item := range w.NewItems {
+				r.print(fmt.Sprintf("  %s: %s - %s", item.ID, item.Creator, item.Title))
+			}
+			if !w.AutoBuild {
+				continue
+			}
+			for _, item := range w.NewItems {
+				c := config.Instance().GetCopy()
+				w.Settings.Apply(&c)
+				if err := r.build(ItemURL(item.ID), &c); err != nil {
+					// keep the item in the new items list. It's built again next time
+					logger.Error("Watch build failed: " + err.Error())
+					r.print("Error: " + err.Error())
+					failed++
+					continue
+				}
+				r.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.ClearWatchItemsCommand{Name: w.Name, IDs: []string{item.ID}}, true)
+			}
+		}
+		if interval <= 0 {
+			return failed
+		}
+		r.print(fmt.Sprintf("Next check at %s", time.Now().Add(interval).Format("2006-01-02 15:04")))
+		time.Sleep(interval)
+	}
+}
+
+func (r *Runner) startEventListener() {
+	for {
+		for _, recipient := range recipients {
+			m := r.mq.GetMessage(recipient)
+			if m != nil {
+				r.dispatchMessage(m)
+			}
+		}
+		time.Sleep(mq.PullFrequency)
+	}
+}
+
+func (r *Runner) dispatchMessage(m *mq.Message) {
+	switch dto := m.Dto.(type) {
+	case *dto.UpdateStatus:
+		if dto.Message != "" {
+			r.print(dto.Message)
+		}
+	case *dto.IAItem:
+		r.mu.Lock()
+		r.item = dto
+		r.mu.Unlock()
+	case *dto.SearchComplete:
+		r.searchComplete()
+	case *dto.NothingFoundError:
+		r.mu.Lock()
+		itemURL := r.itemURL
+		r.mu.Unlock()
+		r.finish(fmt.Errorf("no audio item found: %s", itemURL))
+	case *dto.FFMPEGNotFoundError:
+		r.finish(fmt.Errorf("ffmpeg or ffprobe command not found"))
+	case *dto.NewAppVersionFound:
+		r.print(fmt.Sprintf("New version of the Audiobook Builder has been released: %s", dto.NewVersion))
+	case *dto.TotalDownloadProgress:
+		speed := dto.Speed
+		if dto.Limit != "" {
+			speed += " (limit " + dto.Limit + ")"
+		}
+		r.printProgress("Download", dto.Percent, fmt.Sprintf("files: %s, downloaded: %s, speed: %s, ETA: %s", dto.Files, dto.Bytes, speed, dto.ETA))
+	case *dto.EncodingProgress:
+		r.printProgress("Encoding", dto.Percent, fmt.Sprintf("files: %s, speed: %s, ETA: %s", dto.Files, dto.Speed, dto.ETA))
+	case *dto.TotalBuildProgress:
+		r.printProgress("Build", dto.Percent, fmt.Sprintf("parts: %s, speed: %s, ETA: %s", dto.Files, dto.Speed, dto.ETA))
+	case *dto.CopyProgress:
+		r.printProgress("Copy", dto.Percent, fmt.Sprintf("files: %s, copied: %s, speed: %s, ETA: %s", dto.Files, dto.Bytes, dto.Speed, dto.ETA))
+	case *dto.UploadProgress:
+		r.printProgress("Upload", dto.Percent, fmt.Sprintf("files: %s, uploaded: %s, speed: %s, ETA: %s", dto.Files, dto.Bytes, dto.Speed, dto.ETA))
+	case *dto.FileDownloadError:
+		r.print(fmt.Sprintf("Can't download %s: %s", dto.FileName, dto.Error))
+	case *dto.DownloadFailed:
+		// don't build an audiobook with missing files
+		r.finish(fmt.Errorf("%d file(s) failed to download", len(dto.Files)))
+	case *dto.ProcessFailed:
+		r.finish(fmt.Errorf("%s failed: %s", dto.Process, dto.Error))
+	case *dto.DownloadComplete:
+		r.downloadComplete(dto)
+	case *dto.EncodingComplete:
+		r.encodingComplete(dto)
+	case *dto.ChaptersReady:
+		r.chaptersReady(dto)
+	case *dto.BuildComplete:
+		r.startNextStep(dto)
+	case *dto.CopyComplete:
+		r.startNextStep(dto)
+	case *dto.UploadComplete:
+		r.startNextStep(dto)
+	case *dto.ScanComplete:
+		r.startNextStep(dto)
+	case *dto.CleanupComplete:
+		r.cleanupComplete(dto)
+	case *dto.WatchesChecked:
+		r.watchesChecked(dto)
+	default:
+		// per-file progress, book info and chapter list updates are for the TUI only
+	}
+}
+
+func (r *Runner) searchComplete() {
+	r.mu.Lock()
+	item := r.item
+	c := r.config
+	r.mu.Unlock()
+	if item == nil {
+		// NothingFoundError will follow
+		return
+	}
+	ab := &dto.Audiobook{}
+	ab.IAItem = item
+	ab.Config = c
+	r.print(fmt.Sprintf("Found: %s - %s (%d files)", item.Creator, item.Title, len(item.AudioFiles)))
+	r.startStage("Download", mq.SearchPage, mq.DownloadController, &dto.DownloadCommand{Audiobook: ab})
+}
+
+func (r *Runner) downloadComplete(c *dto.DownloadComplete) {
+	ab := c.Audiobook
+	if ab.Config.IsReEncodeFiles() || ab.Config.IsNormalizeLoudness() || ab.Config.IsTrimSilence() {
+		r.startStage("Encoding", mq.DownloadPage, mq.EncodingController, &dto.EncodeCommand{Audiobook: ab})
+	} else {
+		r.startStage("Chapters", mq.DownloadPage, mq.ChaptersController, &dto.ChaptersCreate{Audiobook: ab})
+	}
+}
+
+func (r *Runner) encodingComplete(c *dto.EncodingComplete) {
+	r.startStage("Chapters", mq.EncodingPage, mq.ChaptersController, &dto.ChaptersCreate{Audiobook: c.Audiobook})
+}
+
+func (r *Runner) chaptersReady(c *dto.ChaptersReady) {
+	// the genre matching the item subjects is set by the DownloadController. The book isn't tagged if none matches
+	r.startStage("Build", mq.ChaptersPage, mq.BuildController, &dto.BuildCommand{Audiobook: c.Audiobook})
+}
+
+// start the next final operation: ?Copy -> ?Upload -> ?Scan -> Cleanup, the same as the TUI does
+func (r *Runner) startNextStep(completed dto.Dto) {
+	if step, ok := controller.NextFinalStep(completed); ok {
+		r.startStage(step.Name, mq.BuildPage, step.Controller, step.Command)
+	}
+}
+
+func (r *Runner) cleanupComplete(c *dto.CleanupComplete) {
+	ab := c.Audiobook
+	for _, part := range ab.Parts {
+		r.print("Created: " + controller.OutputFilePath(ab, part.OutputFile))
+	}
+	r.print(fmt.Sprintf("Audiobook has been created: %s - %s", ab.Author, ab.Title))
+	r.finish(nil)
+}
+
+// report 10% progress steps only to keep the output readable in cron/CI logs
+func (r *Runner) printProgress(stage string, percent int, details string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	step := percent / 10 * 10
+	if last, ok := r.lastPercent[stage]; ok && last >= step {
+		return
+	}
+	r.lastPercent[stage] = step
+	r.print(fmt.Sprintf("%s: %3d%% (%s)", stage, percent, details))
+}
+
+func (r *Runner) print(message string) {
+	logger.Info(message)
+	fmt.Printf("%s %s\n", time.Now().Format("2006-01-02 15:04:05"), message)
+}
+
+func (r *Runner) watchesChecked(c *dto.WatchesChecked) {
+	select {
+	case r.watches <- c:
+	default:
+		// a scheduled check of the WatchController. The results are saved already
+	}
+}
+
+func (r *Runner) finish(err error) {
+	r.mu.Lock()
+	done := r.done
+	r.mu.Unlock()
+	select {
+	case done <- err:
+	default:
+	}
+}
@@ -0,0 +1,132 @@
+package ia_client
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"abb_ia/internal/logger"
+)
+
+/**
+ * On-disk cache of IA API responses (search results and item details) keyed by the request URL.
+ * Fresh entries (younger than TTL) are used instead of a request.
+ * Expired entries are used when IA can't be reached, so previously seen items can be browsed offline.
+ * The oldest entries are removed when the cache size exceeds the limit
+ **/
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	maxSize int64
+}
+
+func NewCache(dir string, ttl time.Duration, maxSizeMb int) *Cache {
+	c := &Cache{}
+	c.dir = dir
+	c.ttl = ttl
+	c.maxSize = int64(maxSizeMb) * 1024 * 1024
+	return c
+}
+
+// Get a cached response if it isn't expired
+func (c *Cache) Get(key string) ([]byte, bool) {
+	return c.get(key, c.ttl)
+}
+
+// Get a cached response of any age
+func (c *Cache) GetStale(key string) ([]byte, bool) {
+	return c.get(key, 0)
+}
+
+func (c *Cache) get(key string, ttl time.Duration) ([]byte, bool) {
+	path := c.path(key)
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	if ttl > 0 && time.Since(fi.ModTime()) > ttl {
+		return nil, false
+	}
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+func (c *Cache) Put(key string, body []byte) {
+	if err := os.MkdirAll(c.dir, 0750); err != nil {
+		logger.Error("IA Cache can't create cache directory " + c.dir + ": " + err.Error())
+		return
+	}
+	// write to a temporary file first so concurrent readers never see a partial entry
+	f, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		logger.Error("IA Cache can't write cache entry: " + err.Error())
+		return
+	}
+	tempPath := f.Name()
+	_, err = f.Write(body)
+	f.Close()
+	if err != nil {
+		logger.Error("IA Cache can't write cache entry: " + err.Error())
+		os.Remove(tempPath)
+		return
+	}
+	if err := os.Rename(tempPath, c.path(key)); err != nil {
+		logger.Error("IA Cache can't write cache entry: " + err.Error())
+		os.Remove(tempPath)
+		return
+	}
+	c.evict()
+}
+
+// Remove all cached responses
+func (c *Cache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// Remove the oldest entries until the cache fits the size limit
+func (c *Cache) evict() {
+	if c.maxSize <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	files := []os.FileInfo{}
+	var size int64 = 0
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		fi, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, fi)
+		size += fi.Size()
+	}
+	if size <= c.maxSize {
+		return
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, fi := range files {
+		if size <= c.maxSize {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, fi.Name())); err == nil {
+			size -= fi.Size()
+		}
+	}
+}
+
+func (c *Cache) path(key string) string {
+	hash := sha1.Sum([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(hash[:])+".json")
+}
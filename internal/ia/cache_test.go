@@ -0,0 +1,81 @@
+package ia_client_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/ia"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := ia_client.NewCache(dir, time.Hour, 1)
+
+	_, ok := cache.Get("key1")
+	assert.False(t, ok)
+	cache.Put("key1", []byte("value1"))
+	body, ok := cache.Get("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", string(body))
+
+	// expired entry
+	expired := ia_client.NewCache(dir, time.Nanosecond, 1)
+	time.Sleep(time.Millisecond)
+	_, ok = expired.Get("key1")
+	assert.False(t, ok)
+	body, ok = expired.GetStale("key1")
+	assert.True(t, ok)
+	assert.Equal(t, "value1", string(body))
+
+	// the oldest entries are removed when the cache is over the size limit
+	big := make([]byte, 600*1024)
+	cache.Put("key2", big)
+	cache.Put("key3", big)
+	_, ok = cache.Get("key1")
+	assert.False(t, ok)
+	_, ok = cache.Get("key2")
+	assert.False(t, ok)
+	_, ok = cache.Get("key3")
+	assert.True(t, ok)
+	entries, _ := filepath.Glob(filepath.Join(dir, "*"))
+	assert.Equal(t, 1, len(entries))
+
+	assert.NoError(t, cache.Clear())
+	_, err := os.Stat(dir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestFakeCachedDetails(t *testing.T) {
+	s := fakeia.NewServer()
+	ia := newFakeClient(s, 5)
+	ia.SetCache(ia_client.NewCache(t.TempDir(), time.Hour, 10))
+	detailsPath := "/details/Fake_Science_Lectures/"
+
+	item := ia.GetItemDetails("Fake_Science_Lectures")
+	assert.Equal(t, []string{"Fake Science Lectures"}, item.Metadata.Title)
+	item = ia.GetItemDetails("Fake_Science_Lectures")
+	assert.Equal(t, []string{"Fake Science Lectures"}, item.Metadata.Title)
+	assert.Equal(t, 1, s.Requests(detailsPath))
+
+	res := ia.Search("Fake Lecturer", "", "audio", "date", "asc")
+	assert.Equal(t, 1, len(res.Response.Docs))
+	res = ia.Search("Fake Lecturer", "", "audio", "date", "asc")
+	assert.Equal(t, 1, len(res.Response.Docs))
+	assert.Equal(t, 1, s.Requests("/advancedsearch.php"))
+
+	ia.SetForceRefresh(true)
+	ia.GetItemDetails("Fake_Science_Lectures")
+	assert.Equal(t, 2, s.Requests(detailsPath))
+	ia.SetForceRefresh(false)
+
+	// the archive is offline. The cached response is used even if it's expired
+	s.Close()
+	ia.SetForceRefresh(true)
+	item = ia.GetItemDetails("Fake_Science_Lectures")
+	assert.Equal(t, []string{"Fake Science Lectures"}, item.Metadata.Title)
+}
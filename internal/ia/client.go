@@ -1,6 +1,8 @@
 package ia_client
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
@@ -8,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,21 +20,39 @@ import (
 )
 
 const (
-	IA_BASE_URL = "https://archive.org"
-	MOCK_DIR    = "mock"
+	IA_BASE_URL      = "https://archive.org"
+	MOCK_DIR         = "mock"
+	DOWNLOAD_RETRIES = 5
+	// the scraping API doesn't accept less than 100 items per request
+	SCRAPE_MIN_COUNT = 100
 )
 
 type IAClient struct {
 	restyClient    *resty.Client
+	httpClient     *http.Client // file downloads
+	baseURL        string
+	authorization  string
+	cookie         string
 	maxSearchRows  int
 	page           int
 	loadMockResult bool
 	saveMockResult bool
+	cache          *Cache
+	forceRefresh   bool
+	limiter        *RateLimiter // download rate limit shared by the concurrent downloads
+
+	// scraping API backend state
+	useScrapeAPI bool
+	scrapeCursor string
+	scrapeDone   bool
+	scrapeTotal  int
+	scrapeBuffer []SearchDoc
 }
 
 func New(maxSearchRows int, useMock bool, saveMock bool) *IAClient {
 	client := &IAClient{}
 	client.maxSearchRows = maxSearchRows
+	client.baseURL = IA_BASE_URL
 	client.loadMockResult = useMock
 	client.saveMockResult = saveMock
 
@@ -41,50 +62,270 @@ func New(maxSearchRows int, useMock bool, saveMock bool) *IAClient {
 		}
 	}
 	client.restyClient = resty.New()
+	client.restyClient.SetPreRequestHook(func(_ *resty.Client, req *http.Request) error {
+		client.authorize(req)
+		return nil
+	})
+	client.restyClient.SetRedirectPolicy(resty.RedirectPolicyFunc(client.checkRedirect))
+	client.httpClient = &http.Client{CheckRedirect: client.checkRedirect}
 	return client
 }
 
+// Use another IA server (a local fake archive, for example) instead of https://archive.org
+func (client *IAClient) SetBaseURL(baseURL string) {
+	baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/")
+	if baseURL != "" {
+		client.baseURL = baseURL
+	}
+}
+
+func (client *IAClient) BaseURL() string {
+	return client.baseURL
+}
+
+// URL of an item file. Files are served by the item server using the same scheme as the base URL
+func (client *IAClient) FileURL(iaServer string, iaDir string, iaFile string) string {
+	scheme := "https"
+	if u, err := url.Parse(client.baseURL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	URL := &url.URL{
+		Scheme: scheme,
+		Host:   iaServer,
+		Path:   strings.TrimPrefix(iaDir, "/") + "/" + strings.TrimPrefix(iaFile, "/"),
+	}
+	return URL.String()
+}
+
+// Authenticate the requests to get access to restricted (lending) items.
+// Either IA S3-like API keys or the login session cookies copied from a browser are used
+func (client *IAClient) SetCredentials(accessKey string, secretKey string, cookie string) {
+	client.authorization = ""
+	client.cookie = ""
+	if accessKey != "" && secretKey != "" {
+		client.authorization = "LOW " + accessKey + ":" + secretKey
+	} else if cookie != "" {
+		client.cookie = cookie
+	}
+}
+
+// The credentials are sent to the Internet Archive hosts and the configured archive only.
+// The file downloads may be redirected to other hosts
+func (client *IAClient) authorize(req *http.Request) {
+	req.Header.Del("Authorization")
+	req.Header.Del("Cookie")
+	if !client.isArchiveHost(req.URL) {
+		return
+	}
+	if client.authorization != "" {
+		req.Header.Set("Authorization", client.authorization)
+	}
+	if client.cookie != "" {
+		req.Header.Set("Cookie", client.cookie)
+	}
+}
+
+func (client *IAClient) isArchiveHost(u *url.URL) bool {
+	if base, err := url.Parse(client.baseURL); err == nil && base.Host == u.Host {
+		return true
+	}
+	host := u.Hostname()
+	return host == "archive.org" || strings.HasSuffix(host, ".archive.org")
+}
+
+// follow the redirects like http.Client does by default, authorizing the requests to the archive hosts only
+func (client *IAClient) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= 10 {
+		return errors.New("stopped after 10 redirects")
+	}
+	client.authorize(req)
+	return nil
+}
+
+// Cache the search results and item details on disk
+func (client *IAClient) SetCache(cache *Cache) {
+	client.cache = cache
+}
+
+// Don't use the cached responses (they are updated though)
+func (client *IAClient) SetForceRefresh(forceRefresh bool) {
+	client.forceRefresh = forceRefresh
+}
+
+// Limit the download rate of all the files downloaded by the client
+func (client *IAClient) SetRateLimiter(limiter *RateLimiter) {
+	client.limiter = limiter
+}
+
+func (client *IAClient) RateLimiter() *RateLimiter {
+	return client.limiter
+}
+
+// Get and decode a JSON response using the cache if it's enabled
+func (client *IAClient) getJson(requestURL string, result any) error {
+	if client.cache != nil && !client.forceRefresh {
+		if body, ok := client.cache.Get(requestURL); ok {
+			logger.Debug("IA cached response: " + requestURL)
+			return json.Unmarshal(body, result)
+		}
+	}
+	body, err := client.get(requestURL)
+	if err != nil {
+		// IA is unreachable. Use the expired response if there is one
+		if client.cache != nil {
+			if body, ok := client.cache.GetStale(requestURL); ok {
+				logger.Warn("IA request failed: " + err.Error() + ". Using the cached response")
+				return json.Unmarshal(body, result)
+			}
+		}
+		return err
+	}
+	if err := json.Unmarshal(body, result); err != nil {
+		return err
+	}
+	if client.cache != nil {
+		client.cache.Put(requestURL, body)
+	}
+	return nil
+}
+
+// Get the response body. The server error responses are returned as errors
+func (client *IAClient) get(requestURL string) ([]byte, error) {
+	resp, err := client.restyClient.R().Get(requestURL)
+	if err == nil && resp.IsError() {
+		err = fmt.Errorf("unexpected server response: %s", resp.Status())
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body(), nil
+}
+
+// Use the cursor-based scraping API instead of advancedsearch.php paging.
+// It stays fast on deep pages and doesn't skip or repeat items if IA reindexes during the session
+func (client *IAClient) SetUseScrapeAPI(useScrapeAPI bool) {
+	client.useScrapeAPI = useScrapeAPI
+}
+
 func (client *IAClient) Search(author string, title string, mediaType string, sortBy string, sortOrder string) *SearchResponse {
-	if strings.Contains(title, IA_BASE_URL+"/details/") {
-		item_id := strings.Split(title, "/")[4]
+	if item_id := ItemIdFromURL(title); item_id != "" {
 		return client.searchByID(item_id, mediaType)
 	} else {
 		client.page = 1
-		return client.searchByTitle(author, title, mediaType, sortBy, sortOrder)
+		return client.searchByFilter(SearchFilter{Author: author, Title: title}, mediaType, sortBy, sortOrder)
 	}
 }
 
 func (client *IAClient) GetNextPage(author string, title string, mediaType string, sortBy string, sortOrder string) *SearchResponse {
-	if strings.Contains(title, IA_BASE_URL+"/details/") {
+	if ItemIdFromURL(title) != "" {
 		return &SearchResponse{}
 	} else {
 		client.page += 1
-		resp := client.searchByTitle(author, title, mediaType, sortBy, sortOrder)
+		resp := client.searchByFilter(SearchFilter{Author: author, Title: title}, mediaType, sortBy, sortOrder)
 		return resp
 	}
 }
 
-func (client *IAClient) searchByTitle(author string, title string, mediaType string, sortBy string, sortOrder string) *SearchResponse {
+// Search for audio items using advanced search conditions.
+// If a collection is specified, its audio items and sub-collections are listed
+func (client *IAClient) SearchByFilter(filter SearchFilter, sortBy string, sortOrder string) *SearchResponse {
+	if filter.Collection == "" && ItemIdFromURL(filter.Title) != "" {
+		return client.Search(filter.Author, filter.Title, "audio", sortBy, sortOrder)
+	}
+	client.page = 1
+	if client.useScrapeAPI && !client.loadMockResult {
+		client.scrapeCursor = ""
+		client.scrapeDone = false
+		client.scrapeBuffer = nil
+		return client.scrapeNextPage(filter, filter.mediaType(), sortBy, sortOrder)
+	}
+	return client.searchByFilter(filter, filter.mediaType(), sortBy, sortOrder)
+}
+
+func (client *IAClient) GetNextPageByFilter(filter SearchFilter, sortBy string, sortOrder string) *SearchResponse {
+	if filter.Collection == "" && ItemIdFromURL(filter.Title) != "" {
+		return &SearchResponse{}
+	}
+	client.page += 1
+	if client.useScrapeAPI && !client.loadMockResult {
+		return client.scrapeNextPage(filter, filter.mediaType(), sortBy, sortOrder)
+	}
+	return client.searchByFilter(filter, filter.mediaType(), sortBy, sortOrder)
+}
+
+// Return the next maxSearchRows items. The scraping API returns at least 100 items per request,
+// so the items are buffered and the next batch is requested using the cursor when the buffer runs low
+func (client *IAClient) scrapeNextPage(filter SearchFilter, mediaType string, sortBy string, sortOrder string) *SearchResponse {
+	for len(client.scrapeBuffer) < client.maxSearchRows && !client.scrapeDone {
+		scrapeResp, err := client.scrape(filter, mediaType, sortBy, sortOrder)
+		if err != nil {
+			logger.Error("IAClient Scrape() error: " + err.Error())
+			break
+		}
+		client.scrapeBuffer = append(client.scrapeBuffer, scrapeResp.Items...)
+		client.scrapeTotal = scrapeResp.Total
+		client.scrapeCursor = scrapeResp.Cursor
+		// no cursor means there are no more items
+		client.scrapeDone = scrapeResp.Cursor == ""
+	}
+
+	rows := client.maxSearchRows
+	if rows > len(client.scrapeBuffer) {
+		rows = len(client.scrapeBuffer)
+	}
+	result := &SearchResponse{}
+	result.Response.NumFound = client.scrapeTotal
+	result.Response.Start = (client.page - 1) * client.maxSearchRows
+	result.Response.Docs = client.scrapeBuffer[:rows]
+	client.scrapeBuffer = client.scrapeBuffer[rows:]
+	return result
+}
+
+func (client *IAClient) scrape(filter SearchFilter, mediaType string, sortBy string, sortOrder string) (*ScrapeResponse, error) {
+	count := client.maxSearchRows
+	if count < SCRAPE_MIN_COUNT {
+		count = SCRAPE_MIN_COUNT
+	}
+	params := url.Values{}
+	params.Set("q", filter.Query(mediaType))
+	params.Set("fields", "identifier,title,creator,description,mediatype,licenseurl")
+	params.Set("count", strconv.Itoa(count))
+	if sortBy != "" {
+		params.Set("sorts", strings.TrimSpace(sortBy+" "+sortOrder))
+	}
+	if client.scrapeCursor != "" {
+		params.Set("cursor", client.scrapeCursor)
+	}
+	scrapeURL := client.baseURL + "/services/search/v1/scrape?" + params.Encode()
+	logger.Debug("IA request: " + scrapeURL)
+
+	// the pages are not cached. A cached page may have an expired cursor and a stale one may skip or repeat items
+	body, err := client.get(scrapeURL)
+	if err != nil {
+		return nil, err
+	}
+	result := &ScrapeResponse{}
+	if err := json.Unmarshal(body, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (client *IAClient) searchByFilter(filter SearchFilter, mediaType string, sortBy string, sortOrder string) *SearchResponse {
 	mockFile := MOCK_DIR + "/SearchByAuthorAndTitle.json"
+	if filter.Collection != "" {
+		mockFile = MOCK_DIR + "/SearchCollection.json"
+	}
 	result := &SearchResponse{}
 	if client.loadMockResult {
 		if err := utils.LoadJson(mockFile, result); err != nil {
 			logger.Error("IA Client SearchByAuthorAndTitle() mock load error: " + err.Error())
 		}
 	} else {
-		searchCondition := ""
-		if author != "" && title != "" {
-			searchCondition = fmt.Sprintf("creator:(%s)+AND+title:(%s)", url.QueryEscape(author), url.QueryEscape(title))
-		} else if author != "" {
-			searchCondition = fmt.Sprintf("creator:(%s)", url.QueryEscape(author))
-		} else if title != "" {
-			searchCondition = fmt.Sprintf("title:(%s)", url.QueryEscape(title))
-		}
-		var searchURL = fmt.Sprintf(IA_BASE_URL+"/advancedsearch.php?q=%s+AND+mediatype:(%s)&sort=%s+%s&output=json&rows=%d&page=%d",
-			searchCondition, mediaType, sortBy, sortOrder, client.maxSearchRows, client.page)
+		var searchURL = fmt.Sprintf(client.baseURL+"/advancedsearch.php?q=%s&sort=%s+%s&output=json&rows=%d&page=%d",
+			url.QueryEscape(filter.Query(mediaType)), sortBy, sortOrder, client.maxSearchRows, client.page)
 		logger.Debug("IA request: " + searchURL)
-		_, err := client.restyClient.R().SetResult(result).Get(searchURL)
-		if err != nil {
+		if err := client.getJson(searchURL, result); err != nil {
 			logger.Error("IAClient SearchByTitle() error: " + err.Error())
 		}
 		if client.saveMockResult {
@@ -105,11 +346,10 @@ func (client *IAClient) searchByID(itemId string, mediaType string) *SearchRespo
 			logger.Error("IAClient SearchByID() mock load error: " + err.Error())
 		}
 	} else {
-		var searchURL = fmt.Sprintf(IA_BASE_URL+"/advancedsearch.php?q=identifier:(%s)+AND+mediatype:(%s)&output=json&rows=%d&page=1",
+		var searchURL = fmt.Sprintf(client.baseURL+"/advancedsearch.php?q=identifier:(%s)+AND+mediatype:(%s)&output=json&rows=%d&page=1",
 			itemId, mediaType, client.maxSearchRows)
 		logger.Debug("IA request: " + searchURL)
-		_, err := client.restyClient.R().SetResult(result).Get(searchURL)
-		if err != nil {
+		if err := client.getJson(searchURL, result); err != nil {
 			logger.Error("IAClient SearchByID() error: " + err.Error())
 		}
 	}
@@ -132,9 +372,8 @@ func (client *IAClient) GetItemDetails(itemId string) *ItemDetails {
 			logger.Error("IAClient GetItemDetails() mock load error: " + err.Error())
 		}
 	} else {
-		var getURL = fmt.Sprintf(IA_BASE_URL+"/details/%s/?output=json", itemId)
-		_, err := client.restyClient.R().SetResult(result).Get(getURL)
-		if err != nil {
+		var getURL = fmt.Sprintf(client.baseURL+"/details/%s/?output=json", itemId)
+		if err := client.getJson(getURL, result); err != nil {
 			logger.Error("IAClient GetItemDetails() error: " + err.Error())
 		}
 	}
@@ -148,7 +387,9 @@ func (client *IAClient) GetItemDetails(itemId string) *ItemDetails {
 	return result
 }
 
-func (client *IAClient) DownloadFile(localDir string, localFile string, iaServer string, iaDir string, iaFile string, fileId int, estimatedSize int64, updateProgress Fn) {
+// Download a file from IA. Transient errors (network errors, 429, 5xx) are retried with exponential backoff.
+// An interrupted download is resumed from the partially downloaded file
+func (client *IAClient) DownloadFile(localDir string, localFile string, iaServer string, iaDir string, iaFile string, fileId int, estimatedSize int64, updateProgress Fn) error {
 
 	if client.loadMockResult {
 		delay := time.Duration(rand.Intn(10)) // 100
@@ -156,57 +397,155 @@ func (client *IAClient) DownloadFile(localDir string, localFile string, iaServer
 			updateProgress(fileId, iaFile, estimatedSize, int64(float32(estimatedSize)*float32(percent)/100), percent)
 			time.Sleep(delay * time.Millisecond)
 		}
-		return
+		return nil
 	}
 
-	iaDir = strings.TrimPrefix(iaDir, "/")
-	iaFile = strings.TrimPrefix(iaFile, "/")
-	URL := &url.URL{
-		Scheme: "https",
-		Host:   iaServer,
-		Path:   iaDir + "/" + iaFile,
+	var err error
+	for attempt := 1; attempt <= DOWNLOAD_RETRIES; attempt++ {
+		var retryAfter time.Duration
+		retryAfter, err = client.downloadFile(localDir, localFile, iaServer, iaDir, iaFile, fileId, estimatedSize, updateProgress)
+		if err == nil {
+			return nil
+		}
+		if retryAfter < 0 {
+			// not recoverable
+			break
+		}
+		if attempt < DOWNLOAD_RETRIES {
+			if retryAfter == 0 {
+				retryAfter = backoffDelay(attempt)
+			}
+			logger.Warn(fmt.Sprintf("IAClient DownloadFile() %s attempt %d of %d failed: %s. Retrying in %s", iaFile, attempt, DOWNLOAD_RETRIES, err.Error(), retryAfter))
+			time.Sleep(retryAfter)
+		}
 	}
-	fileUrl := URL.String()
+	logger.Error("IAClient DownloadFile() can't download " + iaFile + ": " + err.Error())
+	return err
+}
+
+// Make a single download attempt. Returns the delay requested by the server before the next attempt
+// (0 - use default backoff, negative - the error is not recoverable)
+func (client *IAClient) downloadFile(localDir string, localFile string, iaServer string, iaDir string, iaFile string, fileId int, estimatedSize int64, updateProgress Fn) (time.Duration, error) {
+	iaFile = strings.TrimPrefix(iaFile, "/")
+	fileUrl := client.FileURL(iaServer, iaDir, iaFile)
 	localPath := filepath.Join(localDir, localFile)
 	tempPath := localPath + ".tmp"
+	validatorPath := tempPath + ".etag"
 
-	req, _ := http.NewRequest("GET", fileUrl, nil)
-	resp, _ := http.DefaultClient.Do(req)
-	if resp == nil || resp.StatusCode != 200 {
-		logger.Fatal("Error while downloading: " + resp.Status)
-		return
+	tempDir := filepath.Dir(tempPath)
+	if err := os.MkdirAll(tempDir, 0750); err != nil {
+		return -1, fmt.Errorf("can't create output directory: %w", err)
+	}
+
+	// resume an interrupted download if there is a partially downloaded file
+	var offset int64 = 0
+	validator := ""
+	if fi, err := os.Stat(tempPath); err == nil && fi.Size() > 0 {
+		if v, err := os.ReadFile(validatorPath); err == nil && len(v) > 0 {
+			offset = fi.Size()
+			validator = string(v)
+		}
+	}
+
+	req, err := http.NewRequest("GET", fileUrl, nil)
+	if err != nil {
+		return -1, err
+	}
+	client.authorize(req)
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	if offset > 0 && (resp.StatusCode == http.StatusRequestedRangeNotSatisfiable ||
+		(resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp.Header.Get("Content-Range")) != offset)) {
+		// the partial file doesn't match the remote one. Start from scratch
+		resp.Body.Close()
+		logger.Debug("Can't resume " + iaFile + " download: " + resp.Status)
+		os.Remove(tempPath)
+		os.Remove(validatorPath)
+		offset = 0
+		req.Header.Del("Range")
+		req.Header.Del("If-Range")
+		resp, err = client.httpClient.Do(req)
+		if err != nil {
+			return 0, err
+		}
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		err := fmt.Errorf("unexpected server response: %s", resp.Status)
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable:
+			return retryAfterDelay(resp.Header.Get("Retry-After")), err
+		case resp.StatusCode >= 500:
+			return 0, err
+		case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+			return -1, fmt.Errorf("access denied (%s). The item may be restricted, check the Internet Archive credentials", resp.Status)
+		default:
+			return -1, err
+		}
+	}
 
-	tempDir := filepath.Dir(tempPath)
-	if err := os.MkdirAll(tempDir, 0750); err != nil {
-		logger.Fatal("Can't create output directory: " + err.Error())
-		return
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if resp.StatusCode == http.StatusPartialContent {
+		flags = os.O_WRONLY | os.O_APPEND
+		logger.Debug(fmt.Sprintf("Resuming %s download from byte %d", iaFile, offset))
+	} else {
+		// the server sent the whole file (the remote file has changed or Range isn't supported)
+		offset = 0
+		if v := resp.Header.Get("ETag"); v != "" {
+			os.WriteFile(validatorPath, []byte(v), 0644)
+		} else if v := resp.Header.Get("Last-Modified"); v != "" {
+			os.WriteFile(validatorPath, []byte(v), 0644)
+		} else {
+			os.Remove(validatorPath)
+		}
 	}
-	f, err := os.OpenFile(tempPath, os.O_CREATE|os.O_WRONLY, 0644)
+
+	f, err := os.OpenFile(tempPath, flags, 0644)
 	if err != nil {
-		logger.Fatal("Can't create temporary file: " + err.Error())
-		return
+		return -1, fmt.Errorf("can't create temporary file: %w", err)
 	}
 	defer f.Close()
 
+	var body io.Reader = resp.Body
+	if client.limiter != nil {
+		body = &limitedReader{Reader: resp.Body, limiter: client.limiter}
+	}
+	size := offset + resp.ContentLength
+	if resp.ContentLength < 0 {
+		// no Content-Length (chunked response). Take the total size from Content-Range or the IA metadata
+		size = contentRangeSize(resp.Header.Get("Content-Range"))
+		if size < 0 || resp.StatusCode == http.StatusOK {
+			size = estimatedSize
+		}
+	}
 	progressReader := &ProgressReader{
 		FileId:   fileId,
 		FileName: iaFile,
-		Reader:   resp.Body,
-		Size:     resp.ContentLength,
+		Reader:   body,
+		Size:     size,
+		Pos:      offset,
 		Callback: updateProgress,
 	}
 
 	if _, err := io.Copy(f, progressReader); err != nil {
-		logger.Fatal("Error while downloading: " + err.Error())
+		// keep the partially downloaded file. The next attempt will resume from there
+		return 0, err
 	}
 
-	// fix incorrect ContentLength problem
-	if !client.loadMockResult {
-		updateProgress(fileId, iaFile, resp.ContentLength, resp.ContentLength, 100)
-	}
+	// the size is unknown or incorrect if the server sent no or wrong Content-Length. Report the bytes downloaded
+	updateProgress(fileId, iaFile, progressReader.Pos, progressReader.Pos, 100)
 
-	os.Rename(tempPath, localPath)
+	f.Close()
+	if err := os.Rename(tempPath, localPath); err != nil {
+		return -1, err
+	}
+	os.Remove(validatorPath)
 	logger.Debug(iaFile + " downloaded to " + localPath)
+	return 0, nil
 }
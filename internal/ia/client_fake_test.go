@@ -0,0 +1,144 @@
+package ia_client_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"abb_ia/internal/fakeia"
+	"abb_ia/internal/ia"
+	"abb_ia/internal/utils"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newFakeClient(s *fakeia.Server, rows int) *ia_client.IAClient {
+	ia := ia_client.New(rows, false, false)
+	ia.SetBaseURL(s.URL + "/")
+	return ia
+}
+
+func TestFakeSearch(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	ia := newFakeClient(s, 1)
+	assert.Equal(t, s.URL, ia.BaseURL())
+
+	res := ia.Search("Old Time Radio Researchers", "Single Episodes", "audio", "date", "asc")
+	assert.Equal(t, 1, len(res.Response.Docs))
+	assert.Equal(t, "OTRR_Fake_Show_Singles", res.Response.Docs[0].Identifier)
+
+	res = ia.Search("", s.URL+"/details/Fake_Science_Lectures", "audio", "date", "asc") // search by item ID
+	assert.Equal(t, 1, len(res.Response.Docs))
+	assert.Equal(t, "Fake_Science_Lectures", res.Response.Docs[0].Identifier)
+	// archive.org links are searched by item ID with a custom base URL too
+	res = ia.Search("", "https://archive.org/details/Fake_Science_Lectures", "audio", "date", "asc")
+	assert.Equal(t, 1, len(res.Response.Docs))
+	assert.Equal(t, "Fake_Science_Lectures", res.Response.Docs[0].Identifier)
+
+	filter := ia_client.SearchFilter{Collection: "fake_otr_collection"}
+	res = ia.SearchByFilter(filter, "date", "asc")
+	assert.Equal(t, 2, res.Response.NumFound)
+	assert.Equal(t, "OTRR_Fake_Show_Singles", res.Response.Docs[0].Identifier)
+	res = ia.GetNextPageByFilter(filter, "date", "asc")
+	assert.Equal(t, "Fake_Science_Lectures", res.Response.Docs[0].Identifier)
+	res = ia.GetNextPageByFilter(filter, "date", "asc")
+	assert.Equal(t, 0, len(res.Response.Docs))
+}
+
+func TestFakeScrape(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	ia := newFakeClient(s, 1)
+	ia.SetUseScrapeAPI(true)
+
+	filter := ia_client.SearchFilter{Collection: "fake_otr_collection"}
+	res := ia.SearchByFilter(filter, "date", "asc")
+	assert.Equal(t, 2, res.Response.NumFound)
+	assert.Equal(t, "OTRR_Fake_Show_Singles", res.Response.Docs[0].Identifier)
+	res = ia.GetNextPageByFilter(filter, "date", "asc")
+	assert.Equal(t, "Fake_Science_Lectures", res.Response.Docs[0].Identifier)
+	res = ia.GetNextPageByFilter(filter, "date", "asc")
+	assert.Equal(t, 0, len(res.Response.Docs))
+	// both items are fetched by a single scrape request
+	assert.Equal(t, 1, s.Requests("/services/search/v1/scrape"))
+
+	// the scrape pages are not cached
+	ia.SetCache(ia_client.NewCache(t.TempDir(), time.Hour, 10))
+	ia.SearchByFilter(filter, "date", "asc")
+	res = ia.SearchByFilter(filter, "date", "asc")
+	assert.Equal(t, "OTRR_Fake_Show_Singles", res.Response.Docs[0].Identifier)
+	assert.Equal(t, 3, s.Requests("/services/search/v1/scrape"))
+}
+
+func TestFakeGetItemDetails(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	ia := newFakeClient(s, 5)
+
+	item := ia.GetItemDetails("OTRR_Fake_Show_Singles")
+	assert.Equal(t, []string{"Fake Show - Single Episodes"}, item.Metadata.Title)
+	assert.Equal(t, []string{"Old Time Radio Researchers Group"}, item.Metadata.Creator)
+	assert.Equal(t, s.Listener.Addr().String(), item.Server)
+	assert.Equal(t, 4, len(item.Files))
+	file := item.Files["/Fake_Show_49-01-08_Episode_2.mp3"]
+	assert.Equal(t, "VBR MP3", file.Format)
+	assert.Equal(t, "11:40", file.Length)
+	md5, _, _ := s.Checksums("OTRR_Fake_Show_Singles", "Fake_Show_49-01-08_Episode_2.mp3")
+	assert.Equal(t, md5, file.Md5)
+	assert.Equal(t, s.URL+"/files/OTRR_Fake_Show_Singles/Fake_Show_Cover.jpg", ia.FileURL(item.Server, item.Dir, "Fake_Show_Cover.jpg"))
+}
+
+func TestFakeDownloadFile(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	ia := newFakeClient(s, 5)
+	item := ia.GetItemDetails("Fake_Science_Lectures")
+	localDir := t.TempDir()
+	progress := func(fileId int, fileName string, size int64, pos int64, percent int) {}
+
+	// a transient server error is retried
+	s.FailDownloads("Fake_Science_Lectures", "lecture_01.ogg", 1)
+	err := ia.DownloadFile(localDir, "lecture_01.ogg", item.Server, item.Dir, "lecture_01.ogg", 0, 0, progress)
+	assert.NoError(t, err)
+	file := item.Files["/lecture_01.ogg"]
+	assert.NoError(t, utils.VerifyChecksum(filepath.Join(localDir, "lecture_01.ogg"), file.Md5, file.Sha1, file.Crc32))
+
+	// an interrupted download is resumed
+	content := s.Content("Fake_Science_Lectures", "lecture_02.ogg")
+	tempPath := filepath.Join(localDir, "lecture_02.ogg.tmp")
+	assert.NoError(t, os.WriteFile(tempPath, content[:1000], 0644))
+	assert.NoError(t, os.WriteFile(tempPath+".etag", []byte("\""+item.Files["/lecture_02.ogg"].Md5+"\""), 0644))
+	err = ia.DownloadFile(localDir, "lecture_02.ogg", item.Server, item.Dir, "lecture_02.ogg", 1, 0, progress)
+	assert.NoError(t, err)
+	downloaded, _ := os.ReadFile(filepath.Join(localDir, "lecture_02.ogg"))
+	assert.Equal(t, content, downloaded)
+
+	// not recoverable error
+	err = ia.DownloadFile(localDir, "missing.ogg", item.Server, item.Dir, "missing.ogg", 2, 0, progress)
+	assert.Error(t, err)
+}
+
+func TestFakeRestrictedItem(t *testing.T) {
+	s := fakeia.NewServer()
+	defer s.Close()
+	s.SetCredentials("access", "secret", "logged-in-user=user; logged-in-sig=sig")
+	ia := newFakeClient(s, 5)
+	progress := func(fileId int, fileName string, size int64, pos int64, percent int) {}
+
+	item := ia.GetItemDetails("Fake_Lending_Audiobook")
+	assert.True(t, item.IsRestricted())
+	assert.False(t, ia.GetItemDetails("Fake_Science_Lectures").IsRestricted())
+
+	// anonymous download is not retried
+	err := ia.DownloadFile(t.TempDir(), "part1.mp3", item.Server, item.Dir, "fake_lending_01.mp3", 0, 0, progress)
+	assert.Error(t, err)
+	assert.Equal(t, 1, s.Requests("/files/Fake_Lending_Audiobook/fake_lending_01.mp3"))
+
+	ia.SetCredentials("access", "secret", "")
+	assert.NoError(t, ia.DownloadFile(t.TempDir(), "part1.mp3", item.Server, item.Dir, "fake_lending_01.mp3", 0, 0, progress))
+
+	ia.SetCredentials("", "", "logged-in-user=user; logged-in-sig=sig")
+	assert.NoError(t, ia.DownloadFile(t.TempDir(), "part1.mp3", item.Server, item.Dir, "fake_lending_01.mp3", 0, 0, progress))
+}
@@ -2,6 +2,9 @@ package ia_client_test
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -79,3 +82,91 @@ func TestDownloadItem(t *testing.T) {
 func UpdateProgress(fileId int, fileName string, size int64, pos int64, percent int) {
 	fmt.Printf("Downloading... %d%%\n", percent)
 }
+
+func TestDownloadFileResume(t *testing.T) {
+	content := []byte("0123456789abcdefghij")
+	etag := `"v1"`
+	ranges := []string{}
+	// a file server sending chunked responses without Content-Length
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		start := 0
+		if r.Header.Get("Range") != "" && r.Header.Get("If-Range") == etag {
+			fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start)
+			if start >= len(content) {
+				w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+				return
+			}
+		}
+		w.Header().Set("ETag", etag)
+		if start > 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		w.(http.Flusher).Flush()
+		w.Write(content[start:])
+	}))
+	defer s.Close()
+	u, _ := url.Parse(s.URL)
+	ia := ia_client.New(5, false, false)
+	ia.SetBaseURL(s.URL)
+
+	tests := []struct {
+		name      string
+		partial   string
+		validator string
+		wantRange string
+	}{
+		{"resumed", "0123456789", etag, "bytes=10-"},
+		{"the remote file has changed", "XXXXXXXXXXXXX", `"v0"`, "bytes=13-"},
+		{"range not satisfiable", "0123456789abcdefghijklmnop", etag, "bytes=26-"},
+		{"nothing to resume", "", "", ""},
+	}
+	for _, tt := range tests {
+		ranges = ranges[:0]
+		localDir := t.TempDir()
+		if tt.partial != "" {
+			os.WriteFile(filepath.Join(localDir, "file.mp3.tmp"), []byte(tt.partial), 0644)
+			os.WriteFile(filepath.Join(localDir, "file.mp3.tmp.etag"), []byte(tt.validator), 0644)
+		}
+		var lastSize, lastPos int64
+		progress := func(fileId int, fileName string, size int64, pos int64, percent int) {
+			lastSize, lastPos = size, pos
+		}
+		assert.NoError(t, ia.DownloadFile(localDir, "file.mp3", u.Host, "/items", "file.mp3", 0, int64(len(content)), progress), tt.name)
+		downloaded, _ := os.ReadFile(filepath.Join(localDir, "file.mp3"))
+		assert.Equal(t, string(content), string(downloaded), tt.name)
+		assert.Equal(t, int64(len(content)), lastSize, tt.name)
+		assert.Equal(t, int64(len(content)), lastPos, tt.name)
+		assert.Equal(t, tt.wantRange, ranges[0], tt.name)
+		_, err := os.Stat(filepath.Join(localDir, "file.mp3.tmp.etag"))
+		assert.True(t, os.IsNotExist(err), tt.name)
+	}
+}
+
+func TestCredentialsSentToArchiveOnly(t *testing.T) {
+	var mirrorAuth, archiveAuth string
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mirrorAuth = r.Header.Get("Authorization")
+		w.Write([]byte("audio"))
+	}))
+	defer mirror.Close()
+	archive := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archiveAuth = r.Header.Get("Authorization")
+		http.Redirect(w, r, mirror.URL+"/file.mp3", http.StatusFound)
+	}))
+	defer archive.Close()
+	u, _ := url.Parse(archive.URL)
+	ia := ia_client.New(5, false, false)
+	ia.SetBaseURL(archive.URL)
+	ia.SetCredentials("key", "secret", "")
+
+	// the download is redirected to another host
+	localDir := t.TempDir()
+	progress := func(fileId int, fileName string, size int64, pos int64, percent int) {}
+	assert.NoError(t, ia.DownloadFile(localDir, "file.mp3", u.Host, "/items", "file.mp3", 0, 5, progress))
+	assert.Equal(t, "LOW key:secret", archiveAuth)
+	assert.Equal(t, "", mirrorAuth)
+}
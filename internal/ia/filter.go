@@ -0,0 +1,63 @@
+package ia_client
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Advanced search conditions. Empty fields are ignored
+type SearchFilter struct {
+	Author     string
+	Title      string
+	Collection string // list the collection members (audio items and sub-collections)
+	Subject    string
+	Language   string
+	YearFrom   int
+	YearTo     int
+	RawQuery   string // free-form IA (Lucene) query, e.g. subject:(detective) AND NOT title:(test)
+}
+
+// Build IA advanced search query:
+// creator:(x) AND title:(y) AND subject:(z) AND language:(eng) AND year:[1945 TO 1955] AND (raw query) AND mediatype:(audio)
+func (f SearchFilter) Query(mediaType string) string {
+	conditions := []string{}
+	if f.Collection != "" {
+		conditions = append(conditions, fmt.Sprintf("collection:(%s)", f.Collection))
+	}
+	if f.Author != "" {
+		conditions = append(conditions, fmt.Sprintf("creator:(%s)", f.Author))
+	}
+	if f.Title != "" {
+		conditions = append(conditions, fmt.Sprintf("title:(%s)", f.Title))
+	}
+	if f.Subject != "" {
+		conditions = append(conditions, fmt.Sprintf("subject:(%s)", f.Subject))
+	}
+	if f.Language != "" {
+		conditions = append(conditions, fmt.Sprintf("language:(%s)", f.Language))
+	}
+	if f.YearFrom > 0 || f.YearTo > 0 {
+		from, to := "*", "*"
+		if f.YearFrom > 0 {
+			from = fmt.Sprint(f.YearFrom)
+		}
+		if f.YearTo > 0 {
+			to = fmt.Sprint(f.YearTo)
+		}
+		conditions = append(conditions, fmt.Sprintf("year:[%s TO %s]", from, to))
+	}
+	if strings.TrimSpace(f.RawQuery) != "" {
+		conditions = append(conditions, "("+strings.TrimSpace(f.RawQuery)+")")
+	}
+	if mediaType != "" {
+		conditions = append(conditions, fmt.Sprintf("mediatype:(%s)", mediaType))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func (f SearchFilter) mediaType() string {
+	if f.Collection != "" {
+		return "audio OR collection"
+	}
+	return "audio"
+}
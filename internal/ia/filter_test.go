@@ -0,0 +1,60 @@
+package ia_client_test
+
+import (
+	"testing"
+
+	"abb_ia/internal/ia"
+)
+
+func TestSearchFilterQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    ia_client.SearchFilter
+		mediaType string
+		want      string
+	}{
+		{"empty filter", ia_client.SearchFilter{}, "audio", "mediatype:(audio)"},
+		{"author and title", ia_client.SearchFilter{Author: "Old Time Radio Researchers", Title: "Single Episodes"}, "audio",
+			"creator:(Old Time Radio Researchers) AND title:(Single Episodes) AND mediatype:(audio)"},
+		{"collection", ia_client.SearchFilter{Collection: "oldtimeradio"}, "audio OR collection",
+			"collection:(oldtimeradio) AND mediatype:(audio OR collection)"},
+		{"subject and language", ia_client.SearchFilter{Subject: "detective", Language: "eng"}, "audio",
+			"subject:(detective) AND language:(eng) AND mediatype:(audio)"},
+		{"year range", ia_client.SearchFilter{YearFrom: 1945, YearTo: 1955}, "audio", "year:[1945 TO 1955] AND mediatype:(audio)"},
+		{"year from only", ia_client.SearchFilter{YearFrom: 1945}, "audio", "year:[1945 TO *] AND mediatype:(audio)"},
+		{"year to only", ia_client.SearchFilter{YearTo: 1955}, "audio", "year:[* TO 1955] AND mediatype:(audio)"},
+		{"raw query", ia_client.SearchFilter{Title: "Dragnet", RawQuery: " NOT subject:(music) "}, "audio",
+			"title:(Dragnet) AND (NOT subject:(music)) AND mediatype:(audio)"},
+		{"no media type", ia_client.SearchFilter{Author: "Poe"}, "", "creator:(Poe)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.Query(tt.mediaType)
+			if got != tt.want {
+				t.Errorf("Query() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestItemIdFromURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://archive.org/details/OTRR_Dragnet_Singles", "OTRR_Dragnet_Singles"},
+		{"https://archive.org/details/OTRR_Dragnet_Singles/", "OTRR_Dragnet_Singles"},
+		{" https://archive.org/details/OTRR_Dragnet_Singles/Dragnet_49-06-03.mp3?q=1 ", "OTRR_Dragnet_Singles"},
+		{"http://127.0.0.1:8080/details/fake_item", "fake_item"},
+		{"https://example.com/mirror/ia/details/fake_item", "fake_item"},
+		{"https://archive.org/search?query=dragnet", ""},
+		{"Dragnet /details/ episodes", ""},
+		{"OTRR_Dragnet_Singles", ""},
+	}
+	for _, tt := range tests {
+		if got := ia_client.ItemIdFromURL(tt.url); got != tt.want {
+			t.Errorf("ItemIdFromURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
@@ -18,39 +18,49 @@ type SearchResponse struct {
 		} `json:"params"`
 	} `json:"responseHeader"`
 	Response struct {
-		NumFound int `json:"numFound"`
-		Start    int `json:"start"`
-		Docs     []struct {
-			AvgRating          float64     `json:"avg_rating"`
-			Btih               strArray    `json:"btih"`
-			Collection         []string    `json:"collection"`
-			Creator            strArray    `json:"creator,omitempty"`
-			Date               time.Time   `json:"date,omitempty"`
-			Description        string      `json:"description,omitempty"`
-			Downloads          int         `json:"downloads"`
-			Format             strArray    `json:"format"`
-			Identifier         string      `json:"identifier"`
-			Indexflag          []string    `json:"indexflag"`
-			ItemSize           int         `json:"item_size"`
-			Mediatype          string      `json:"mediatype"`
-			Month              int         `json:"month"`
-			OaiUpdatedate      []time.Time `json:"oai_updatedate"`
-			Publicdate         time.Time   `json:"publicdate"`
-			Reviewdate         time.Time   `json:"reviewdate"`
-			Subject            strArray    `json:"subject,omitempty"`
-			Title              string      `json:"title"`
-			Week               int         `json:"week"`
-			Year               numArray    `json:"year,omitempty"`
-			BackupLocation     string      `json:"backup_location,omitempty"`
-			ExternalIdentifier strArray    `json:"external-identifier,omitempty"`
-			Genre              strArray    `json:"genre,omitempty"`
-			Language           strArray    `json:"language,omitempty"`
-			Licenseurl         string      `json:"licenseurl,omitempty"`
-			StrippedTags       strArray    `json:"stripped_tags,omitempty"`
-		} `json:"docs"`
+		NumFound int         `json:"numFound"`
+		Start    int         `json:"start"`
+		Docs     []SearchDoc `json:"docs"`
 	} `json:"response"`
 }
 
+type SearchDoc struct {
+	AvgRating          float64     `json:"avg_rating"`
+	Btih               strArray    `json:"btih"`
+	Collection         []string    `json:"collection"`
+	Creator            strArray    `json:"creator,omitempty"`
+	Date               time.Time   `json:"date,omitempty"`
+	Description        string      `json:"description,omitempty"`
+	Downloads          int         `json:"downloads"`
+	Format             strArray    `json:"format"`
+	Identifier         string      `json:"identifier"`
+	Indexflag          []string    `json:"indexflag"`
+	ItemSize           int         `json:"item_size"`
+	Mediatype          string      `json:"mediatype"`
+	Month              int         `json:"month"`
+	OaiUpdatedate      []time.Time `json:"oai_updatedate"`
+	Publicdate         time.Time   `json:"publicdate"`
+	Reviewdate         time.Time   `json:"reviewdate"`
+	Subject            strArray    `json:"subject,omitempty"`
+	Title              string      `json:"title"`
+	Week               int         `json:"week"`
+	Year               numArray    `json:"year,omitempty"`
+	BackupLocation     string      `json:"backup_location,omitempty"`
+	ExternalIdentifier strArray    `json:"external-identifier,omitempty"`
+	Genre              strArray    `json:"genre,omitempty"`
+	Language           strArray    `json:"language,omitempty"`
+	Licenseurl         string      `json:"licenseurl,omitempty"`
+	StrippedTags       strArray    `json:"stripped_tags,omitempty"`
+}
+
+// Response of the scraping API (https://archive.org/services/search/v1/scrape)
+type ScrapeResponse struct {
+	Items  []SearchDoc `json:"items"`
+	Count  int         `json:"count"`
+	Cursor string      `json:"cursor"`
+	Total  int         `json:"total"`
+}
+
 type ItemDetails struct {
 	Server   string `json:"server"`
 	Dir      string `json:"dir"`
@@ -59,6 +69,8 @@ type ItemDetails struct {
 		Creator                []string `json:"creator"`
 		Artist                 []string `json:"artist"`
 		Date                   []string `json:"date"`
+		Year                   []string `json:"year"`
+		Language               []string `json:"language"`
 		Description            []string `json:"description"`
 		GUID                   []string `json:"guid"`
 		Mediatype              []string `json:"mediatype"`
@@ -124,3 +136,13 @@ type ItemDetails struct {
 		CollectionSize       any `json:"collection_size"`
 	} `json:"item"`
 }
+
+// Restricted (lending) items can be downloaded by logged in users only
+func (d *ItemDetails) IsRestricted() bool {
+	for _, v := range d.Metadata.AccessRestrictedItem {
+		if v == "true" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package ia_client
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"abb_ia/internal/utils"
+)
+
+/**
+ * Download rate limit shared by all the concurrent downloads of a client (token bucket).
+ * The limit can be lifted for a daily time window ("00:00-07:00" - unlimited after midnight till 7 a.m.)
+ **/
+type RateLimiter struct {
+	mu             sync.Mutex
+	bytesPerSecond int64
+	unlimitedFrom  int // minutes since midnight
+	unlimitedTo    int
+	hasSchedule    bool
+	tokens         float64
+	last           time.Time
+	now            func() time.Time
+}
+
+func NewRateLimiter(kbPerSecond int, unlimitedHours string) (*RateLimiter, error) {
+	l := &RateLimiter{}
+	l.bytesPerSecond = int64(kbPerSecond) * 1024
+	l.now = time.Now
+	if strings.TrimSpace(unlimitedHours) != "" {
+		from, to, err := utils.ParseTimeWindow(unlimitedHours)
+		if err != nil {
+			return nil, err
+		}
+		l.unlimitedFrom = from
+		l.unlimitedTo = to
+		l.hasSchedule = true
+	}
+	return l, nil
+}
+
+// The limit in effect now, bytes per second. 0 means unlimited
+func (l *RateLimiter) Limit() int64 {
+	if l == nil || l.bytesPerSecond <= 0 {
+		return 0
+	}
+	if l.hasSchedule {
+		now := l.now()
+		m := now.Hour()*60 + now.Minute()
+		if utils.InTimeWindow(m, l.unlimitedFrom, l.unlimitedTo) {
+			return 0
+		}
+	}
+	return l.bytesPerSecond
+}
+
+// Take n bytes from the bucket. Blocks until the bytes are allowed to be transferred
+func (l *RateLimiter) Wait(n int) {
+	limit := l.Limit()
+	if limit <= 0 {
+		return
+	}
+	l.mu.Lock()
+	now := l.now()
+	if l.last.IsZero() {
+		l.tokens = float64(limit)
+	} else {
+		l.tokens += now.Sub(l.last).Seconds() * float64(limit)
+	}
+	// allow a burst of 1 second max
+	if l.tokens > float64(limit) {
+		l.tokens = float64(limit)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	delay := time.Duration(0)
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / float64(limit) * float64(time.Second))
+	}
+	l.mu.Unlock()
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// Reader throttled by the limiter
+type limitedReader struct {
+	Reader  io.Reader
+	limiter *RateLimiter
+}
+
+func (r *limitedReader) Read(p []byte) (int, error) {
+	// read small chunks so the throttled downloads progress smoothly
+	if limit := r.limiter.Limit(); limit > 0 {
+		chunk := int(limit / 10)
+		if chunk < 1024 {
+			chunk = 1024
+		}
+		if len(p) > chunk {
+			p = p[:chunk]
+		}
+	}
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.limiter.Wait(n)
+	}
+	return n, err
+}
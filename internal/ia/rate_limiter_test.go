@@ -0,0 +1,50 @@
+package ia_client
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l, err := NewRateLimiter(64, "")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(64*1024), l.Limit())
+
+	// 3 concurrent readers share 64KB/s. 192KB take ~2 seconds after the initial 1 second burst
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &limitedReader{Reader: bytes.NewReader(make([]byte, 64*1024)), limiter: l}
+			n, err := io.Copy(io.Discard, r)
+			assert.NoError(t, err)
+			assert.Equal(t, int64(64*1024), n)
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	assert.True(t, elapsed > 1500*time.Millisecond, "elapsed %s", elapsed)
+	assert.True(t, elapsed < 4*time.Second, "elapsed %s", elapsed)
+}
+
+func TestRateLimiterSchedule(t *testing.T) {
+	l, err := NewRateLimiter(64, "23:00-07:00")
+	assert.NoError(t, err)
+	l.now = func() time.Time { return time.Date(2024, 1, 1, 2, 30, 0, 0, time.Local) }
+	assert.Equal(t, int64(0), l.Limit())
+	l.now = func() time.Time { return time.Date(2024, 1, 1, 12, 0, 0, 0, time.Local) }
+	assert.Equal(t, int64(64*1024), l.Limit())
+
+	_, err = NewRateLimiter(64, "after midnight")
+	assert.Error(t, err)
+
+	var unlimited *RateLimiter
+	assert.Equal(t, int64(0), unlimited.Limit())
+}
@@ -3,8 +3,13 @@ package ia_client
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	"jaytaylor.com/html2text"
 )
@@ -109,14 +114,85 @@ type ProgressReader struct {
 
 func (pr *ProgressReader) Read(p []byte) (int, error) {
 	n, err := pr.Reader.Read(p)
-	if err == nil {
+	// the last bytes may come with io.EOF
+	if n > 0 {
 		pr.Pos += int64(n)
-		pr.Percent = int(float64(pr.Pos) / float64(pr.Size) * 100)
+		if pr.Size > 0 {
+			pr.Percent = int(float64(pr.Pos) / float64(pr.Size) * 100)
+		}
 		pr.Callback(pr.FileId, pr.FileName, pr.Size, pr.Pos, pr.Percent)
 	}
 	return n, err
 }
 
+// Parse the first byte position of "Content-Range: bytes 1000-1999/2000" header. Returns -1 if the header can't be parsed
+func contentRangeStart(contentRange string) int64 {
+	var start, end, size int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/*", &start, &end); err != nil {
+			return -1
+		}
+	}
+	return start
+}
+
+// Parse the complete length of "Content-Range: bytes 1000-1999/2000" header. Returns -1 if it's unknown
+func contentRangeSize(contentRange string) int64 {
+	var start, end, size int64
+	if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &start, &end, &size); err != nil {
+		return -1
+	}
+	return size
+}
+
+// The item identifier of a details URL: https://archive.org/details/<identifier>[/<file>].
+// Any host is accepted, so archive.org links work with a custom base URL too. Returns "" if it's not a details URL
+func ItemIdFromURL(detailsURL string) string {
+	u, err := url.Parse(strings.TrimSpace(detailsURL))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	_, path, found := strings.Cut(u.Path, "/details/")
+	if !found {
+		return ""
+	}
+	itemId, _, _ := strings.Cut(path, "/")
+	return itemId
+}
+
+// Exponential backoff delay for a failed download attempt: 2s, 4s, 8s... up to 1 min
+func backoffDelay(attempt int) time.Duration {
+	delay := 2 * time.Second
+	for i := 1; i < attempt && delay < time.Minute; i++ {
+		delay *= 2
+	}
+	if delay > time.Minute {
+		delay = time.Minute
+	}
+	return delay
+}
+
+// Parse "Retry-After" header value (delay in seconds or HTTP date). Returns 0 if the header is empty or invalid
+func retryAfterDelay(retryAfter string) time.Duration {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return 0
+	}
+	var delay time.Duration
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		delay = time.Duration(seconds) * time.Second
+	} else if t, err := http.ParseTime(retryAfter); err == nil {
+		delay = time.Until(t)
+	}
+	// don't let the server make us wait forever
+	if delay < 0 {
+		delay = 0
+	} else if delay > 5*time.Minute {
+		delay = 5 * time.Minute
+	}
+	return delay
+}
+
 func (client *IAClient) Html2Text(html string) string {
 	html = RemoveHtmlTag(html, "<blockquote>")
 	html = RemoveHtmlTag(html, "<b>")
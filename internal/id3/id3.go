@@ -0,0 +1,224 @@
+package id3
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	headerSize = 10
+	// a CTOC frame refers to 255 child elements max
+	maxTocEntries = 255
+	// ID3v2 header flag: a footer is present
+	flagFooter = 0x10
+	// text encoding byte of the frames
+	encodingUTF8 = 0x03
+	// APIC picture type
+	pictureFrontCover = 0x03
+	// CHAP byte offsets are not used
+	noOffset = 0xFFFFFFFF
+)
+
+/**
+ * ID3v2.4 tag of an mp3 file (see: https://id3.org/id3v2.4.0-frames and https://id3.org/id3v2-chapters-1.0).
+ * The tag written replaces the ID3v2 tag of the file, the audio frames are copied as they are
+ **/
+type Mp3 struct {
+	fileName string
+	frames   []frame
+}
+
+type frame struct {
+	id   string
+	data []byte
+}
+
+type Chapter struct {
+	Start float64 // seconds
+	End   float64
+	Title string
+}
+
+func NewMp3(fileName string) (*Mp3, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return &Mp3{fileName: fileName}, nil
+}
+
+// Set a text information frame (TIT2, TPE1, TALB, TCON etc.)
+func (m *Mp3) SetTag(id string, value string) {
+	m.setFrame(id, append([]byte{encodingUTF8}, value...))
+}
+
+// COMM frame
+func (m *Mp3) SetComment(text string) {
+	data := []byte{encodingUTF8}
+	data = append(data, "eng"...)
+	data = append(data, 0) // empty content description
+	data = append(data, text...)
+	m.setFrame("COMM", data)
+}
+
+// APIC frame, front cover
+func (m *Mp3) SetImage(imageData []byte, mimeType string) {
+	data := []byte{encodingUTF8}
+	data = append(data, mimeType...)
+	data = append(data, 0, pictureFrontCover, 0) // empty description
+	data = append(data, imageData...)
+	m.setFrame("APIC", data)
+}
+
+// CHAP frames of the chapters and CTOC frames of the table of contents
+func (m *Mp3) SetChapters(chapters []Chapter) {
+	m.removeFrames("CHAP")
+	m.removeFrames("CTOC")
+	ids := []string{}
+	for i, ch := range chapters {
+		id := fmt.Sprintf("ch%d", i+1)
+		ids = append(ids, id)
+		data := append([]byte(id), 0)
+		data = appendUint32(data, uint32(ch.Start*1000+0.5))
+		data = appendUint32(data, uint32(ch.End*1000+0.5))
+		data = appendUint32(data, noOffset)
+		data = appendUint32(data, noOffset)
+		data = append(data, encodeFrame(frame{"TIT2", append([]byte{encodingUTF8}, ch.Title...)})...)
+		m.frames = append(m.frames, frame{"CHAP", data})
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if len(ids) <= maxTocEntries {
+		m.frames = append(m.frames, tocFrame("toc", true, ids))
+		return
+	}
+	// too many chapters for a single table of contents. Split it into the nested ones
+	tocs := []string{}
+	for i := 0; i < len(ids); i += maxTocEntries {
+		end := i + maxTocEntries
+		if end > len(ids) {
+			end = len(ids)
+		}
+		id := fmt.Sprintf("toc%d", len(tocs)+1)
+		tocs = append(tocs, id)
+		m.frames = append(m.frames, tocFrame(id, false, ids[i:end]))
+	}
+	m.frames = append(m.frames, tocFrame("toc", true, tocs))
+}
+
+func tocFrame(id string, topLevel bool, children []string) frame {
+	var flags byte = 0x01 // ordered
+	if topLevel {
+		flags |= 0x02
+	}
+	data := append([]byte(id), 0, flags, byte(len(children)))
+	for _, child := range children {
+		data = append(data, child...)
+		data = append(data, 0)
+	}
+	return frame{"CTOC", data}
+}
+
+func (m *Mp3) setFrame(id string, data []byte) {
+	m.removeFrames(id)
+	m.frames = append(m.frames, frame{id, data})
+}
+
+func (m *Mp3) removeFrames(id string) {
+	frames := []frame{}
+	for _, f := range m.frames {
+		if f.id != id {
+			frames = append(frames, f)
+		}
+	}
+	m.frames = frames
+}
+
+func (m *Mp3) Save() error {
+	inputFileName := m.fileName
+	outputFileName := inputFileName + ".tmp"
+
+	inputFile, err := os.Open(inputFileName)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %v", inputFileName, err)
+	}
+	defer inputFile.Close()
+	outputFile, err := os.OpenFile(outputFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("can't create temporary file %s: %v", outputFileName, err)
+	}
+	defer outputFile.Close()
+
+	if err := m.write(bufio.NewReader(inputFile), outputFile); err != nil {
+		outputFile.Close()
+		os.Remove(outputFileName)
+		return fmt.Errorf("can't save %s: %v", inputFileName, err)
+	}
+
+	inputFile.Close()
+	outputFile.Close()
+	// rename temporary file to final one
+	os.Remove(inputFileName)
+	return os.Rename(outputFileName, inputFileName)
+}
+
+func (m *Mp3) write(r *bufio.Reader, out io.Writer) error {
+	// skip the existing tag
+	header, err := r.Peek(headerSize)
+	if err == nil && string(header[0:3]) == "ID3" {
+		size := int(syncsafe(header[6:10])) + headerSize
+		if header[5]&flagFooter != 0 {
+			size += headerSize
+		}
+		if _, err := r.Discard(size); err != nil {
+			return err
+		}
+	}
+
+	w := bufio.NewWriter(out)
+	if _, err := w.Write(m.tag()); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func (m *Mp3) tag() []byte {
+	body := &bytes.Buffer{}
+	for _, f := range m.frames {
+		body.Write(encodeFrame(f))
+	}
+	tag := []byte{'I', 'D', '3', 4, 0, 0}
+	tag = append(tag, toSyncsafe(uint32(body.Len()))...)
+	return append(tag, body.Bytes()...)
+}
+
+func encodeFrame(f frame) []byte {
+	data := []byte(f.id)
+	data = append(data, toSyncsafe(uint32(len(f.data)))...)
+	data = append(data, 0, 0) // flags
+	return append(data, f.data...)
+}
+
+func appendUint32(data []byte, n uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, n)
+	return append(data, b...)
+}
+
+// ID3v2.4 sizes are 28 bit integers stored in 4 bytes, 7 bits each
+func toSyncsafe(n uint32) []byte {
+	return []byte{byte(n >> 21 & 0x7f), byte(n >> 14 & 0x7f), byte(n >> 7 & 0x7f), byte(n & 0x7f)}
+}
+
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7f)<<21 | uint32(b[1]&0x7f)<<14 | uint32(b[2]&0x7f)<<7 | uint32(b[3]&0x7f)
+}
@@ -0,0 +1,107 @@
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// frames of the tag at the beginning of the file
+func readFrames(t *testing.T, data []byte) (map[string][][]byte, []byte) {
+	if string(data[0:3]) != "ID3" || data[3] != 4 {
+		t.Fatalf("no ID3v2.4 tag")
+	}
+	size := int(syncsafe(data[6:10]))
+	body := data[headerSize : headerSize+size]
+	frames := map[string][][]byte{}
+	for len(body) > 0 {
+		id := string(body[0:4])
+		n := int(syncsafe(body[4:8]))
+		frames[id] = append(frames[id], body[headerSize:headerSize+n])
+		body = body[headerSize+n:]
+	}
+	return frames, data[headerSize+size:]
+}
+
+func TestMp3Tags(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.mp3")
+	audio := []byte{0xff, 0xfb, 0x90, 0x64, 1, 2, 3}
+	// an old tag written by ffmpeg
+	old := append([]byte{'I', 'D', '3', 4, 0, 0}, toSyncsafe(15)...)
+	old = append(old, encodeFrame(frame{"TSSE", []byte{encodingUTF8, 'L', 'a', 'v', 'f'}})...)
+	if err := os.WriteFile(fileName, append(old, audio...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewMp3(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetTag("TIT2", "Old title")
+	m.SetTag("TIT2", "Title")
+	m.SetTag("TPE1", "Author")
+	m.SetComment("Comment")
+	m.SetImage([]byte{1, 2, 3, 4}, "image/jpeg")
+	m.SetChapters([]Chapter{{Start: 0, End: 61.5, Title: "Intro"}, {Start: 61.5, End: 3600, Title: "Chapter 1"}})
+	if err := m.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames, rest := readFrames(t, data)
+	if !bytes.Equal(rest, audio) {
+		t.Errorf("audio frames are not copied: %v", rest)
+	}
+	if _, ok := frames["TSSE"]; ok {
+		t.Errorf("the old tag is not replaced")
+	}
+	if len(frames["TIT2"]) != 1 || string(frames["TIT2"][0]) != "\x03Title" {
+		t.Errorf("TIT2 = %q", frames["TIT2"])
+	}
+	if len(frames["COMM"]) != 1 || string(frames["COMM"][0]) != "\x03eng\x00Comment" {
+		t.Errorf("COMM = %q", frames["COMM"])
+	}
+	if len(frames["APIC"]) != 1 || string(frames["APIC"][0]) != "\x03image/jpeg\x00\x03\x00\x01\x02\x03\x04" {
+		t.Errorf("APIC = %q", frames["APIC"])
+	}
+
+	chapters := frames["CHAP"]
+	if len(chapters) != 2 {
+		t.Fatalf("CHAP frames = %d, want 2", len(chapters))
+	}
+	ch := chapters[1]
+	if !bytes.HasPrefix(ch, []byte("ch2\x00")) {
+		t.Errorf("wrong chapter id: %q", ch)
+	}
+	if start, end := binary.BigEndian.Uint32(ch[4:8]), binary.BigEndian.Uint32(ch[8:12]); start != 61500 || end != 3600000 {
+		t.Errorf("chapter time = %d - %d, want 61500 - 3600000", start, end)
+	}
+	sub, _ := readFrames(t, append([]byte{'I', 'D', '3', 4, 0, 0}, append(toSyncsafe(uint32(len(ch)-20)), ch[20:]...)...))
+	if string(sub["TIT2"][0]) != "\x03Chapter 1" {
+		t.Errorf("chapter title = %q", sub["TIT2"][0])
+	}
+	if len(frames["CTOC"]) != 1 || string(frames["CTOC"][0]) != "toc\x00\x03\x02ch1\x00ch2\x00" {
+		t.Errorf("CTOC = %q", frames["CTOC"])
+	}
+}
+
+func TestNestedToc(t *testing.T) {
+	m := &Mp3{}
+	chapters := make([]Chapter, 300)
+	m.SetChapters(chapters)
+	tocs := []string{}
+	for _, f := range m.frames {
+		if f.id == "CTOC" {
+			tocs = append(tocs, string(f.data[:bytes.IndexByte(f.data, 0)]))
+		}
+	}
+	// 255 + 45 chapters and the top level toc
+	if len(tocs) != 3 || tocs[2] != "toc" {
+		t.Errorf("CTOC frames = %v", tocs)
+	}
+}
@@ -13,6 +13,7 @@ const (
 	EncodingPage       = "EncodingPage"
 	ChaptersPage       = "ChaptersPage"
 	BuildPage          = "BuildPage"
+	HistoryPage        = "HistoryPage"
 	BootController     = "BootController"
 	SearchController   = "SearchController"
 	ConfigController   = "ConfigController"
@@ -23,4 +24,6 @@ const (
 	CopyController     = "CopyController"
 	CleanupController  = "CleanupController"
 	UploadController   = "UploadController"
+	WatchController    = "WatchController"
+	HistoryController  = "HistoryController"
 )
@@ -0,0 +1,246 @@
+package ogg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Picture types of METADATA_BLOCK_PICTURE (FLAC picture block)
+const (
+	PictureFrontCover uint32 = 3
+)
+
+/**
+ * Ogg Opus file tags. The OpusTags header packet (Vorbis comments) is replaced on Save,
+ * the audio pages are copied as they are
+ **/
+type Opus struct {
+	fileName string
+	vendor   string
+	comments []string
+}
+
+func NewOpus(fileName string) (*Opus, error) {
+	o := &Opus{fileName: fileName}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	head, err := readPage(r)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %v", fileName, err)
+	}
+	if !bytes.HasPrefix(head.data, []byte("OpusHead")) {
+		return nil, fmt.Errorf("%s is not an Ogg Opus file", fileName)
+	}
+	tags, _, err := readPacket(r)
+	if err != nil {
+		return nil, fmt.Errorf("can't read %s: %v", fileName, err)
+	}
+	o.vendor, o.comments, err = parseOpusTags(tags)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", fileName, err)
+	}
+	return o, nil
+}
+
+// Set a comment (TITLE, ARTIST etc.). Replaces the comments of the same name
+func (o *Opus) SetTag(name string, value string) {
+	o.RemoveTag(name)
+	o.comments = append(o.comments, strings.ToUpper(name)+"="+value)
+}
+
+func (o *Opus) RemoveTag(name string) {
+	comments := []string{}
+	for _, c := range o.comments {
+		if n, _, _ := strings.Cut(c, "="); !strings.EqualFold(n, name) {
+			comments = append(comments, c)
+		}
+	}
+	o.comments = comments
+}
+
+func (o *Opus) GetTag(name string) string {
+	for _, c := range o.comments {
+		if n, v, _ := strings.Cut(c, "="); strings.EqualFold(n, name) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Chapters as CHAPTERxxx/CHAPTERxxxNAME comments (see: https://wiki.xiph.org/Chapter_Extension)
+func (o *Opus) SetChapters(starts []float64, names []string) {
+	comments := []string{}
+	for _, c := range o.comments {
+		if !strings.HasPrefix(strings.ToUpper(c), "CHAPTER") {
+			comments = append(comments, c)
+		}
+	}
+	o.comments = comments
+	for i := range starts {
+		n := fmt.Sprintf("CHAPTER%03d", i+1)
+		o.comments = append(o.comments, n+"="+chapterTime(starts[i]), n+"NAME="+names[i])
+	}
+}
+
+// Cover image as METADATA_BLOCK_PICTURE comment
+func (o *Opus) SetImage(imageData []byte, mimeType string) {
+	b := &bytes.Buffer{}
+	binary.Write(b, binary.BigEndian, PictureFrontCover)
+	binary.Write(b, binary.BigEndian, uint32(len(mimeType)))
+	b.WriteString(mimeType)
+	binary.Write(b, binary.BigEndian, uint32(0)) // description
+	// width, height, color depth and number of colors are unknown
+	binary.Write(b, binary.BigEndian, [4]uint32{})
+	binary.Write(b, binary.BigEndian, uint32(len(imageData)))
+	b.Write(imageData)
+	o.SetTag("METADATA_BLOCK_PICTURE", base64.StdEncoding.EncodeToString(b.Bytes()))
+}
+
+func (o *Opus) Save() error {
+	inputFileName := o.fileName
+	outputFileName := inputFileName + ".tmp"
+
+	inputFile, err := os.Open(inputFileName)
+	if err != nil {
+		return fmt.Errorf("can't open %s: %v", inputFileName, err)
+	}
+	defer inputFile.Close()
+	outputFile, err := os.OpenFile(outputFileName, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("can't create temporary file %s: %v", outputFileName, err)
+	}
+	defer outputFile.Close()
+
+	if err := o.write(bufio.NewReader(inputFile), outputFile); err != nil {
+		outputFile.Close()
+		os.Remove(outputFileName)
+		return fmt.Errorf("can't save %s: %v", inputFileName, err)
+	}
+
+	inputFile.Close()
+	outputFile.Close()
+	// rename temporary file to final one
+	os.Remove(inputFileName)
+	return os.Rename(outputFileName, inputFileName)
+}
+
+func (o *Opus) write(r *bufio.Reader, out io.Writer) error {
+	w := bufio.NewWriter(out)
+	head, err := readPage(r)
+	if err != nil {
+		return err
+	}
+	if err := head.write(w); err != nil {
+		return err
+	}
+	_, pagesRead, err := readPacket(r)
+	if err != nil {
+		return err
+	}
+
+	// new tags header, then the audio pages renumbered
+	pages := packetPages(o.opusTags(), head.serial, head.sequence+1)
+	for _, p := range pages {
+		if err := p.write(w); err != nil {
+			return err
+		}
+	}
+	shift := uint32(len(pages) - pagesRead)
+	for {
+		p, err := readPage(r)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		if p.serial == head.serial {
+			p.sequence += shift
+		}
+		if err := p.write(w); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func (o *Opus) opusTags() []byte {
+	b := &bytes.Buffer{}
+	b.WriteString("OpusTags")
+	binary.Write(b, binary.LittleEndian, uint32(len(o.vendor)))
+	b.WriteString(o.vendor)
+	binary.Write(b, binary.LittleEndian, uint32(len(o.comments)))
+	for _, c := range o.comments {
+		binary.Write(b, binary.LittleEndian, uint32(len(c)))
+		b.WriteString(c)
+	}
+	return b.Bytes()
+}
+
+// read a packet starting on a new page. Returns the packet and the number of pages read
+func readPacket(r *bufio.Reader) ([]byte, int, error) {
+	packet := []byte{}
+	pages := 0
+	for {
+		p, err := readPage(r)
+		if err != nil {
+			return nil, pages, err
+		}
+		pages++
+		packet = append(packet, p.data...)
+		if p.complete() {
+			return packet, pages, nil
+		}
+	}
+}
+
+func parseOpusTags(packet []byte) (string, []string, error) {
+	if !bytes.HasPrefix(packet, []byte("OpusTags")) {
+		return "", nil, fmt.Errorf("no OpusTags header")
+	}
+	r := bytes.NewReader(packet[8:])
+	readString := func() (string, error) {
+		var size uint32
+		if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+			return "", err
+		}
+		if int64(size) > int64(r.Len()) {
+			return "", fmt.Errorf("wrong OpusTags header")
+		}
+		s := make([]byte, size)
+		_, err := io.ReadFull(r, s)
+		return string(s), err
+	}
+	vendor, err := readString()
+	if err != nil {
+		return "", nil, err
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return "", nil, err
+	}
+	comments := []string{}
+	for i := uint32(0); i < count; i++ {
+		c, err := readString()
+		if err != nil {
+			return "", nil, err
+		}
+		comments = append(comments, c)
+	}
+	return vendor, comments, nil
+}
+
+// HH:MM:SS.sss
+func chapterTime(seconds float64) string {
+	ms := int64(seconds*1000 + 0.5)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
@@ -0,0 +1,110 @@
+package ogg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// OpusHead, OpusTags and an audio page
+func writeTestOpus(t *testing.T, fileName string) {
+	b := &bytes.Buffer{}
+	head := &page{headerType: 0x02, serial: 7, sequence: 0, segments: []byte{19}, data: append([]byte("OpusHead"), make([]byte, 11)...)}
+	head.write(b)
+	tags := &Opus{vendor: "test", comments: []string{"TITLE=Old title", "CHAPTER001=00:00:00.000"}}
+	for _, p := range packetPages(tags.opusTags(), 7, 1) {
+		p.write(b)
+	}
+	audio := &page{headerType: 0x04, granule: 48000, serial: 7, sequence: 2, segments: []byte{3}, data: []byte{1, 2, 3}}
+	audio.write(b)
+	if err := os.WriteFile(fileName, b.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpusTags(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "test.opus")
+	writeTestOpus(t, fileName)
+
+	o, err := NewOpus(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.GetTag("title") != "Old title" {
+		t.Errorf("GetTag() = %q, want %q", o.GetTag("title"), "Old title")
+	}
+	o.SetTag("TITLE", "New title")
+	o.SetChapters([]float64{0, 3725.5}, []string{"Intro", "Part 2"})
+	// a cover bigger than a page
+	image := bytes.Repeat([]byte{0xff}, 100000)
+	o.SetImage(image, "image/jpeg")
+	if err := o.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err = NewOpus(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"TITLE":          "New title",
+		"CHAPTER001":     "00:00:00.000",
+		"CHAPTER001NAME": "Intro",
+		"CHAPTER002":     "01:02:05.500",
+		"CHAPTER002NAME": "Part 2",
+	}
+	for name, value := range want {
+		if o.GetTag(name) != value {
+			t.Errorf("GetTag(%s) = %q, want %q", name, o.GetTag(name), value)
+		}
+	}
+	if len(o.comments) != 6 {
+		t.Errorf("comments = %d, want 6", len(o.comments))
+	}
+	picture, err := base64.StdEncoding.DecodeString(o.GetTag("METADATA_BLOCK_PICTURE"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasSuffix(picture, image) || !bytes.Contains(picture, []byte("image/jpeg")) {
+		t.Errorf("wrong METADATA_BLOCK_PICTURE")
+	}
+
+	// the pages are renumbered and have valid checksums
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last *page
+	for i := uint32(0); len(data) > 0; i++ {
+		p, err := readPage(bufio.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.sequence != i {
+			t.Errorf("page %d sequence = %d", i, p.sequence)
+		}
+		size := pageHeaderSize + len(p.segments) + len(p.data)
+		raw := append([]byte{}, data[:size]...)
+		checksum := binary.LittleEndian.Uint32(raw[22:26])
+		copy(raw[22:26], []byte{0, 0, 0, 0})
+		if crc32(raw) != checksum {
+			t.Errorf("page %d: wrong checksum", i)
+		}
+		data = data[size:]
+		last = p
+	}
+	if last == nil || last.granule != 48000 || !bytes.Equal(last.data, []byte{1, 2, 3}) {
+		t.Errorf("audio page is not copied")
+	}
+}
+
+func TestCrc32(t *testing.T) {
+	// the checksum of "OggS" with the Ogg polynomial
+	if got := crc32([]byte("OggS")); got != 0x5fb0a94f {
+		t.Errorf("crc32() = %#x, want %#x", got, 0x5fb0a94f)
+	}
+}
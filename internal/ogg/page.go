@@ -0,0 +1,137 @@
+package ogg
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const (
+	pageHeaderSize = 27
+	maxSegments    = 255
+	// the page continues a packet started on the previous page
+	flagContinued = 0x01
+	// no packet is completed on the page
+	noGranule = 0xFFFFFFFFFFFFFFFF
+)
+
+// Ogg page (see: https://www.xiph.org/ogg/doc/framing.html)
+type page struct {
+	headerType byte
+	granule    uint64
+	serial     uint32
+	sequence   uint32
+	segments   []byte // lacing values
+	data       []byte
+}
+
+func readPage(r *bufio.Reader) (*page, error) {
+	header := make([]byte, pageHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, err
+	}
+	if string(header[0:4]) != "OggS" {
+		return nil, fmt.Errorf("not an ogg page")
+	}
+	p := &page{
+		headerType: header[5],
+		granule:    binary.LittleEndian.Uint64(header[6:14]),
+		serial:     binary.LittleEndian.Uint32(header[14:18]),
+		sequence:   binary.LittleEndian.Uint32(header[18:22]),
+	}
+	p.segments = make([]byte, header[26])
+	if _, err := io.ReadFull(r, p.segments); err != nil {
+		return nil, err
+	}
+	size := 0
+	for _, s := range p.segments {
+		size += int(s)
+	}
+	p.data = make([]byte, size)
+	if _, err := io.ReadFull(r, p.data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// true if the last packet of the page ends on the page
+func (p *page) complete() bool {
+	return len(p.segments) > 0 && p.segments[len(p.segments)-1] < 255
+}
+
+func (p *page) write(w io.Writer) error {
+	buf := make([]byte, pageHeaderSize, pageHeaderSize+len(p.segments)+len(p.data))
+	copy(buf, "OggS")
+	buf[5] = p.headerType
+	binary.LittleEndian.PutUint64(buf[6:14], p.granule)
+	binary.LittleEndian.PutUint32(buf[14:18], p.serial)
+	binary.LittleEndian.PutUint32(buf[18:22], p.sequence)
+	buf[26] = byte(len(p.segments))
+	buf = append(buf, p.segments...)
+	buf = append(buf, p.data...)
+	binary.LittleEndian.PutUint32(buf[22:26], crc32(buf))
+	_, err := w.Write(buf)
+	return err
+}
+
+// split a packet into pages. The packet has to start and end on its own pages (the header packets do)
+func packetPages(packet []byte, serial uint32, sequence uint32) []*page {
+	// lacing values: 255 for each full segment, the last one < 255 (0 if the packet size is a multiple of 255)
+	lacing := make([]byte, 0, len(packet)/255+1)
+	for i := 0; i < len(packet)/255; i++ {
+		lacing = append(lacing, 255)
+	}
+	lacing = append(lacing, byte(len(packet)%255))
+
+	pages := []*page{}
+	offset := 0
+	for len(lacing) > 0 {
+		n := len(lacing)
+		if n > maxSegments {
+			n = maxSegments
+		}
+		p := &page{serial: serial, sequence: sequence, granule: noGranule}
+		p.segments = lacing[:n]
+		size := 0
+		for _, s := range p.segments {
+			size += int(s)
+		}
+		p.data = packet[offset : offset+size]
+		if len(pages) > 0 {
+			p.headerType = flagContinued
+		}
+		pages = append(pages, p)
+		lacing = lacing[n:]
+		offset += size
+		sequence++
+	}
+	// the header packets have zero granule position
+	pages[len(pages)-1].granule = 0
+	return pages
+}
+
+var crcTable = func() [256]uint32 {
+	var t [256]uint32
+	for i := range t {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = (r << 1) ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		t[i] = r
+	}
+	return t
+}()
+
+// Ogg checksum: CRC-32 of the page with the checksum field zeroed (polynomial 0x04c11db7, no reflection)
+func crc32(data []byte) uint32 {
+	var crc uint32 = 0
+	for _, b := range data {
+		crc = (crc << 8) ^ crcTable[byte(crc>>24)^b]
+	}
+	return crc
+}
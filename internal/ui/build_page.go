@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"abb_ia/internal/audiobookshelf"
+	"abb_ia/internal/controller"
 	"abb_ia/internal/dto"
 	"abb_ia/internal/mq"
 	"abb_ia/internal/utils"
@@ -133,7 +134,7 @@ func (p *BuildPage) dispatchMessage(m *mq.Message) {
 	case *dto.TotalBuildProgress:
 		p.updateTotalBuildProgress(dto)
 	case *dto.BuildComplete:
-		p.buildComplete(dto)
+		p.startNextStep(dto)
 	case *dto.CopyFileProgress:
 		p.updateFileCopyProgress(dto)
 	case *dto.CopyProgress:
@@ -143,13 +144,15 @@ func (p *BuildPage) dispatchMessage(m *mq.Message) {
 	case *dto.UploadProgress:
 		p.updateTotalUploadProgress(dto)
 	case *dto.CopyComplete:
-		p.copyComplete(dto)
+		p.startNextStep(dto)
 	case *dto.UploadComplete:
-		p.uploadComplete(dto)
+		p.startNextStep(dto)
 	case *dto.ScanComplete:
-		p.scanComplete(dto)
+		p.startNextStep(dto)
 	case *dto.CleanupComplete:
 		p.cleanupComplete(dto)
+	case *dto.ProcessFailed:
+		p.processFailed(dto)
 	default:
 		m.UnsupportedTypeError(mq.BuildPage)
 	}
@@ -193,7 +196,7 @@ func (p *BuildPage) displayBookInfo(ab *dto.Audiobook) {
 	p.buildTable.Clear()
 	p.buildTable.showHeader()
 	for i, part := range ab.Parts {
-		p.buildTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.M4BFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
+		p.buildTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.OutputFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
 	}
 	p.buildTable.ScrollToBeginning()
 
@@ -204,7 +207,7 @@ func (p *BuildPage) displayBookInfo(ab *dto.Audiobook) {
 	p.copyTable.Clear()
 	p.copyTable.showHeader()
 	for i, part := range ab.Parts {
-		p.copyTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.M4BFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
+		p.copyTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.OutputFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
 	}
 	p.copyTable.ScrollToBeginning()
 
@@ -212,7 +215,7 @@ func (p *BuildPage) displayBookInfo(ab *dto.Audiobook) {
 	p.uploadTable.Clear()
 	p.uploadTable.showHeader()
 	for i, part := range ab.Parts {
-		p.uploadTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.M4BFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
+		p.uploadTable.appendRow(" "+strconv.Itoa(i+1)+" ", filepath.Base(part.OutputFile), part.Format, utils.SecondsToTime(part.Duration), utils.BytesToHuman(part.Size), "")
 	}
 	p.uploadTable.ScrollToBeginning()
 
@@ -365,48 +368,22 @@ func (p *BuildPage) updateTotalUploadProgress(dp *dto.UploadProgress) {
 	}
 }
 
-/*
- * A chain of final operations: ?Copy -> ?Upload -> ?Scan -> Cleanup - Done msg
- */
-func (p *BuildPage) buildComplete(c *dto.BuildComplete) {
-	// copy the book to Output directory if needed
-	ab := c.Audiobook
-	if ab.Config.IsCopyToOutputDir() {
-		p.mq.SendMessage(mq.BuildPage, mq.CopyController, &dto.CopyCommand{Audiobook: ab}, true)
-	} else {
-		p.mq.SendMessage(mq.BuildPage, mq.BuildPage, &dto.CopyComplete{Audiobook: ab}, true)
+// start the next final operation: ?Copy -> ?Upload -> ?Scan -> Cleanup - Done msg
+func (p *BuildPage) startNextStep(completed dto.Dto) {
+	if step, ok := controller.NextFinalStep(completed); ok {
+		p.mq.SendMessage(mq.BuildPage, step.Controller, step.Command, true)
 	}
 }
 
-func (p *BuildPage) copyComplete(c *dto.CopyComplete) {
-	// upload the book to Audiobookshelf server if needed
-	ab := c.Audiobook
-	if ab.Config.IsUploadToAudiobookshef() {
-		p.mq.SendMessage(mq.BuildPage, mq.UploadController, &dto.AbsUploadCommand{Audiobook: ab}, true)
-	} else {
-		p.mq.SendMessage(mq.BuildPage, mq.BuildPage, &dto.UploadComplete{Audiobook: ab}, true)
-	}
-}
-
-func (p *BuildPage) uploadComplete(c *dto.UploadComplete) {
-	// launch Audiobookshelf library scan if needed
-	ab := c.Audiobook
-	if ab.Config.IsScanAudiobookshef() {
-		p.mq.SendMessage(mq.BuildPage, mq.UploadController, &dto.AbsScanCommand{Audiobook: c.Audiobook}, true)
-	} else {
-		p.mq.SendMessage(mq.BuildPage, mq.BuildPage, &dto.ScanComplete{Audiobook: ab}, true)
-	}
-}
-
-func (p *BuildPage) scanComplete(c *dto.ScanComplete) {
-	// clean up temporary directory
-	p.mq.SendMessage(mq.BuildPage, mq.CleanupController, &dto.CleanupCommand{Audiobook: c.Audiobook}, true)
-}
-
 func (p *BuildPage) cleanupComplete(c *dto.CleanupComplete) {
 	p.bookReadyMgs(c.Audiobook)
 }
 
+// the build, copy, upload or scan failed. The audiobook is left in the temporary directory
+func (p *BuildPage) processFailed(c *dto.ProcessFailed) {
+	newMessageDialog(p.mq, c.Process+" Failed", "\n"+c.Error, p.buildSection.Grid, p.switchToSearch)
+}
+
 func (p *BuildPage) bookReadyMgs(ab *dto.Audiobook) {
 	newMessageDialog(p.mq, "Build Complete", "Audiobook has been created", p.buildSection.Grid, p.switchToSearch)
 }
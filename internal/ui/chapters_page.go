@@ -25,6 +25,8 @@ type ChaptersPage struct {
 	inputSeriesNo            *tview.InputField
 	inputGenre               *tview.DropDown
 	inputNarrator            *tview.InputField
+	inputYear                *tview.InputField
+	inputLanguage            *tview.InputField
 	inputCover               *tview.InputField
 	buttonCreateBook         *tview.Button
 	buttonCancel            *tview.Button
@@ -94,6 +96,11 @@ func newChaptersPage(dispatcher *mq.Dispatcher) *ChaptersPage {
 			p.ab.SeriesNo = s
 		}
 	})
+	p.inputYear = f1.AddInputField("Year:", "", 5, acceptInt, func(s string) {
+		if p.ab != nil {
+			p.ab.Year = s
+		}
+	})
 	infoSection.AddItem(f1.Form, 0, 1, 1, 1, 0, 0, true)
 	f2 := newForm()
 	f2.SetBorderPadding(1, 0, 2, 2)
@@ -107,6 +114,11 @@ func newChaptersPage(dispatcher *mq.Dispatcher) *ChaptersPage {
 			p.ab.Narrator = s
 		}
 	})
+	p.inputLanguage = f2.AddInputField("Language:", "", 20, nil, func(s string) {
+		if p.ab != nil {
+			p.ab.Language = s
+		}
+	})
 	infoSection.AddItem(f2.Form, 0, 2, 1, 1, 0, 0, true)
 	f3 := newForm()
 	f3.SetBorderPadding(0, 1, 1, 1)
@@ -200,8 +212,10 @@ func newChaptersPage(dispatcher *mq.Dispatcher) *ChaptersPage {
 		p.inputTitle,
 		p.inputSeries,
 		p.inputSeriesNo,
+		p.inputYear,
 		p.inputGenre,
 		p.inputNarrator,
+		p.inputLanguage,
 		p.inputCover,
 		p.buttonCreateBook,
 		p.buttonCancel,
@@ -254,6 +268,13 @@ func (p *ChaptersPage) displayBookInfo(ab *dto.Audiobook) {
 	p.inputAuthor.SetText(ab.Author)
 	p.inputTitle.SetText(ab.Title)
 	p.inputCover.SetText(ab.CoverURL)
+	// pre-filled from the IA metadata
+	p.inputYear.SetText(ab.Year)
+	p.inputNarrator.SetText(ab.Narrator)
+	p.inputLanguage.SetText(ab.Language)
+	if i := utils.GetIndex(config.Instance().GetGenres(), ab.Genre); i >= 0 {
+		p.inputGenre.SetCurrentOption(i)
+	}
 	p.textAreaDescription.SetText(ab.Description, false)
 
 	p.chaptersTable.Clear()
@@ -435,6 +456,8 @@ func (p *ChaptersPage) buildBook() {
 	p.ab.Series = p.inputSeries.GetText()
 	p.ab.SeriesNo = p.inputSeriesNo.GetText()
 	p.ab.Narrator = p.inputNarrator.GetText()
+	p.ab.Year = p.inputYear.GetText()
+	p.ab.Language = p.inputLanguage.GetText()
 	_, p.ab.Genre = p.inputGenre.GetCurrentOption()
 
 	p.mq.SendMessage(mq.ChaptersPage, mq.BuildController, &dto.BuildCommand{Audiobook: p.ab}, true)
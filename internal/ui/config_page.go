@@ -30,20 +30,38 @@ type ConfigPage struct {
 	sortByField      *tview.DropDown
 	sortOrderField   *tview.DropDown
 	rowsPerPage      *tview.InputField
+	useScrapeAPI     *tview.Checkbox
 	useMockField     *tview.Checkbox
 	saveMockField    *tview.Checkbox
 	outputDir        *tview.InputField
 	copyToOutputDir  *tview.Checkbox
 	tmpDir           *tview.InputField
+	iaBaseUrl        *tview.InputField
 
 	// audiobook build config section
 	concurrentDownloaders *tview.InputField
 	concurrentEncoders    *tview.InputField
+	downloadLimit         *tview.InputField
+	unlimitedHours        *tview.InputField
+	concurrentSearches    *tview.InputField
 	reEncodeFiles         *tview.Checkbox
 	bitRate               *tview.InputField
 	sampleRate            *tview.InputField
 	maxFileSize           *tview.InputField
 	shortenTitles         *tview.Checkbox
+	fileOrder             *tview.DropDown
+	outputFormat          *tview.DropDown
+	normalizeLoudness     *tview.Checkbox
+	targetLoudness        *tview.InputField
+	targetTruePeak        *tview.InputField
+	trimSilence           *tview.Checkbox
+	silenceThreshold      *tview.InputField
+	silenceMinDuration    *tview.InputField
+	silenceMaxTrim        *tview.InputField
+	cacheTTL              *tview.InputField
+	cacheMaxSize          *tview.InputField
+	watchInterval         *tview.InputField
+	stageTimeout          *tview.InputField
 
 	// audiobookshelf config section
 	uploadToAudiobookshelf *tview.Checkbox
@@ -53,6 +71,11 @@ type ConfigPage struct {
 	audiobookshelfLibrary  *tview.InputField
 	scanAudiobookshelf     *tview.Checkbox
 
+	// Internet Archive account
+	iaAccessKey *tview.InputField
+	iaSecretKey *tview.InputField
+	iaCookie    *tview.InputField
+
 	saveConfigButton *tview.Button
 	cancelButton     *tview.Button
 }
@@ -80,6 +103,7 @@ func newConfigPage(dispatcher *mq.Dispatcher) *ConfigPage {
 	p.sortByField = configFormLeft.AddDropdown("Sort By:", utils.AddSpaces(p.configCopy.GetSortByOptions()), 1, func(o string, i int) { p.configCopy.SetSortBy(strings.TrimSpace(o)) })
 	p.sortOrderField = configFormLeft.AddDropdown("Sort Order:", utils.AddSpaces(p.configCopy.GetSortOrderOptions()), 1, func(o string, i int) { p.configCopy.SetSortOrder(strings.TrimSpace(o)) })
 	p.rowsPerPage = configFormLeft.AddInputField("Page size:", "", 4, acceptInt, func(t string) { p.configCopy.SetRowsPerPage(utils.ToInt(t)) })
+	p.useScrapeAPI = configFormLeft.AddCheckbox("Use scrape API?", false, func(t bool) { p.configCopy.SetUseScrapeAPI(t) })
 	// p.useMockField = configFormLeft.AddCheckbox("Use mock?", false, func(t bool) { p.configCopy.SetUseMock(t) })
 	// p.saveMockField = configFormLeft.AddCheckbox("Save mock?", false, func(t bool) { p.configCopy.SetSaveMock(t) })
 	p.configSection.AddItem(configFormLeft.Form, 0, 0, 1, 1, 0, 0, true)
@@ -91,6 +115,7 @@ func newConfigPage(dispatcher *mq.Dispatcher) *ConfigPage {
 	p.tmpDir = configFormRight.AddInputField("Work directory:", "", 25, nil, func(t string) { p.configCopy.SetTmpDir(t) })
 	p.logFileNameField = configFormRight.AddInputField("Log name:", "", 25, nil, func(t string) { p.configCopy.SetLogfileName(t) })
 	p.logLevelField = configFormRight.AddDropdown("Log level:", utils.AddSpaces(logger.LogLeves()), 1, func(o string, i int) { p.configCopy.SetLogLevel(strings.TrimSpace(o)) })
+	p.iaBaseUrl = configFormRight.AddInputField("Archive URL:", "", 25, nil, func(t string) { p.configCopy.SetIaBaseUrl(t) })
 	p.configSection.AddItem(configFormRight.Form, 0, 1, 1, 1, 0, 0, true)
 
 	buttonsGrid := newGrid()
@@ -119,23 +144,39 @@ func newConfigPage(dispatcher *mq.Dispatcher) *ConfigPage {
 	buildFormLeft := newForm()
 	buildFormLeft.SetHorizontal(false)
 	p.concurrentDownloaders = buildFormLeft.AddInputField("Concurrent Downloaders:", "", 4, acceptInt, func(t string) { p.configCopy.SetConcurrentDownloaders(utils.ToInt(t)) })
+	p.downloadLimit = buildFormLeft.AddInputField("Download speed limit (KB/s, 0 - none):", "", 6, acceptInt, func(t string) { p.configCopy.SetDownloadLimitKbs(utils.ToInt(t)) })
+	p.unlimitedHours = buildFormLeft.AddInputField("No limit hours (00:00-07:00):", "", 11, nil, func(t string) { p.configCopy.SetUnlimitedDownloadHours(strings.TrimSpace(t)) })
 	p.concurrentEncoders = buildFormLeft.AddInputField("Concurrent Encoders:", "", 4, acceptInt, func(t string) { p.configCopy.SetConcurrentEncoders(utils.ToInt(t)) })
-	p.reEncodeFiles = buildFormLeft.AddCheckbox("Re-encode .mp3 files?", false, func(t bool) { p.configCopy.SetReEncodeFiles(t) })
+	p.concurrentSearches = buildFormLeft.AddInputField("Concurrent Search Requests:", "", 4, acceptInt, func(t string) { p.configCopy.SetConcurrentSearchRequests(utils.ToInt(t)) })
+	p.reEncodeFiles = buildFormLeft.AddCheckbox("Re-encode audio files?", false, func(t bool) { p.configCopy.SetReEncodeFiles(t) })
 	p.bitRate = buildFormLeft.AddInputField("Bit Rate (Kbps):", "", 4, acceptInt, func(t string) { p.configCopy.SetBitRate(utils.ToInt(t)) })
 	p.sampleRate = buildFormLeft.AddInputField("Sample Rate (Hz):", "", 6, acceptInt, func(t string) { p.configCopy.SetSampleRate(utils.ToInt(t)) })
+	p.trimSilence = buildFormLeft.AddCheckbox("Trim leading and trailing silence?", false, func(t bool) { p.configCopy.SetTrimSilence(t) })
+	p.silenceThreshold = buildFormLeft.AddInputField("Silence threshold (dB):", "", 6, acceptFloat, func(t string) { p.configCopy.SetSilenceThreshold(utils.ToFloat(t)) })
+	p.silenceMinDuration = buildFormLeft.AddInputField("Silence min duration (sec):", "", 6, acceptFloat, func(t string) { p.configCopy.SetSilenceMinDuration(utils.ToFloat(t)) })
+	p.silenceMaxTrim = buildFormLeft.AddInputField("Max silence trim per file end (sec):", "", 6, acceptFloat, func(t string) { p.configCopy.SetSilenceMaxTrim(utils.ToFloat(t)) })
 	p.buildSection.AddItem(buildFormLeft.Form, 0, 0, 1, 1, 0, 0, true)
 
 	buildFormRight := newForm()
 	buildFormRight.SetHorizontal(false)
 	p.maxFileSize = buildFormRight.AddInputField("Audiobook part max file size (Mb):", "", 6, acceptInt, func(t string) { p.configCopy.SetMaxFileSizeMb(utils.ToInt(t)) })
 	p.shortenTitles = buildFormRight.AddCheckbox("Shorten titles (-> OTRR for ex.)?", false, func(t bool) { p.configCopy.SetShortenTitles(t) })
+	p.fileOrder = buildFormRight.AddDropdown("Files order:", utils.AddSpaces(p.configCopy.GetFileOrderOptions()), 0, func(o string, i int) { p.configCopy.SetFileOrder(strings.TrimSpace(o)) })
+	p.normalizeLoudness = buildFormRight.AddCheckbox("Normalize loudness (EBU R128)?", false, func(t bool) { p.configCopy.SetNormalizeLoudness(t) })
+	p.targetLoudness = buildFormRight.AddInputField("Target loudness (LUFS):", "", 6, acceptFloat, func(t string) { p.configCopy.SetTargetLoudness(utils.ToFloat(t)) })
+	p.targetTruePeak = buildFormRight.AddInputField("Target true peak (dBTP):", "", 6, acceptFloat, func(t string) { p.configCopy.SetTargetTruePeak(utils.ToFloat(t)) })
+	p.outputFormat = buildFormRight.AddDropdown("Output format:", utils.AddSpaces(p.configCopy.GetOutputFormatOptions()), 0, func(o string, i int) { p.configCopy.SetOutputFormat(strings.TrimSpace(o)) })
+	p.cacheTTL = buildFormRight.AddInputField("Search cache TTL (hours, 0 - disabled):", "", 6, acceptInt, func(t string) { p.configCopy.SetCacheTTLHours(utils.ToInt(t)) })
+	p.cacheMaxSize = buildFormRight.AddInputField("Search cache max size (Mb):", "", 6, acceptInt, func(t string) { p.configCopy.SetCacheMaxSizeMb(utils.ToInt(t)) })
+	p.watchInterval = buildFormRight.AddInputField("Check watches every (hours, 0 - off):", "", 6, acceptInt, func(t string) { p.configCopy.SetWatchIntervalHours(utils.ToInt(t)) })
+	p.stageTimeout = buildFormRight.AddInputField("Headless stage timeout (hours, 0 - none):", "", 6, acceptInt, func(t string) { p.configCopy.SetStageTimeoutHours(utils.ToInt(t)) })
 	p.buildSection.AddItem(buildFormRight.Form, 0, 1, 1, 1, 0, 0, true)
 
 	p.mainGrid.AddItem(p.buildSection.Grid, 1, 0, 1, 1, 0, 0, true)
 
 	// audiobookshelf config section
 	p.absSection = newGrid()
-	p.absSection.SetColumns(-1)
+	p.absSection.SetColumns(-1, 40)
 	p.absSection.SetBorder(true)
 	p.absSection.SetTitle(" Audiobookshelf Integration: ")
 	p.absSection.SetTitleAlign(tview.AlignLeft)
@@ -150,9 +191,15 @@ func newConfigPage(dispatcher *mq.Dispatcher) *ConfigPage {
 	p.scanAudiobookshelf = absFormLeft.AddCheckbox("Scan the Audiobookshelf library after copy/upload?", false, func(t bool) { p.configCopy.SetScanAudiobookshelf(t) })
 	p.absSection.AddItem(absFormLeft.Form, 0, 0, 1, 1, 0, 0, true)
 
-	// absFormRight := newForm()
-	// absFormRight.SetHorizontal(false)
-	// p.absSection.AddItem(absFormRight.f, 0, 1, 1, 1, 0, 0, true)
+	iaFormRight := newForm()
+	iaFormRight.SetHorizontal(false)
+	iaFormRight.SetBorder(true)
+	iaFormRight.SetTitle("Internet Archive account (restricted items):")
+	iaFormRight.SetTitleAlign(tview.AlignLeft)
+	p.iaAccessKey = iaFormRight.AddInputField("IA Access Key:", "", 20, nil, func(t string) { p.configCopy.SetIaAccessKey(t) })
+	p.iaSecretKey = iaFormRight.AddPasswordField("IA Secret Key:", "", 20, 0, func(t string) { p.configCopy.SetIaSecretKey(t) })
+	p.iaCookie = iaFormRight.AddPasswordField("or Login Cookie:", "", 20, 0, func(t string) { p.configCopy.SetIaCookie(t) })
+	p.absSection.AddItem(iaFormRight.Form, 0, 1, 1, 1, 0, 0, true)
 
 	p.mainGrid.AddItem(p.absSection.Grid, 2, 0, 1, 1, 0, 0, true)
 
@@ -163,24 +210,45 @@ func newConfigPage(dispatcher *mq.Dispatcher) *ConfigPage {
 		p.sortByField,
 		p.sortOrderField,
 		p.rowsPerPage,
+		p.useScrapeAPI,
 		p.outputDir,
 		p.copyToOutputDir,
 		p.tmpDir,
 		p.logFileNameField,
 		p.logLevelField,
+		p.iaBaseUrl,
 		p.concurrentDownloaders,
+		p.downloadLimit,
+		p.unlimitedHours,
 		p.concurrentEncoders,
+		p.concurrentSearches,
 		p.reEncodeFiles,
 		p.bitRate,
 		p.sampleRate,
+		p.trimSilence,
+		p.silenceThreshold,
+		p.silenceMinDuration,
+		p.silenceMaxTrim,
 		p.maxFileSize,
 		p.shortenTitles,
+		p.fileOrder,
+		p.normalizeLoudness,
+		p.targetLoudness,
+		p.targetTruePeak,
+		p.outputFormat,
+		p.cacheTTL,
+		p.cacheMaxSize,
+		p.watchInterval,
+		p.stageTimeout,
 		p.uploadToAudiobookshelf,
 		p.audiobookshelfUrl,
 		p.audiobookshelfUser,
 		p.audiobookshelfPassword,
 		p.audiobookshelfLibrary,
 		p.scanAudiobookshelf,
+		p.iaAccessKey,
+		p.iaSecretKey,
+		p.iaCookie,
 		p.saveConfigButton,
 		p.cancelButton,
 	)
@@ -212,19 +280,37 @@ func (p *ConfigPage) displayConfig(c *dto.DisplayConfigCommand) {
 
 	p.logFileNameField.SetText(p.configCopy.GetLogFileName())
 	p.logLevelField.SetCurrentOption(utils.GetIndex(logger.LogLeves(), p.configCopy.GetLogLevel()))
+	p.iaBaseUrl.SetText(p.configCopy.GetIaBaseUrl())
 	p.defaultAuthor.SetText(p.configCopy.GetDefaultAuthor())
 	p.defaultTitle.SetText(p.configCopy.GetDefaultTitle())
 	p.sortByField.SetCurrentOption(utils.GetIndex(config.Instance().GetSortByOptions(), p.configCopy.GetSortBy()))
 	p.sortOrderField.SetCurrentOption(utils.GetIndex(config.Instance().GetSortOrderOptions(), p.configCopy.GetSortOrder()))
 	p.rowsPerPage.SetText(utils.ToString(p.configCopy.GetRowsPerPage()))
+	p.useScrapeAPI.SetChecked(p.configCopy.IsUseScrapeAPI())
 
 	p.concurrentDownloaders.SetText(utils.ToString(p.configCopy.GetConcurrentDownloaders()))
+	p.downloadLimit.SetText(utils.ToString(p.configCopy.GetDownloadLimitKbs()))
+	p.unlimitedHours.SetText(p.configCopy.GetUnlimitedDownloadHours())
 	p.concurrentEncoders.SetText(utils.ToString(p.configCopy.GetConcurrentEncoders()))
+	p.concurrentSearches.SetText(utils.ToString(p.configCopy.GetConcurrentSearchRequests()))
 	p.reEncodeFiles.SetChecked(p.configCopy.IsReEncodeFiles())
 	p.bitRate.SetText(utils.ToString(p.configCopy.GetBitRate()))
 	p.sampleRate.SetText(utils.ToString(p.configCopy.GetSampleRate()))
+	p.trimSilence.SetChecked(p.configCopy.IsTrimSilence())
+	p.silenceThreshold.SetText(utils.ToString(p.configCopy.GetSilenceThreshold()))
+	p.silenceMinDuration.SetText(utils.ToString(p.configCopy.GetSilenceMinDuration()))
+	p.silenceMaxTrim.SetText(utils.ToString(p.configCopy.GetSilenceMaxTrim()))
 	p.maxFileSize.SetText(utils.ToString(p.configCopy.GetMaxFileSizeMb()))
 	p.shortenTitles.SetChecked(p.configCopy.IsShortenTitle())
+	p.fileOrder.SetCurrentOption(utils.GetIndex(config.Instance().GetFileOrderOptions(), p.configCopy.GetFileOrder()))
+	p.normalizeLoudness.SetChecked(p.configCopy.IsNormalizeLoudness())
+	p.targetLoudness.SetText(utils.ToString(p.configCopy.GetTargetLoudness()))
+	p.targetTruePeak.SetText(utils.ToString(p.configCopy.GetTargetTruePeak()))
+	p.outputFormat.SetCurrentOption(utils.GetIndex(config.Instance().GetOutputFormatOptions(), p.configCopy.GetOutputFormat()))
+	p.cacheTTL.SetText(utils.ToString(p.configCopy.GetCacheTTLHours()))
+	p.cacheMaxSize.SetText(utils.ToString(p.configCopy.GetCacheMaxSizeMb()))
+	p.watchInterval.SetText(utils.ToString(p.configCopy.GetWatchIntervalHours()))
+	p.stageTimeout.SetText(utils.ToString(p.configCopy.GetStageTimeoutHours()))
 
 	p.uploadToAudiobookshelf.SetChecked(p.configCopy.IsUploadToAudiobookshef())
 	p.audiobookshelfUrl.SetText(p.configCopy.GetAudiobookshelfUrl())
@@ -233,11 +319,21 @@ func (p *ConfigPage) displayConfig(c *dto.DisplayConfigCommand) {
 	p.audiobookshelfUser.SetText(p.configCopy.GetAudiobookshelfUser())
 	p.audiobookshelfPassword.SetText(p.configCopy.GetAudiobookshelfPassword())
 
+	p.iaAccessKey.SetText(p.configCopy.GetIaAccessKey())
+	p.iaSecretKey.SetText(p.configCopy.GetIaSecretKey())
+	p.iaCookie.SetText(p.configCopy.GetIaCookie())
+
 	ui.Draw()
 	ui.SetFocus(p.configSection.Grid)
 }
 
 func (p *ConfigPage) SaveConfig() {
+	if h := p.configCopy.GetUnlimitedDownloadHours(); h != "" {
+		if _, _, err := utils.ParseTimeWindow(h); err != nil {
+			newMessageDialog(p.mq, "Error", "\nWrong no limit download hours: "+err.Error(), p.buildSection.Grid, func() {})
+			return
+		}
+	}
 	p.mq.SendMessage(mq.ConfigPage, mq.ConfigController, &dto.SaveConfigCommand{Config: p.configCopy}, true)
 	p.mq.SendMessage(mq.ConfigPage, mq.Frame, &dto.SwitchToPageCommand{Name: "SearchPage"}, false)
 }
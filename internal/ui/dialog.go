@@ -145,3 +145,10 @@ func newYesNoDialog(dispatcher *mq.Dispatcher, title string, message string, foc
 	d.setForm(f.Form)
 	d.Show()
 }
+
+// Dialog with a custom layout (a table and a form for ex.). The form gets the focus when the dialog is shown
+func (d *dialogWindow) setLayout(layout *grid, f *tview.Form) {
+	d.form = f
+	d.setFormAttributes()
+	d.grid.AddItem(layout.Grid, 1, 1, 1, 1, 0, 0, true)
+}
@@ -51,14 +51,14 @@ func newDownloadPage(dispatcher *mq.Dispatcher) *DownloadPage {
 	// files downnload section
 	p.filesSection = newGrid()
 	p.filesSection.SetColumns(-1)
-	p.filesSection.SetTitle(" Downloading .mp3 files... ")
+	p.filesSection.SetTitle(" Downloading audio files... ")
 	p.filesSection.SetTitleAlign(tview.AlignLeft)
 	p.filesSection.SetBorder(true)
 
 	p.filesTable = newTable()
-	p.filesTable.setHeaders(" # ", "File name", "Format", "Duration", "Size", "Download progress")
-	p.filesTable.setWeights(1, 2, 1, 1, 1, 5)
-	p.filesTable.setAlign(tview.AlignRight, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignLeft)
+	p.filesTable.setHeaders(" # ", "File name", "Format", "Duration", "Size", "Download progress", "Checksum")
+	p.filesTable.setWeights(1, 2, 1, 1, 1, 5, 1)
+	p.filesTable.setAlign(tview.AlignRight, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignLeft, tview.AlignCenter)
 	p.filesSection.AddItem(p.filesTable.Table, 0, 0, 1, 1, 0, 0, true)
 	p.mainGrid.AddItem(p.filesSection.Grid, 1, 0, 1, 1, 0, 0, true)
 
@@ -98,8 +98,14 @@ func (p *DownloadPage) dispatchMessage(m *mq.Message) {
 		p.displayBookInfo(dto.Audiobook)
 	case *dto.FileDownloadProgress:
 		p.updateFileProgress(dto)
+	case *dto.FileVerificationResult:
+		p.updateFileVerification(dto)
+	case *dto.FileDownloadError:
+		p.showFileError(dto)
 	case *dto.TotalDownloadProgress:
 		p.updateTotalProgress(dto)
+	case *dto.DownloadFailed:
+		p.downloadFailed(dto)
 	case *dto.DownloadComplete:
 		p.downloadComplete(dto)
 	default:
@@ -120,7 +126,7 @@ func (p *DownloadPage) displayBookInfo(ab *dto.Audiobook) {
 	p.filesTable.Clear()
 	p.filesTable.showHeader()
 	for i, f := range ab.IAItem.AudioFiles {
-		p.filesTable.appendRow(" "+strconv.Itoa(i+1)+" ", f.Name, f.Format, utils.SecondsToTime(f.Length), utils.BytesToHuman(f.Size), "")
+		p.filesTable.appendRow(" "+strconv.Itoa(i+1)+" ", f.Name, f.Format, utils.SecondsToTime(f.Length), utils.BytesToHuman(f.Size), "", "")
 	}
 	p.filesTable.ScrollToBeginning()
 	ui.SetFocus(p.filesTable.Table)
@@ -158,6 +164,64 @@ func (p *DownloadPage) updateFileProgress(dp *dto.FileDownloadProgress) {
 	}
 }
 
+func (p *DownloadPage) updateFileVerification(vr *dto.FileVerificationResult) {
+	cell := p.filesTable.GetCell(vr.FileId+1, 6)
+	switch vr.Status {
+	case "OK":
+		cell.Text = "[green]" + vr.Status
+	case "Failed":
+		cell.Text = "[red]" + vr.Status
+	default:
+		cell.Text = "[yellow]" + vr.Status
+	}
+	ui.Draw()
+}
+
+func (p *DownloadPage) showFileError(e *dto.FileDownloadError) {
+	progressCell := p.filesTable.GetCell(e.FileId+1, 5)
+	progressCell.Text = "[red]Failed: " + e.Error
+	statusCell := p.filesTable.GetCell(e.FileId+1, 6)
+	statusCell.Text = "[red]Failed"
+	ui.Draw()
+}
+
+func (p *DownloadPage) downloadFailed(c *dto.DownloadFailed) {
+	message := fmt.Sprintf("%d file(s) failed to download:\n", len(c.Files))
+	for i, f := range c.Files {
+		if i == 3 {
+			message += fmt.Sprintf("...and %d more\n", len(c.Files)-i)
+			break
+		}
+		message += f + "\n"
+	}
+	message += "Retry the download or skip the failed files?"
+
+	d := newDialogWindow(p.mq, 13, 70, p.filesSection.Grid)
+	f := newForm()
+	f.SetTitle("Download Failed")
+	tv := tview.NewTextView()
+	tv.SetWrap(true)
+	tv.SetWordWrap(true)
+	tv.SetDynamicColors(true)
+	tv.SetText(message)
+	tv.SetTextAlign(tview.AlignCenter)
+	f.AddFormItem(tv)
+	f.AddButton("Retry", func() {
+		p.mq.SendMessage(mq.DownloadPage, mq.DownloadController, &dto.RetryDownloadCommand{Audiobook: c.Audiobook}, true)
+		d.Close()
+	})
+	f.AddButton("Skip", func() {
+		p.mq.SendMessage(mq.DownloadPage, mq.DownloadController, &dto.SkipFailedFilesCommand{Audiobook: c.Audiobook}, true)
+		d.Close()
+	})
+	f.AddButton("Stop", func() {
+		d.Close()
+		p.stopDownload()
+	})
+	d.setForm(f.Form)
+	d.Show()
+}
+
 func (p *DownloadPage) updateTotalProgress(dp *dto.TotalDownloadProgress) {
 	if p.progressTable.GetRowCount() == 0 {
 		for i := 0; i < 2; i++ {
@@ -166,7 +230,11 @@ func (p *DownloadPage) updateTotalProgress(dp *dto.TotalDownloadProgress) {
 	}
 	infoCell := p.progressTable.GetCell(0, 0)
 	progressCell := p.progressTable.GetCell(1, 0)
-	infoCell.Text = fmt.Sprintf("  [yellow]Elapsed: [white]%7s | [yellow]Downloaded: [white]%8s | [yellow]Files: [white]%7s | [yellow]Speed: [white]%6s | [yellow]ETA: [white]%7s", dp.Elapsed, dp.Bytes, dp.Files, dp.Speed, dp.ETA)
+	speed := dp.Speed
+	if dp.Limit != "" {
+		speed += " (limit " + dp.Limit + ")"
+	}
+	infoCell.Text = fmt.Sprintf("  [yellow]Elapsed: [white]%7s | [yellow]Downloaded: [white]%8s | [yellow]Files: [white]%7s | [yellow]Speed: [white]%6s | [yellow]ETA: [white]%7s", dp.Elapsed, dp.Bytes, dp.Files, speed, dp.ETA)
 
 	col := 0
 	w := p.progressTable.GetColumnWidth(col) - 5
@@ -181,7 +249,7 @@ func (p *DownloadPage) updateTotalProgress(dp *dto.TotalDownloadProgress) {
 
 func (p *DownloadPage) downloadComplete(c *dto.DownloadComplete) {
 	ab := c.Audiobook
-	if ab.Config.IsReEncodeFiles() {
+	if ab.Config.IsReEncodeFiles() || ab.Config.IsNormalizeLoudness() || ab.Config.IsTrimSilence() {
 		p.mq.SendMessage(mq.DownloadPage, mq.EncodingController, &dto.EncodeCommand{Audiobook: c.Audiobook}, true)
 		p.mq.SendMessage(mq.DownloadPage, mq.Frame, &dto.SwitchToPageCommand{Name: "EncodingPage"}, false)
 	} else {
@@ -51,14 +51,14 @@ func newEncodingPage(dispatcher *mq.Dispatcher) *EncodingPage {
 	// files re-encoding section
 	p.filesSection = newGrid()
 	p.filesSection.SetColumns(-1)
-	p.filesSection.SetTitle(" Re-encodinging .mp3 files to the same bitrate... ")
+	p.filesSection.SetTitle(" Re-encoding audio files to the same bitrate... ")
 	p.filesSection.SetTitleAlign(tview.AlignLeft)
 	p.filesSection.SetBorder(true)
 
 	p.filesTable = newTable()
-	p.filesTable.setHeaders(" # ", "File name", "Format", "Duration", "Size", "Encoding progress")
-	p.filesTable.setWeights(1, 2, 1, 1, 1, 5)
-	p.filesTable.setAlign(tview.AlignRight, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignLeft)
+	p.filesTable.setHeaders(" # ", "File name", "Format", "Duration", "Size", "Loudness (LUFS)", "Encoding progress")
+	p.filesTable.setWeights(1, 2, 1, 1, 1, 1, 5)
+	p.filesTable.setAlign(tview.AlignRight, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignRight, tview.AlignLeft)
 	p.filesSection.AddItem(p.filesTable.Table, 0, 0, 1, 1, 0, 0, true)
 	p.mainGrid.AddItem(p.filesSection.Grid, 1, 0, 1, 1, 0, 0, true)
 
@@ -97,10 +97,16 @@ func (p *EncodingPage) dispatchMessage(m *mq.Message) {
 		p.displayBookInfo(dto.Audiobook)
 	case *dto.EncodingFileProgress:
 		p.updateFileProgress(dto)
+	case *dto.EncodingFileLoudness:
+		p.updateFileLoudness(dto)
+	case *dto.EncodingFileTrimmed:
+		p.updateFileDuration(dto)
 	case *dto.EncodingProgress:
 		p.updateTotalProgress(dto)
 	case *dto.EncodingComplete:
 		p.encodingComplete(dto)
+	case *dto.ProcessFailed:
+		p.encodingFailed(dto)
 	default:
 		m.UnsupportedTypeError(mq.EncodingPage)
 	}
@@ -116,10 +122,19 @@ func (p *EncodingPage) displayBookInfo(ab *dto.Audiobook) {
 	p.infoPanel.appendRow("Size:", utils.BytesToHuman(ab.IAItem.TotalSize))
 	p.infoPanel.appendRow("Files", strconv.Itoa(len(ab.IAItem.AudioFiles)))
 
+	if ab.Config.IsNormalizeLoudness() && ab.Config.IsTrimSilence() {
+		p.filesSection.SetTitle(fmt.Sprintf(" Trimming silence, normalizing loudness to %s LUFS and re-encoding audio files... ", utils.ToString(ab.Config.GetTargetLoudness())))
+	} else if ab.Config.IsNormalizeLoudness() {
+		p.filesSection.SetTitle(fmt.Sprintf(" Normalizing loudness to %s LUFS and re-encoding audio files... ", utils.ToString(ab.Config.GetTargetLoudness())))
+	} else if ab.Config.IsTrimSilence() {
+		p.filesSection.SetTitle(" Trimming leading and trailing silence and re-encoding audio files... ")
+	} else {
+		p.filesSection.SetTitle(" Re-encoding audio files to the same bitrate... ")
+	}
 	p.filesTable.Clear()
 	p.filesTable.showHeader()
 	for i, f := range ab.IAItem.AudioFiles {
-		p.filesTable.appendRow(" "+strconv.Itoa(i+1)+" ", f.Name, fmt.Sprintf("MP3 %d kb/s", ab.Config.GetBitRate()), utils.SecondsToTime(f.Length), utils.BytesToHuman(f.Size), "")
+		p.filesTable.appendRow(" "+strconv.Itoa(i+1)+" ", f.Name, fmt.Sprintf("MP3 %d kb/s", ab.Config.GetBitRate()), utils.SecondsToTime(f.Length), utils.BytesToHuman(f.Size), "", "")
 	}
 	p.filesTable.ScrollToBeginning()
 	ui.SetFocus(p.filesTable.Table)
@@ -136,8 +151,26 @@ func (p *EncodingPage) stopEncoding() {
 	p.mq.SendMessage(mq.EncodingPage, mq.Frame, &dto.SwitchToPageCommand{Name: "SearchPage"}, true)
 }
 
+// measured loudness -> loudness after normalization
+func (p *EncodingPage) updateFileLoudness(dl *dto.EncodingFileLoudness) {
+	cell := p.filesTable.GetCell(dl.FileId+1, 5)
+	if dl.Normalized {
+		cell.Text = fmt.Sprintf("%.1f -> %.1f", dl.Measured, dl.Corrected)
+	} else {
+		cell.Text = fmt.Sprintf("%.1f", dl.Measured)
+	}
+	ui.Draw()
+}
+
+// the duration of the trimmed file and the silence cut
+func (p *EncodingPage) updateFileDuration(dt *dto.EncodingFileTrimmed) {
+	cell := p.filesTable.GetCell(dt.FileId+1, 3)
+	cell.Text = fmt.Sprintf("%s (-%.1fs)", utils.SecondsToTime(dt.Duration), dt.Trimmed)
+	ui.Draw()
+}
+
 func (p *EncodingPage) updateFileProgress(dp *dto.EncodingFileProgress) {
-	col := 5
+	col := 6
 	w := p.filesTable.GetColumnWidth(col) - 5
 	if w > 0 {
 		progressText := fmt.Sprintf(" %3d%% ", dp.Percent)
@@ -178,6 +211,12 @@ func (p *EncodingPage) updateTotalProgress(dp *dto.EncodingProgress) {
 	}
 }
 
+func (p *EncodingPage) encodingFailed(c *dto.ProcessFailed) {
+	newMessageDialog(p.mq, "Encoding Failed", "\n"+c.Error, p.filesSection.Grid, func() {
+		p.mq.SendMessage(mq.EncodingPage, mq.Frame, &dto.SwitchToPageCommand{Name: "SearchPage"}, false)
+	})
+}
+
 func (p *EncodingPage) encodingComplete(c *dto.EncodingComplete) {
 	p.mq.SendMessage(mq.EncodingPage, mq.ChaptersController, &dto.ChaptersCreate{Audiobook: c.Audiobook}, true)
 	p.mq.SendMessage(mq.EncodingPage, mq.Frame, &dto.SwitchToPageCommand{Name: "ChaptersPage"}, false)
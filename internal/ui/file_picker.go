@@ -0,0 +1,219 @@
+package ui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/vpoluyaktov/tview"
+)
+
+type FilesSelectedFunc func(selected []bool)
+
+/**
+ * Dialog to select a subset of the item audio files to download (one season of a "Singles" item for ex.).
+ * Files are selected one by one (Enter, Space or double click), by a regular expression matching the file name or title
+ * and by a range of dates found in the file names (Show_49-01-15_Title.mp3)
+ **/
+type filePicker struct {
+	d        *dialogWindow
+	files    []dto.AudioFile
+	selected []bool
+	table    *table
+	regex    string
+	dateFrom string
+	dateTo   string
+	message  *tview.TextView
+}
+
+func newFilePicker(dispatcher *mq.Dispatcher, files []dto.AudioFile, selected []bool, focus tview.Primitive, okFunc FilesSelectedFunc) {
+	p := &filePicker{}
+	p.files = files
+	p.selected = append([]bool{}, selected...)
+
+	p.d = newDialogWindow(dispatcher, 32, 110, focus)
+	layout := newGrid()
+	layout.SetRows(-1, 1, 5)
+	layout.SetColumns(0)
+
+	p.table = newTable()
+	p.table.SetBorder(true)
+	p.table.SetTitleAlign(tview.AlignLeft)
+	p.table.setHeaders("", "File name", "Date", "Duration", "Size")
+	p.table.setWeights(1, 10, 2, 2, 2)
+	p.table.setAlign(tview.AlignCenter, tview.AlignLeft, tview.AlignCenter, tview.AlignRight, tview.AlignRight)
+	p.table.SetSelectedFunc(func(row int, col int) { p.toggle(row) })
+	p.table.SetMouseDblClickFunc(func(row int, col int) { p.toggle(row) })
+	p.table.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == ' ' {
+			row, _ := p.table.GetSelection()
+			p.toggle(row)
+			return nil
+		}
+		return event
+	})
+	p.table.showHeader()
+	for i, f := range p.files {
+		date := ""
+		if d, ok := utils.FileNameDate(f.Name); ok {
+			date = d.Format("2006-01-02")
+		}
+		p.table.appendRow(checkMark(p.selected[i]), f.Name, date, utils.SecondsToTime(f.Length), utils.BytesToHuman(f.Size))
+	}
+	p.table.ScrollToBeginning()
+	layout.AddItem(p.table.Table, 0, 0, 1, 1, 0, 0, true)
+
+	p.message = tview.NewTextView()
+	p.message.SetDynamicColors(true)
+	p.message.SetTextColor(black)
+	p.message.SetBackgroundColor(gray)
+	layout.AddItem(p.message, 1, 0, 1, 1, 0, 0, false)
+
+	f := newForm()
+	f.SetTitle("Select Files")
+	regexField := f.AddInputField("Regex:", "", 30, nil, func(t string) { p.regex = t })
+	fromField := f.AddInputField("Date from:", "", 10, nil, func(t string) { p.dateFrom = t })
+	toField := f.AddInputField("to:", "", 10, nil, func(t string) { p.dateTo = t })
+	selectButton := f.AddButton("Select", func() { p.selectMatching(true) })
+	unselectButton := f.AddButton("Unselect", func() { p.selectMatching(false) })
+	allButton := f.AddButton("All", func() { p.selectAll(true) })
+	noneButton := f.AddButton("None", func() { p.selectAll(false) })
+	okButton := f.AddButton("Ok", func() {
+		if p.selectedCount() == 0 {
+			p.showMessage("Please select at least one file")
+			return
+		}
+		p.d.Close()
+		okFunc(p.selected)
+	})
+	cancelButton := f.AddButton("Cancel", func() {
+		p.d.Close()
+		okFunc(selected)
+	})
+	layout.AddItem(f.Form, 2, 0, 1, 1, 0, 0, false)
+	layout.SetNavigationOrder(p.table.Table, regexField, fromField, toField, selectButton, unselectButton, allButton, noneButton, okButton, cancelButton)
+
+	p.d.setLayout(layout, f.Form)
+	f.SetHorizontal(true)
+	p.updateTitle()
+	p.showMessage("Space/Enter - select a file. Regex matches the file name, dates are yyyy, yyyy-mm or yyyy-mm-dd")
+	p.d.Show()
+	ui.SetFocus(p.table.Table)
+}
+
+func (p *filePicker) toggle(row int) {
+	i := row - 1
+	if i < 0 || i >= len(p.files) {
+		return
+	}
+	p.setSelected(i, !p.selected[i])
+	p.updateTitle()
+}
+
+func (p *filePicker) selectAll(selected bool) {
+	for i := range p.files {
+		p.setSelected(i, selected)
+	}
+	p.updateTitle()
+}
+
+// select or unselect the files matching the regex and the date range
+func (p *filePicker) selectMatching(selected bool) {
+	var re *regexp.Regexp
+	if strings.TrimSpace(p.regex) != "" {
+		var err error
+		re, err = regexp.Compile("(?i)" + p.regex)
+		if err != nil {
+			p.showMessage("[red]Invalid regex: " + err.Error())
+			return
+		}
+	}
+	var from, to time.Time
+	var err error
+	if strings.TrimSpace(p.dateFrom) != "" {
+		if from, err = utils.ParsePartialDate(p.dateFrom, false); err != nil {
+			p.showMessage("[red]" + err.Error())
+			return
+		}
+	}
+	if strings.TrimSpace(p.dateTo) != "" {
+		if to, err = utils.ParsePartialDate(p.dateTo, true); err != nil {
+			p.showMessage("[red]" + err.Error())
+			return
+		}
+	}
+
+	matched := 0
+	for i, f := range p.files {
+		if re != nil && !re.MatchString(f.Name) && !re.MatchString(f.Title) {
+			continue
+		}
+		if !from.IsZero() || !to.IsZero() {
+			// files without a date don't match any date range
+			date, ok := utils.FileNameDate(f.Name)
+			if !ok {
+				date, ok = utils.FileNameDate(f.Title)
+			}
+			if !ok || (!from.IsZero() && date.Before(from)) || (!to.IsZero() && date.After(to)) {
+				continue
+			}
+		}
+		p.setSelected(i, selected)
+		matched++
+	}
+	p.showMessage(fmt.Sprintf("%d file(s) matched", matched))
+	p.updateTitle()
+}
+
+func (p *filePicker) setSelected(i int, selected bool) {
+	p.selected[i] = selected
+	p.table.GetCell(i+1, 0).SetText(checkMark(selected))
+}
+
+func (p *filePicker) selectedCount() int {
+	count := 0
+	for _, s := range p.selected {
+		if s {
+			count++
+		}
+	}
+	return count
+}
+
+func (p *filePicker) updateTitle() {
+	files := selectedFiles(p.files, p.selected)
+	var size int64 = 0
+	var length float64 = 0
+	for _, f := range files {
+		size += f.Size
+		length += f.Length
+	}
+	p.table.SetTitle(fmt.Sprintf(" Selected %d of %d files, %s, %s: ", len(files), len(p.files), utils.SecondsToTime(length), utils.BytesToHuman(size)))
+}
+
+func (p *filePicker) showMessage(message string) {
+	p.message.SetText(" " + message)
+}
+
+func checkMark(selected bool) string {
+	if selected {
+		return tview.Escape("[x]")
+	}
+	return tview.Escape("[ ]")
+}
+
+func selectedFiles(files []dto.AudioFile, selected []bool) []dto.AudioFile {
+	result := []dto.AudioFile{}
+	for i, f := range files {
+		if selected[i] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
@@ -0,0 +1,174 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+
+	"github.com/vpoluyaktov/tview"
+)
+
+/**
+ * The audiobooks built by abb_ia. An entry can be opened on the search page or rebuilt with the same settings
+ **/
+type HistoryPage struct {
+	mq          *mq.Dispatcher
+	mainGrid    *grid
+	entries     []dto.HistoryEntry
+	historyGrid *grid
+	table       *table
+	detailsView *tview.TextView
+
+	openButton    *tview.Button
+	rebuildButton *tview.Button
+	deleteButton  *tview.Button
+	backButton    *tview.Button
+}
+
+func newHistoryPage(dispatcher *mq.Dispatcher) *HistoryPage {
+	p := &HistoryPage{}
+	p.mq = dispatcher
+	p.mq.RegisterListener(mq.HistoryPage, p.dispatchMessage)
+
+	p.mainGrid = newGrid()
+	p.mainGrid.SetRows(-1, 9, 3)
+	p.mainGrid.SetColumns(0)
+
+	p.historyGrid = newGrid()
+	p.historyGrid.SetColumns(-1)
+	p.historyGrid.SetBorder(true)
+	p.historyGrid.SetTitle(" Build history: ")
+	p.historyGrid.SetTitleAlign(tview.AlignLeft)
+	p.table = newTable()
+	p.table.setHeaders("Build date", "Author", "Title", "Items", "Parts", "Size")
+	p.table.setWeights(2, 3, 6, 1, 1, 2)
+	p.table.setAlign(tview.AlignLeft, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignRight)
+	p.table.SetSelectionChangedFunc(func(row int, col int) { p.showDetails(row) })
+	p.table.SetSelectedFunc(func(row int, col int) { p.open(false) })
+	p.historyGrid.AddItem(p.table.Table, 0, 0, 1, 1, 0, 0, true)
+	p.mainGrid.AddItem(p.historyGrid.Grid, 0, 0, 1, 1, 0, 0, true)
+
+	p.detailsView = tview.NewTextView()
+	p.detailsView.SetBorder(true)
+	p.detailsView.SetTitle(" Details: ")
+	p.detailsView.SetTitleAlign(tview.AlignLeft)
+	p.mainGrid.AddItem(p.detailsView, 1, 0, 1, 1, 0, 0, false)
+
+	f := newForm()
+	f.SetHorizontal(true)
+	f.SetButtonsAlign(tview.AlignRight)
+	p.openButton = f.AddButton("Open", func() { p.open(false) })
+	p.rebuildButton = f.AddButton("Rebuild", func() { p.open(true) })
+	p.deleteButton = f.AddButton("Delete", p.deleteEntry)
+	p.backButton = f.AddButton("Back", func() {
+		p.mq.SendMessage(mq.HistoryPage, mq.Frame, &dto.SwitchToPageCommand{Name: "SearchPage"}, false)
+	})
+	p.mainGrid.AddItem(f.Form, 2, 0, 1, 1, 0, 0, false)
+
+	p.mainGrid.SetNavigationOrder(
+		p.table.Table,
+		p.detailsView,
+		p.openButton,
+		p.rebuildButton,
+		p.deleteButton,
+		p.backButton,
+	)
+
+	return p
+}
+
+func (p *HistoryPage) checkMQ() {
+	m := p.mq.GetMessage(mq.HistoryPage)
+	if m != nil {
+		p.dispatchMessage(m)
+	}
+}
+
+func (p *HistoryPage) dispatchMessage(m *mq.Message) {
+	switch dto := m.Dto.(type) {
+	case *dto.HistoryList:
+		p.showHistory(dto)
+	default:
+		m.UnsupportedTypeError(mq.HistoryPage)
+	}
+}
+
+func (p *HistoryPage) showHistory(l *dto.HistoryList) {
+	row, _ := p.table.GetSelection()
+	p.entries = l.Entries
+	p.table.Clear()
+	p.table.showHeader()
+	for _, e := range p.entries {
+		var size int64 = 0
+		for _, part := range e.Parts {
+			size += part.Size
+		}
+		p.table.appendRow(e.BuildDate.Format("2006-01-02 15:04"), tview.Escape(e.Author), tview.Escape(e.Title), strconv.Itoa(len(e.ItemIDs)), strconv.Itoa(len(e.Parts)), utils.BytesToHuman(size))
+	}
+	p.historyGrid.SetTitle(fmt.Sprintf(" Build history (%d audiobooks): ", len(p.entries)))
+	if row > len(p.entries) {
+		row = len(p.entries)
+	}
+	if row < 1 {
+		row = 1
+	}
+	p.table.Select(row, 0)
+	p.showDetails(row)
+	ui.SetFocus(p.table.Table)
+	ui.Draw()
+}
+
+func (p *HistoryPage) selectedEntry() *dto.HistoryEntry {
+	row, _ := p.table.GetSelection()
+	if row <= 0 || row > len(p.entries) {
+		return nil
+	}
+	return &p.entries[row-1]
+}
+
+func (p *HistoryPage) showDetails(row int) {
+	if row <= 0 || row > len(p.entries) {
+		p.detailsView.SetText("")
+		return
+	}
+	e := p.entries[row-1]
+	text := "Items: " + strings.Join(e.ItemIDs, ", ") + "\n"
+	for _, part := range e.Parts {
+		text += part.Path + "  " + utils.BytesToHuman(part.Size) + "  sha1: " + part.Sha1 + "\n"
+	}
+	s := e.Settings
+	reEncode := "no"
+	if s.ReEncodeFiles {
+		reEncode = fmt.Sprintf("%d Kbps, %d Hz", s.BitRateKbs, s.SampleRateHz)
+	}
+	text += fmt.Sprintf("Re-encoded: %s, part max size: %d Mb, files order: %s, format: %s", reEncode, s.MaxFileSizeMb, s.FileOrder, s.OutputFormat)
+	p.detailsView.SetText(tview.Escape(text))
+	p.detailsView.ScrollToBeginning()
+}
+
+// show the entry items on the search page. Open the Create Audiobook dialog with the entry settings to rebuild
+func (p *HistoryPage) open(rebuild bool) {
+	e := p.selectedEntry()
+	if e == nil {
+		return
+	}
+	p.mq.SendMessage(mq.HistoryPage, mq.SearchPage, &dto.OpenHistoryEntryCommand{Entry: *e, Rebuild: rebuild}, true)
+	p.mq.SendMessage(mq.HistoryPage, mq.Frame, &dto.SwitchToPageCommand{Name: "SearchPage"}, false)
+}
+
+func (p *HistoryPage) deleteEntry() {
+	e := p.selectedEntry()
+	if e == nil {
+		return
+	}
+	entry := *e
+	newYesNoDialog(p.mq, "Delete", "Remove [darkblue]"+tview.Escape(entry.Author+" - "+entry.Title)+"[black] from the build history?\nThe audiobook files are not deleted.", p.table.Table,
+		func() {
+			p.mq.SendMessage(mq.HistoryPage, mq.HistoryController, &dto.DeleteHistoryCommand{ID: entry.ID, BuildDate: entry.BuildDate}, true)
+		},
+		func() {})
+}
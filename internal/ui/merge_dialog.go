@@ -0,0 +1,117 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+
+	"github.com/vpoluyaktov/tview"
+)
+
+type ItemsMergedFunc func(items []*dto.IAItem)
+
+/**
+ * Dialog to set the order of the IA items merged into one audiobook (Part 1, Part 2, ... of a long serial for ex.)
+ **/
+type mergeDialog struct {
+	d       *dialogWindow
+	items   []*dto.IAItem
+	table   *table
+	message *tview.TextView
+}
+
+func newMergeDialog(dispatcher *mq.Dispatcher, items []*dto.IAItem, focus tview.Primitive, okFunc ItemsMergedFunc) {
+	p := &mergeDialog{}
+	p.items = append([]*dto.IAItem{}, items...)
+
+	p.d = newDialogWindow(dispatcher, 24, 110, focus)
+	layout := newGrid()
+	layout.SetRows(-1, 1, 3)
+	layout.SetColumns(0)
+
+	p.table = newTable()
+	p.table.SetBorder(true)
+	p.table.SetTitleAlign(tview.AlignLeft)
+	p.table.setHeaders(" # ", "Creator", "Title", "Files", "Duration", "Size")
+	p.table.setWeights(1, 3, 6, 1, 2, 2)
+	p.table.setAlign(tview.AlignRight, tview.AlignLeft, tview.AlignLeft, tview.AlignRight, tview.AlignRight, tview.AlignRight)
+	layout.AddItem(p.table.Table, 0, 0, 1, 1, 0, 0, true)
+
+	p.message = tview.NewTextView()
+	p.message.SetDynamicColors(true)
+	p.message.SetTextColor(black)
+	p.message.SetBackgroundColor(gray)
+	layout.AddItem(p.message, 1, 0, 1, 1, 0, 0, false)
+
+	f := newForm()
+	f.SetTitle("Merge Items")
+	upButton := f.AddButton("Move Up", func() { p.move(-1) })
+	downButton := f.AddButton("Move Down", func() { p.move(1) })
+	removeButton := f.AddButton("Remove", p.remove)
+	okButton := f.AddButton("Ok", func() {
+		if len(p.items) < 2 {
+			p.showMessage("Please select at least two items to merge")
+			return
+		}
+		p.d.Close()
+		okFunc(p.items)
+	})
+	cancelButton := f.AddButton("Cancel", func() {
+		p.d.Close()
+	})
+	layout.AddItem(f.Form, 2, 0, 1, 1, 0, 0, false)
+	layout.SetNavigationOrder(p.table.Table, upButton, downButton, removeButton, okButton, cancelButton)
+
+	p.d.setLayout(layout, f.Form)
+	f.SetHorizontal(true)
+	p.showItems(1)
+	p.showMessage("The items are merged in the order shown. Select an item and move it up or down to change the order")
+	p.d.Show()
+	ui.SetFocus(p.table.Table)
+}
+
+func (p *mergeDialog) showItems(selectedRow int) {
+	p.table.Clear()
+	p.table.showHeader()
+	var size int64 = 0
+	var length float64 = 0
+	for i, item := range p.items {
+		p.table.appendRow(strconv.Itoa(i+1), item.Creator, item.Title, strconv.Itoa(len(item.AudioFiles)), utils.SecondsToTime(item.TotalLength), utils.BytesToHuman(item.TotalSize))
+		size += item.TotalSize
+		length += item.TotalLength
+	}
+	p.table.SetTitle(fmt.Sprintf(" %d items, %s, %s: ", len(p.items), utils.SecondsToTime(length), utils.BytesToHuman(size)))
+	if selectedRow > len(p.items) {
+		selectedRow = len(p.items)
+	}
+	p.table.Select(selectedRow, 0)
+}
+
+// move the selected item up (-1) or down (1)
+func (p *mergeDialog) move(direction int) {
+	row, _ := p.table.GetSelection()
+	i := row - 1
+	j := i + direction
+	if i < 0 || i >= len(p.items) || j < 0 || j >= len(p.items) {
+		return
+	}
+	p.items[i], p.items[j] = p.items[j], p.items[i]
+	p.showItems(j + 1)
+}
+
+func (p *mergeDialog) remove() {
+	row, _ := p.table.GetSelection()
+	i := row - 1
+	if i < 0 || i >= len(p.items) {
+		return
+	}
+	p.items = append(p.items[:i], p.items[i+1:]...)
+	p.showItems(row)
+}
+
+func (p *mergeDialog) showMessage(message string) {
+	p.message.SetText(" " + message)
+}
@@ -11,6 +11,7 @@ import (
 	"abb_ia/internal/mq"
 	"abb_ia/internal/utils"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/vpoluyaktov/tview"
 )
 
@@ -20,15 +21,32 @@ type SearchPage struct {
 	searchCondition dto.SearchCondition
 	isSearchRunning bool
 	searchResult    []*dto.IAItem
+	breadcrumb      []dto.SearchCondition // search conditions to go back to from a collection
+	mergeItems      []*dto.IAItem         // items marked to be merged into one audiobook, in the marking order
+	watchDialog     *watchDialog
+	rebuildEntry    *dto.HistoryEntry // the history entry to rebuild once its items are found
 
 	searchSection         *grid
 	author                *tview.InputField
 	title                 *tview.InputField
+	collection            *tview.InputField
+	query                 *tview.InputField
+	subject               *tview.InputField
+	language              *tview.InputField
+	yearFrom              *tview.InputField
+	yearTo                *tview.InputField
+	minRuntime            *tview.InputField
+	maxRuntime            *tview.InputField
 	SortBy                *tview.DropDown
 	sortOrder             *tview.DropDown
 	searchButton          *tview.Button
 	clearButton           *tview.Button
+	upButton              *tview.Button
+	refreshButton         *tview.Button
 	createAudioBookButton *tview.Button
+	mergeButton           *tview.Button
+	watchesButton         *tview.Button
+	historyButton         *tview.Button
 	SettingsButton        *tview.Button
 
 	resultSection *grid
@@ -50,12 +68,12 @@ func newSearchPage(dispatcher *mq.Dispatcher) *SearchPage {
 	p.searchCondition.Title = config.Instance().GetDefaultTitle()
 
 	p.mainGrid = newGrid()
-	p.mainGrid.SetRows(9, -1, -1, 3)
+	p.mainGrid.SetRows(11, -1, -1, 3)
 	p.mainGrid.SetColumns(0)
 
 	// search section
 	p.searchSection = newGrid()
-	p.searchSection.SetColumns(50, -1, -1)
+	p.searchSection.SetColumns(55, -1, 32, -1)
 	p.searchSection.SetBorder(true)
 	p.searchSection.SetTitle(" Internet Archive Search ")
 	p.searchSection.SetTitleAlign(tview.AlignLeft)
@@ -63,15 +81,27 @@ func newSearchPage(dispatcher *mq.Dispatcher) *SearchPage {
 	f.SetHorizontal(false)
 	p.author = f.AddInputField("Creator", config.Instance().GetDefaultAuthor(), 40, nil, func(t string) { p.searchCondition.Author = t })
 	p.title = f.AddInputField("Title", config.Instance().GetDefaultTitle(), 40, nil, func(t string) { p.searchCondition.Title = t })
+	p.collection = f.AddInputField("Collection", "", 40, nil, func(t string) { p.searchCondition.Collection = strings.TrimSpace(t) })
+	p.query = f.AddInputField("Query", "", 40, nil, func(t string) { p.searchCondition.Query = t })
 
-	p.searchButton = f.AddButton("Search", p.runSearch)
+	p.searchButton = f.AddButton("Search", p.newSearch)
 	p.clearButton = f.AddButton("Clear", p.clearEverything)
+	p.upButton = f.AddButton("Up", p.collectionUp)
+	p.refreshButton = f.AddButton("Refresh", p.refreshSearch)
 	f.SetButtonsAlign(tview.AlignRight)
 	p.searchSection.AddItem(f, 0, 0, 1, 1, 0, 0, true)
 	f = newForm()
 	p.SortBy = f.AddDropdown("Sort by:", utils.AddSpaces(config.Instance().GetSortByOptions()), utils.GetIndex(config.Instance().GetSortByOptions(), config.Instance().GetSortBy()), func(o string, i int) { p.searchCondition.SortBy = p.mapSortBy(o) })
 	p.sortOrder = f.AddDropdown("Sort order:", utils.AddSpaces(config.Instance().GetSortOrderOptions()), utils.GetIndex(config.Instance().GetSortOrderOptions(), config.Instance().GetSortOrder()), func(o string, i int) { p.searchCondition.SortOrder = p.mapSortOrder(o) })
+	p.subject = f.AddInputField("Subject:", "", 20, nil, func(t string) { p.searchCondition.Subject = t })
+	p.language = f.AddInputField("Language:", "", 20, nil, func(t string) { p.searchCondition.Language = t })
 	p.searchSection.AddItem(f, 0, 1, 1, 1, 0, 0, true)
+	f = newForm()
+	p.yearFrom = f.AddInputField("Year from:", "", 5, acceptInt, func(t string) { p.searchCondition.YearFrom = utils.ToInt(t) })
+	p.yearTo = f.AddInputField("Year to:", "", 5, acceptInt, func(t string) { p.searchCondition.YearTo = utils.ToInt(t) })
+	p.minRuntime = f.AddInputField("Min runtime (min):", "", 5, acceptInt, func(t string) { p.searchCondition.MinRuntime = utils.ToInt(t) })
+	p.maxRuntime = f.AddInputField("Max runtime (min):", "", 5, acceptInt, func(t string) { p.searchCondition.MaxRuntime = utils.ToInt(t) })
+	p.searchSection.AddItem(f, 0, 2, 1, 1, 0, 0, true)
 	g := newGrid()
 	g.SetRows(-1, -1)
 	g.SetColumns(0)
@@ -84,8 +114,11 @@ func newSearchPage(dispatcher *mq.Dispatcher) *SearchPage {
 	f.SetHorizontal(false)
 	f.SetButtonsAlign(tview.AlignRight)
 	g.AddItem(f, 1, 0, 1, 1, 1, 1, true)
+	p.mergeButton = f.AddButton("Merge Items", p.mergeBooks)
+	p.watchesButton = f.AddButton("Watches", p.showWatches)
+	p.historyButton = f.AddButton("History", p.showHistory)
 	p.SettingsButton = f.AddButton("Settings", p.updateConfig)
-	p.searchSection.AddItem(g, 0, 2, 1, 1, 0, 0, true)
+	p.searchSection.AddItem(g, 0, 3, 1, 1, 0, 0, true)
 
 	p.mainGrid.AddItem(p.searchSection.Grid, 0, 0, 1, 1, 0, 0, true)
 
@@ -104,6 +137,16 @@ func newSearchPage(dispatcher *mq.Dispatcher) *SearchPage {
 	p.resultTable.SetSelectedFunc(p.itemSelected)
 	p.resultTable.SetMouseDblClickFunc(p.itemSelected)
 	p.resultTable.setLastRowEvent(p.lastRowEvent)
+	// Space marks the items to merge into one audiobook
+	tableInputCapture := p.resultTable.GetInputCapture()
+	p.resultTable.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyRune && event.Rune() == ' ' {
+			row, _ := p.resultTable.GetSelection()
+			p.toggleMergeItem(row)
+			return nil
+		}
+		return tableInputCapture(event)
+	})
 	p.resultSection.AddItem(p.resultTable.Table, 0, 0, 1, 1, 0, 0, true)
 	p.mainGrid.AddItem(p.resultSection.Grid, 1, 0, 1, 1, 0, 0, true)
 
@@ -157,11 +200,24 @@ func newSearchPage(dispatcher *mq.Dispatcher) *SearchPage {
 	p.mainGrid.SetNavigationOrder(
 		p.author,
 		p.title,
+		p.collection,
+		p.query,
 		p.searchButton,
 		p.clearButton,
+		p.upButton,
+		p.refreshButton,
 		p.SortBy,
 		p.sortOrder,
+		p.subject,
+		p.language,
+		p.yearFrom,
+		p.yearTo,
+		p.minRuntime,
+		p.maxRuntime,
 		p.createAudioBookButton,
+		p.mergeButton,
+		p.watchesButton,
+		p.historyButton,
 		p.SettingsButton,
 		p.resultTable.Table,
 		p.descriptionView,
@@ -187,7 +243,7 @@ func (p *SearchPage) dispatchMessage(m *mq.Message) {
 	case *dto.SearchProgress:
 		p.updateTitle(dto)
 	case *dto.SearchComplete:
-		p.isSearchRunning = false
+		p.searchComplete()
 	case *dto.NothingFoundError:
 		p.showNothingFoundError(dto)
 	case *dto.LastPageMessage:
@@ -196,25 +252,50 @@ func (p *SearchPage) dispatchMessage(m *mq.Message) {
 		p.showNewVersionMessage(dto)
 	case *dto.FFMPEGNotFoundError:
 		p.showFFMPEGNotFoundError(dto)
+	case *dto.WatchList:
+		p.updateWatches(dto)
+	case *dto.WatchesChecked:
+		p.watchesChecked(dto)
+	case *dto.OpenHistoryEntryCommand:
+		p.openHistoryEntry(dto)
 	default:
 		m.UnsupportedTypeError(mq.SearchPage)
 	}
 }
 
+// search started by the user. Forget the collection navigation history
+func (p *SearchPage) newSearch() {
+	if p.isSearchRunning {
+		return
+	}
+	p.breadcrumb = nil
+	p.runSearch()
+}
+
 func (p *SearchPage) runSearch() {
+	p.startSearch(false)
+}
+
+// repeat the search ignoring the cached IA responses
+func (p *SearchPage) refreshSearch() {
+	p.startSearch(true)
+}
+
+func (p *SearchPage) startSearch(forceRefresh bool) {
 	if p.isSearchRunning {
 		return
 	}
 	p.isSearchRunning = true
 	p.clearSearchResults()
 	p.resultTable.showHeader()
-	p.mq.SendMessage(mq.SearchPage, mq.SearchController, &dto.SearchCommand{Condition: p.searchCondition}, false)
+	p.mq.SendMessage(mq.SearchPage, mq.SearchController, &dto.SearchCommand{Condition: p.searchCondition, ForceRefresh: forceRefresh}, false)
 	p.mq.SendMessage(mq.SearchPage, mq.TUI, &dto.SetFocusCommand{Primitive: p.resultTable.Table}, true)
 }
 
 func (p *SearchPage) clearSearchResults() {
 	p.searchResult = make([]*dto.IAItem, 0)
-	p.resultSection.SetTitle(" Search result: ")
+	p.mergeItems = nil
+	p.resultSection.SetTitle(" " + p.breadcrumbText() + ": ")
 	p.resultTable.Clear()
 	p.descriptionView.SetText("")
 	p.filesTable.Clear()
@@ -223,6 +304,15 @@ func (p *SearchPage) clearSearchResults() {
 func (p *SearchPage) clearEverything() {
 	p.author.SetText("")
 	p.title.SetText("")
+	p.collection.SetText("")
+	p.query.SetText("")
+	p.subject.SetText("")
+	p.language.SetText("")
+	p.yearFrom.SetText("")
+	p.yearTo.SetText("")
+	p.minRuntime.SetText("")
+	p.maxRuntime.SetText("")
+	p.breadcrumb = nil
 	p.clearSearchResults()
 	p.urlField.SetText("")
 }
@@ -249,13 +339,21 @@ func (p *SearchPage) updateResult(i *dto.IAItem) {
 	logger.Debug(mq.SearchPage + ": Got AI Item: " + i.Title)
 	p.searchResult = append(p.searchResult, i)
 	row, col := p.resultTable.GetSelection()
-	p.resultTable.appendRow(strconv.Itoa(p.resultTable.GetRowCount()), i.Creator, i.Title, strconv.Itoa(len(i.AudioFiles)), utils.SecondsToTime(i.TotalLength), utils.BytesToHuman(i.TotalSize))
+	if i.Collection {
+		p.resultTable.appendRow(strconv.Itoa(p.resultTable.GetRowCount()), i.Creator, "[yellow]"+i.Title+" (collection)", "", "", "")
+	} else if i.Restricted {
+		p.resultTable.appendRow(strconv.Itoa(p.resultTable.GetRowCount()), i.Creator, "[red]"+i.Title+" (restricted)", strconv.Itoa(len(i.AudioFiles)), utils.SecondsToTime(i.TotalLength), utils.BytesToHuman(i.TotalSize))
+	} else if i.Built {
+		p.resultTable.appendRow(strconv.Itoa(p.resultTable.GetRowCount()), i.Creator, "[green]"+i.Title+" (already built)", strconv.Itoa(len(i.AudioFiles)), utils.SecondsToTime(i.TotalLength), utils.BytesToHuman(i.TotalSize))
+	} else {
+		p.resultTable.appendRow(strconv.Itoa(p.resultTable.GetRowCount()), i.Creator, i.Title, strconv.Itoa(len(i.AudioFiles)), utils.SecondsToTime(i.TotalLength), utils.BytesToHuman(i.TotalSize))
+	}
 	p.resultTable.Select(row, col)
 	ui.Draw()
 }
 
 func (p *SearchPage) updateTitle(sp *dto.SearchProgress) {
-	p.resultSection.SetTitle(fmt.Sprintf(" Search result (fetched %d items from %d total): ", sp.ItemsFetched, sp.ItemsTotal))
+	p.resultSection.SetTitle(fmt.Sprintf(" %s (fetched %d items from %d total): ", p.breadcrumbText(), sp.ItemsFetched, sp.ItemsTotal))
 }
 
 func (p *SearchPage) updateDetails(row int, col int) {
@@ -273,17 +371,80 @@ func (p *SearchPage) updateDetails(row int, col int) {
 		}
 		p.filesTable.ScrollToBeginning()
 
-		p.urlField.SetText(" " + item.IaURL)
+		if item.Collection {
+			p.urlField.SetText(" " + item.IaURL + "  (press Enter to open the collection)")
+		} else if item.Restricted {
+			p.urlField.SetText(" " + item.IaURL + "  (access restricted, Internet Archive login required)")
+		} else {
+			p.urlField.SetText(" " + item.IaURL)
+		}
 	}
 }
 
 // process Enter and DoubleClick on the result table
 func (p *SearchPage) itemSelected(row int, col int) {
 	if row > 0 && len(p.searchResult) > 0 && row <= len(p.searchResult) {
-		p.createBook()
+		if p.searchResult[row-1].Collection {
+			p.openCollection(p.searchResult[row-1])
+		} else {
+			p.createBook()
+		}
 	}
 }
 
+// list the members of the collection and remember where we came from
+func (p *SearchPage) openCollection(item *dto.IAItem) {
+	if p.isSearchRunning {
+		return
+	}
+	p.breadcrumb = append(p.breadcrumb, p.searchCondition)
+	p.setSearchCondition(dto.SearchCondition{Collection: item.ID, SortBy: p.searchCondition.SortBy, SortOrder: p.searchCondition.SortOrder})
+	p.runSearch()
+}
+
+// go back to the parent collection or to the search result the collection was opened from
+func (p *SearchPage) collectionUp() {
+	if p.isSearchRunning || len(p.breadcrumb) == 0 {
+		return
+	}
+	condition := p.breadcrumb[len(p.breadcrumb)-1]
+	p.breadcrumb = p.breadcrumb[:len(p.breadcrumb)-1]
+	p.setSearchCondition(condition)
+	p.runSearch()
+}
+
+func (p *SearchPage) setSearchCondition(condition dto.SearchCondition) {
+	p.author.SetText(condition.Author)
+	p.title.SetText(condition.Title)
+	p.collection.SetText(condition.Collection)
+	p.query.SetText(condition.Query)
+	p.subject.SetText(condition.Subject)
+	p.language.SetText(condition.Language)
+	p.yearFrom.SetText(intToText(condition.YearFrom))
+	p.yearTo.SetText(intToText(condition.YearTo))
+	p.minRuntime.SetText(intToText(condition.MinRuntime))
+	p.maxRuntime.SetText(intToText(condition.MaxRuntime))
+	p.searchCondition = condition
+}
+
+// Search > oldtimeradio > otr_xyz
+func (p *SearchPage) breadcrumbText() string {
+	path := []string{}
+	for _, c := range p.breadcrumb {
+		if c.Collection != "" {
+			path = append(path, c.Collection)
+		} else {
+			path = append(path, "Search result")
+		}
+	}
+	if p.searchCondition.Collection != "" {
+		path = append(path, p.searchCondition.Collection)
+	} else {
+		path = append(path, "Search result")
+	}
+	return strings.Join(path, " > ")
+}
+
 func (p *SearchPage) createBook() {
 	// get selectet row from the results table
 	row, _ := p.resultTable.GetSelection()
@@ -291,6 +452,10 @@ func (p *SearchPage) createBook() {
 		newMessageDialog(p.mq, "Error", "\nPlease conduct a search beforehand.", p.searchSection.Grid, func() {})
 	} else if !(utils.CommandExists("ffmpeg") && utils.CommandExists("ffprobe")) {
 		p.showFFMPEGNotFoundError(&dto.FFMPEGNotFoundError{})
+	} else if p.searchResult[row-1].Collection {
+		p.openCollection(p.searchResult[row-1])
+	} else if p.searchResult[row-1].Restricted && !config.Instance().HasIaCredentials() {
+		newMessageDialog(p.mq, "Error", "\nThe item is access restricted. Please set your Internet Archive access keys or login cookie in the Settings.", p.resultSection.Grid, func() {})
 	} else {
 		item := p.searchResult[row-1]
 		// create new audiobook object
@@ -298,27 +463,120 @@ func (p *SearchPage) createBook() {
 		ab.IAItem = item
 		c := config.Instance().GetCopy()
 		ab.Config = &c
+		selected := make([]bool, len(item.AudioFiles))
+		for i := range selected {
+			selected[i] = true
+		}
+		p.createBookDialog(ab, item, selected)
+	}
+}
 
-		d := newDialogWindow(p.mq, 17, 55, p.resultSection.Grid)
-		f := newForm()
-		f.SetTitle("Create Audiobook")
-		f.AddInputField("Concurrent Downloaders:", utils.ToString(ab.Config.GetConcurrentDownloaders()), 8, acceptInt, func(t string) { ab.Config.SetConcurrentDownloaders(utils.ToInt(t)) })
-		f.AddInputField("Concurrent Encoders:", utils.ToString(ab.Config.GetConcurrentEncoders()), 8, acceptInt, func(t string) { ab.Config.SetConcurrentEncoders(utils.ToInt(t)) })
-		f.AddCheckbox("Re-encode .mp3 files to the same Bit Rate?", ab.Config.IsReEncodeFiles(), func(t bool) { ab.Config.SetReEncodeFiles(t) })
-		f.AddInputField("Bit Rate (Kbps):", utils.ToString(ab.Config.GetBitRate()), 8, acceptInt, func(t string) { ab.Config.SetBitRate(utils.ToInt(t)) })
-		f.AddInputField("Sample Rate (Hz):", utils.ToString(ab.Config.GetSampleRate()), 8, acceptInt, func(t string) { ab.Config.SetSampleRate(utils.ToInt(t)) })
-		f.AddInputField("Audiobook part max file size (Mb):", utils.ToString(ab.Config.GetMaxFileSizeMb()), 8, acceptInt, func(t string) { ab.Config.SetMaxFileSizeMb(utils.ToInt(t)) })
-
-		f.AddButton("Create Audiobook", func() {
-			p.startDownload(ab)
-			d.Close()
-		})
-		f.AddButton("Cancel", func() {
-			d.Close()
+// mark or unmark the item to merge. Collections can't be merged
+func (p *SearchPage) toggleMergeItem(row int) {
+	if row <= 0 || row > len(p.searchResult) || p.searchResult[row-1].Collection {
+		return
+	}
+	item := p.searchResult[row-1]
+	marked := false
+	for i, m := range p.mergeItems {
+		if m == item {
+			p.mergeItems = append(p.mergeItems[:i], p.mergeItems[i+1:]...)
+			marked = true
+			break
+		}
+	}
+	if !marked {
+		p.mergeItems = append(p.mergeItems, item)
+	}
+	number := strconv.Itoa(row)
+	if !marked {
+		number = "+" + number
+	}
+	p.resultTable.GetCell(row, 0).SetText(number)
+	if len(p.mergeItems) > 0 {
+		p.urlField.SetText(fmt.Sprintf(" %d item(s) marked to merge (Space - mark/unmark an item)", len(p.mergeItems)))
+	}
+}
+
+// create one audiobook from several items (Part 1, Part 2, ... of a long serial for ex.)
+func (p *SearchPage) mergeBooks() {
+	if len(p.mergeItems) < 2 {
+		newMessageDialog(p.mq, "Error", "\nPlease mark at least two items to merge in the search result by pressing Space.", p.resultSection.Grid, func() {})
+		return
+	}
+	if !(utils.CommandExists("ffmpeg") && utils.CommandExists("ffprobe")) {
+		p.showFFMPEGNotFoundError(&dto.FFMPEGNotFoundError{})
+		return
+	}
+	newMergeDialog(p.mq, p.mergeItems, p.resultSection.Grid, func(items []*dto.IAItem) {
+		item := dto.MergeIAItems(items)
+		if item.Restricted && !config.Instance().HasIaCredentials() {
+			newMessageDialog(p.mq, "Error", "\nSome of the items are access restricted. Please set your Internet Archive access keys or login cookie in the Settings.", p.resultSection.Grid, func() {})
+			return
+		}
+		ab := &dto.Audiobook{}
+		ab.IAItem = item
+		ab.IAItems = items
+		c := config.Instance().GetCopy()
+		ab.Config = &c
+		selected := make([]bool, len(item.AudioFiles))
+		for i := range selected {
+			selected[i] = true
+		}
+		p.createBookDialog(ab, item, selected)
+	})
+}
+
+func (p *SearchPage) createBookDialog(ab *dto.Audiobook, item *dto.IAItem, selected []bool) {
+	d := newDialogWindow(p.mq, 28, 60, p.resultSection.Grid)
+	f := newForm()
+	f.SetTitle(fmt.Sprintf("Create Audiobook (%d of %d files, %s)", len(ab.IAItem.AudioFiles), len(item.AudioFiles), utils.BytesToHuman(ab.IAItem.TotalSize)))
+	f.AddInputField("Concurrent Downloaders:", utils.ToString(ab.Config.GetConcurrentDownloaders()), 8, acceptInt, func(t string) { ab.Config.SetConcurrentDownloaders(utils.ToInt(t)) })
+	f.AddInputField("Download speed limit (KB/s, 0 - none):", utils.ToString(ab.Config.GetDownloadLimitKbs()), 8, acceptInt, func(t string) { ab.Config.SetDownloadLimitKbs(utils.ToInt(t)) })
+	f.AddInputField("Concurrent Encoders:", utils.ToString(ab.Config.GetConcurrentEncoders()), 8, acceptInt, func(t string) { ab.Config.SetConcurrentEncoders(utils.ToInt(t)) })
+	f.AddCheckbox("Re-encode audio files to the same Bit Rate?", ab.Config.IsReEncodeFiles(), func(t bool) { ab.Config.SetReEncodeFiles(t) })
+	f.AddInputField("Bit Rate (Kbps):", utils.ToString(ab.Config.GetBitRate()), 8, acceptInt, func(t string) { ab.Config.SetBitRate(utils.ToInt(t)) })
+	f.AddInputField("Sample Rate (Hz):", utils.ToString(ab.Config.GetSampleRate()), 8, acceptInt, func(t string) { ab.Config.SetSampleRate(utils.ToInt(t)) })
+	f.AddCheckbox("Normalize loudness (EBU R128)?", ab.Config.IsNormalizeLoudness(), func(t bool) { ab.Config.SetNormalizeLoudness(t) })
+	f.AddInputField("Target loudness (LUFS):", utils.ToString(ab.Config.GetTargetLoudness()), 8, acceptFloat, func(t string) { ab.Config.SetTargetLoudness(utils.ToFloat(t)) })
+	f.AddInputField("Target true peak (dBTP):", utils.ToString(ab.Config.GetTargetTruePeak()), 8, acceptFloat, func(t string) { ab.Config.SetTargetTruePeak(utils.ToFloat(t)) })
+	f.AddCheckbox("Trim leading and trailing silence?", ab.Config.IsTrimSilence(), func(t bool) { ab.Config.SetTrimSilence(t) })
+	f.AddInputField("Silence threshold (dB):", utils.ToString(ab.Config.GetSilenceThreshold()), 8, acceptFloat, func(t string) { ab.Config.SetSilenceThreshold(utils.ToFloat(t)) })
+	f.AddInputField("Silence min duration (sec):", utils.ToString(ab.Config.GetSilenceMinDuration()), 8, acceptFloat, func(t string) { ab.Config.SetSilenceMinDuration(utils.ToFloat(t)) })
+	f.AddInputField("Max silence trim per file end (sec):", utils.ToString(ab.Config.GetSilenceMaxTrim()), 8, acceptFloat, func(t string) { ab.Config.SetSilenceMaxTrim(utils.ToFloat(t)) })
+	f.AddInputField("Audiobook part max file size (Mb):", utils.ToString(ab.Config.GetMaxFileSizeMb()), 8, acceptInt, func(t string) { ab.Config.SetMaxFileSizeMb(utils.ToInt(t)) })
+	f.AddDropdown("Files order:", utils.AddSpaces(ab.Config.GetFileOrderOptions()), utils.GetIndex(ab.Config.GetFileOrderOptions(), ab.Config.GetFileOrder()), func(o string, i int) { ab.Config.SetFileOrder(strings.TrimSpace(o)) })
+	f.AddDropdown("Output format:", utils.AddSpaces(ab.Config.GetOutputFormatOptions()), utils.GetIndex(ab.Config.GetOutputFormatOptions(), ab.Config.GetOutputFormat()), func(o string, i int) { ab.Config.SetOutputFormat(strings.TrimSpace(o)) })
+
+	f.AddButton("Create Audiobook", func() {
+		p.startDownload(ab)
+		d.Close()
+	})
+	f.AddButton("Select Files", func() {
+		d.Close()
+		newFilePicker(p.mq, item.AudioFiles, selected, p.resultSection.Grid, func(s []bool) {
+			ab.IAItem = itemWithFiles(item, s)
+			p.createBookDialog(ab, item, s)
 		})
-		d.setForm(f.Form)
-		d.Show()
+	})
+	f.AddButton("Cancel", func() {
+		d.Close()
+	})
+	d.setForm(f.Form)
+	d.Show()
+}
+
+// A copy of the item with the selected audio files only. The size and duration estimates are recalculated
+func itemWithFiles(item *dto.IAItem, selected []bool) *dto.IAItem {
+	i := *item
+	i.AudioFiles = selectedFiles(item.AudioFiles, selected)
+	i.TotalSize = 0
+	i.TotalLength = 0
+	for _, f := range i.AudioFiles {
+		i.TotalSize += f.Size
+		i.TotalLength += f.Length
 	}
+	return &i
 }
 
 func (p *SearchPage) startDownload(ab *dto.Audiobook) {
@@ -326,6 +584,112 @@ func (p *SearchPage) startDownload(ab *dto.Audiobook) {
 	p.mq.SendMessage(mq.SearchPage, mq.Frame, &dto.SwitchToPageCommand{Name: "DownloadPage"}, false)
 }
 
+func (p *SearchPage) showWatches() {
+	p.watchDialog = newWatchDialog(p.mq, p.searchSection.Grid, p.addWatch, p.showWatchItems)
+}
+
+// save the current search as a watch
+func (p *SearchPage) addWatch() {
+	p.watchDialog = nil
+	newWatchEditDialog(p.mq, p.searchCondition, p.searchSection.Grid, p.showWatches)
+}
+
+// search for the new items of the watch so the user can build them
+func (p *SearchPage) showWatchItems(w dto.Watch) {
+	p.watchDialog = nil
+	ids := []string{}
+	for _, item := range w.NewItems {
+		ids = append(ids, item.ID)
+	}
+	p.searchItems(ids)
+	p.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.ClearWatchItemsCommand{Name: w.Name, IDs: ids}, true)
+}
+
+// search for the items by the identifiers
+func (p *SearchPage) searchItems(ids []string) {
+	p.clearEverything()
+	p.setSearchCondition(dto.SearchCondition{Query: "identifier:(" + strings.Join(ids, " OR ") + ")", SortBy: p.searchCondition.SortBy, SortOrder: p.searchCondition.SortOrder})
+	p.newSearch()
+}
+
+func (p *SearchPage) showHistory() {
+	p.mq.SendMessage(mq.SearchPage, mq.HistoryController, &dto.GetHistoryCommand{}, true)
+	p.mq.SendMessage(mq.SearchPage, mq.Frame, &dto.SwitchToPageCommand{Name: "HistoryPage"}, false)
+}
+
+func (p *SearchPage) openHistoryEntry(c *dto.OpenHistoryEntryCommand) {
+	if p.isSearchRunning {
+		return
+	}
+	p.rebuildEntry = nil
+	if c.Rebuild {
+		p.rebuildEntry = &c.Entry
+	}
+	p.searchItems(c.Entry.ItemIDs)
+}
+
+func (p *SearchPage) searchComplete() {
+	p.isSearchRunning = false
+	if p.rebuildEntry != nil {
+		e := p.rebuildEntry
+		p.rebuildEntry = nil
+		p.rebuild(e)
+	}
+}
+
+// open the Create Audiobook dialog for the history entry items with the settings the audiobook was built with
+func (p *SearchPage) rebuild(e *dto.HistoryEntry) {
+	items := []*dto.IAItem{}
+	for _, id := range e.ItemIDs {
+		for _, item := range p.searchResult {
+			if item.ID == id {
+				items = append(items, item)
+				break
+			}
+		}
+	}
+	if len(items) == 0 || len(items) != len(e.ItemIDs) {
+		newMessageDialog(p.mq, "Error", "\nSome of the audiobook items are not available anymore: [darkblue]"+strings.Join(e.ItemIDs, ", ")+"[black]", p.resultSection.Grid, func() {})
+		return
+	}
+	if !(utils.CommandExists("ffmpeg") && utils.CommandExists("ffprobe")) {
+		p.showFFMPEGNotFoundError(&dto.FFMPEGNotFoundError{})
+		return
+	}
+	ab := &dto.Audiobook{}
+	if len(items) > 1 {
+		ab.IAItem = dto.MergeIAItems(items)
+		ab.IAItems = items
+	} else {
+		ab.IAItem = items[0]
+	}
+	if ab.IAItem.Restricted && !config.Instance().HasIaCredentials() {
+		newMessageDialog(p.mq, "Error", "\nThe item is access restricted. Please set your Internet Archive access keys or login cookie in the Settings.", p.resultSection.Grid, func() {})
+		return
+	}
+	c := config.Instance().GetCopy()
+	e.Settings.Apply(&c)
+	ab.Config = &c
+	selected := make([]bool, len(ab.IAItem.AudioFiles))
+	for i := range selected {
+		selected[i] = true
+	}
+	p.createBookDialog(ab, ab.IAItem, selected)
+}
+
+func (p *SearchPage) updateWatches(l *dto.WatchList) {
+	if p.watchDialog != nil {
+		p.watchDialog.updateWatches(l.Watches)
+	}
+}
+
+// the result of the check on demand or of a scheduled one. The footer shows the number of new items
+func (p *SearchPage) watchesChecked(c *dto.WatchesChecked) {
+	if p.watchDialog != nil {
+		p.watchDialog.watchesChecked(c)
+	}
+}
+
 func (p *SearchPage) updateConfig() {
 	p.mq.SendMessage(mq.SearchPage, mq.ConfigPage, &dto.DisplayConfigCommand{Config: config.Instance().GetCopy()}, true)
 	p.mq.SendMessage(mq.SearchPage, mq.Frame, &dto.SwitchToPageCommand{Name: "ConfigPage"}, false)
@@ -334,11 +698,26 @@ func (p *SearchPage) updateConfig() {
 func (p *SearchPage) showNothingFoundError(dto *dto.NothingFoundError) {
 	newMessageDialog(p.mq, "Error",
 		"\nNo results were found for your search term:\n"+
-			"Creator: [darkblue]'"+dto.Condition.Author+"'[black] Title: [darkblue]'"+dto.Condition.Title+"'[black].\n"+
+			"Creator: [darkblue]'"+dto.Condition.Author+"'[black] Title: [darkblue]'"+dto.Condition.Title+"'[black]"+collectionText(dto.Condition)+".\n"+
 			"Please revise your search criteria.",
 		p.searchSection.Grid, func() {})
 }
 
+// empty string for zero (not set) values
+func intToText(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return strconv.Itoa(i)
+}
+
+func collectionText(condition dto.SearchCondition) string {
+	if condition.Collection == "" {
+		return ""
+	}
+	return " Collection: [darkblue]'" + condition.Collection + "'[black]"
+}
+
 func (p *SearchPage) showLastPageMessage(dto *dto.LastPageMessage) {
 	newMessageDialog(p.mq, "Notification",
 		"No more items were found for your search term: \n"+
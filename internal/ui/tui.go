@@ -46,6 +46,7 @@ func NewTUI(dispatcher *mq.Dispatcher) *TUI {
 	encodingPage := newEncodingPage(dispatcher)
 	chaptersPage := newChaptersPage(dispatcher)
 	buildPage := newBuildPage(dispatcher)
+	historyPage := newHistoryPage(dispatcher)
 
 	// UI main frame
 	frame := newFrame(dispatcher)
@@ -57,6 +58,7 @@ func NewTUI(dispatcher *mq.Dispatcher) *TUI {
 	frame.addPage("EncodingPage", encodingPage.mainGrid.Grid)
 	frame.addPage("ChaptersPage", chaptersPage.mainGrid.Grid)
 	frame.addPage("BuildPage", buildPage.mainGrid.Grid)
+	frame.addPage("HistoryPage", historyPage.mainGrid.Grid)
 
 	ui.components = append(ui.components, frame)
 	ui.components = append(ui.components, header)
@@ -67,6 +69,7 @@ func NewTUI(dispatcher *mq.Dispatcher) *TUI {
 	ui.components = append(ui.components, encodingPage)
 	ui.components = append(ui.components, chaptersPage)
 	ui.components = append(ui.components, buildPage)
+	ui.components = append(ui.components, historyPage)
 
 	frame.switchToPage("SearchPage")
 
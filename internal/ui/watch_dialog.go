@@ -0,0 +1,239 @@
+package ui
+
+import (
+	"strconv"
+	"strings"
+
+	"abb_ia/internal/config"
+	"abb_ia/internal/dto"
+	"abb_ia/internal/mq"
+	"abb_ia/internal/utils"
+
+	"github.com/vpoluyaktov/tview"
+)
+
+type WatchItemsFunc func(w dto.Watch)
+
+/**
+ * Dialog to manage the watches - the saved searches checked for new uploads (an uploader or a collection followed by the user).
+ * The new items are checked on demand or every WatchIntervalHours. "abb_ia watch" builds the new items of the auto build watches
+ **/
+type watchDialog struct {
+	mq        *mq.Dispatcher
+	d         *dialogWindow
+	watches   []dto.Watch
+	table     *table
+	itemsView *tview.TextView
+	message   *tview.TextView
+}
+
+func newWatchDialog(dispatcher *mq.Dispatcher, focus tview.Primitive, addFunc OkFunc, showFunc WatchItemsFunc) *watchDialog {
+	p := &watchDialog{}
+	p.mq = dispatcher
+
+	p.d = newDialogWindow(dispatcher, 30, 120, focus)
+	layout := newGrid()
+	layout.SetRows(-1, 8, 1, 3)
+	layout.SetColumns(0)
+
+	p.table = newTable()
+	p.table.SetBorder(true)
+	p.table.SetTitle(" Watches: ")
+	p.table.SetTitleAlign(tview.AlignLeft)
+	p.table.setHeaders("Name", "Search", "Auto build", "Last check", "New")
+	p.table.setWeights(3, 6, 1, 2, 1)
+	p.table.setAlign(tview.AlignLeft, tview.AlignLeft, tview.AlignCenter, tview.AlignCenter, tview.AlignRight)
+	p.table.SetSelectionChangedFunc(func(row int, col int) { p.showNewItems(row) })
+	layout.AddItem(p.table.Table, 0, 0, 1, 1, 0, 0, true)
+
+	p.itemsView = tview.NewTextView()
+	p.itemsView.SetBorder(true)
+	p.itemsView.SetTitle(" New items: ")
+	p.itemsView.SetTitleAlign(tview.AlignLeft)
+	layout.AddItem(p.itemsView, 1, 0, 1, 1, 0, 0, false)
+
+	p.message = tview.NewTextView()
+	p.message.SetDynamicColors(true)
+	p.message.SetTextColor(black)
+	p.message.SetBackgroundColor(gray)
+	layout.AddItem(p.message, 2, 0, 1, 1, 0, 0, false)
+
+	f := newForm()
+	f.SetTitle("Watches")
+	addButton := f.AddButton("Watch This Search", func() {
+		p.d.Close()
+		addFunc()
+	})
+	checkButton := f.AddButton("Check", func() {
+		if w := p.selectedWatch(); w != nil {
+			p.check(w.Name)
+		}
+	})
+	checkAllButton := f.AddButton("Check All", func() { p.check("") })
+	showButton := f.AddButton("Show New", func() {
+		w := p.selectedWatch()
+		if w == nil || len(w.NewItems) == 0 {
+			p.showMessage("There are no new items in the selected watch")
+			return
+		}
+		p.d.Close()
+		showFunc(*w)
+	})
+	deleteButton := f.AddButton("Delete", func() {
+		if w := p.selectedWatch(); w != nil {
+			p.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.DeleteWatchCommand{Name: w.Name}, true)
+		}
+	})
+	closeButton := f.AddButton("Close", func() {
+		p.d.Close()
+	})
+	layout.AddItem(f.Form, 3, 0, 1, 1, 0, 0, false)
+	layout.SetNavigationOrder(p.table.Table, addButton, checkButton, checkAllButton, showButton, deleteButton, closeButton)
+
+	p.d.setLayout(layout, f.Form)
+	f.SetHorizontal(true)
+	p.showMessage("Loading the watches...")
+	p.d.Show()
+	ui.SetFocus(p.table.Table)
+	p.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.GetWatchesCommand{}, true)
+	return p
+}
+
+func (p *watchDialog) check(name string) {
+	p.showMessage("Checking for new items...")
+	p.mq.SendMessage(mq.SearchPage, mq.WatchController, &dto.CheckWatchesCommand{Name: name}, true)
+}
+
+func (p *watchDialog) updateWatches(watches []dto.Watch) {
+	row, _ := p.table.GetSelection()
+	p.watches = watches
+	p.table.Clear()
+	p.table.showHeader()
+	for _, w := range watches {
+		autoBuild := ""
+		if w.AutoBuild {
+			autoBuild = "yes"
+		}
+		lastCheck := "never"
+		if !w.LastCheck.IsZero() {
+			lastCheck = w.LastCheck.Format("2006-01-02 15:04")
+		}
+		p.table.appendRow(tview.Escape(w.Name), tview.Escape(conditionText(w.Condition)), autoBuild, lastCheck, strconv.Itoa(len(w.NewItems)))
+	}
+	if row > len(watches) {
+		row = len(watches)
+	}
+	if row < 1 {
+		row = 1
+	}
+	p.table.Select(row, 0)
+	p.showNewItems(row)
+	if len(watches) == 0 {
+		p.showMessage("No watches yet. Search for the uploader or the collection to follow and press \"Watch This Search\"")
+	} else {
+		p.showMessage("")
+	}
+	ui.Draw()
+}
+
+func (p *watchDialog) watchesChecked(c *dto.WatchesChecked) {
+	p.updateWatches(c.Watches)
+	p.showMessage("New items found: " + strconv.Itoa(c.NewItems))
+	ui.Draw()
+}
+
+func (p *watchDialog) selectedWatch() *dto.Watch {
+	row, _ := p.table.GetSelection()
+	if row <= 0 || row > len(p.watches) {
+		return nil
+	}
+	return &p.watches[row-1]
+}
+
+func (p *watchDialog) showNewItems(row int) {
+	text := ""
+	if row > 0 && row <= len(p.watches) {
+		for _, item := range p.watches[row-1].NewItems {
+			text += item.Found.Format("2006-01-02") + "  " + item.Creator + " - " + item.Title + " (" + item.ID + ")\n"
+		}
+	}
+	p.itemsView.SetText(text)
+	p.itemsView.ScrollToBeginning()
+}
+
+func (p *watchDialog) showMessage(message string) {
+	p.message.SetText(" " + message)
+}
+
+// Author: Relic Radio, Collection: oldtimeradio
+func conditionText(condition dto.SearchCondition) string {
+	parts := []string{}
+	add := func(name string, value string) {
+		if value != "" {
+			parts = append(parts, name+": "+value)
+		}
+	}
+	add("Creator", condition.Author)
+	add("Title", condition.Title)
+	add("Collection", condition.Collection)
+	add("Subject", condition.Subject)
+	add("Language", condition.Language)
+	add("Query", condition.Query)
+	add("Year from", intToText(condition.YearFrom))
+	add("Year to", intToText(condition.YearTo))
+	add("Min runtime", intToText(condition.MinRuntime))
+	add("Max runtime", intToText(condition.MaxRuntime))
+	return strings.Join(parts, ", ")
+}
+
+// Dialog to save the search as a watch with the build settings of the new items
+func newWatchEditDialog(dispatcher *mq.Dispatcher, condition dto.SearchCondition, focus tview.Primitive, closeFunc OkFunc) {
+	c := config.Instance().GetCopy()
+	w := dto.Watch{}
+	w.Name = condition.Author
+	if condition.Collection != "" {
+		w.Name = condition.Collection
+	} else if condition.Title != "" {
+		w.Name = strings.TrimSpace(condition.Author + " - " + condition.Title)
+	}
+	w.Condition = condition
+	w.Settings = dto.NewBuildSettings(&c)
+
+	d := newDialogWindow(dispatcher, 32, 70, focus)
+	f := newForm()
+	f.SetTitle("Watch This Search")
+	f.AddInputField("Watch name:", w.Name, 40, nil, func(t string) { w.Name = strings.TrimSpace(t) })
+	f.AddCheckbox("Build the new items automatically (abb_ia watch)?", w.AutoBuild, func(t bool) { w.AutoBuild = t })
+	f.AddCheckbox("Re-encode audio files to the same Bit Rate?", w.Settings.ReEncodeFiles, func(t bool) { w.Settings.ReEncodeFiles = t })
+	f.AddInputField("Bit Rate (Kbps):", utils.ToString(w.Settings.BitRateKbs), 8, acceptInt, func(t string) { w.Settings.BitRateKbs = utils.ToInt(t) })
+	f.AddInputField("Sample Rate (Hz):", utils.ToString(w.Settings.SampleRateHz), 8, acceptInt, func(t string) { w.Settings.SampleRateHz = utils.ToInt(t) })
+	f.AddCheckbox("Normalize loudness (EBU R128)?", w.Settings.NormalizeLoudness, func(t bool) { w.Settings.NormalizeLoudness = t })
+	f.AddInputField("Target loudness (LUFS):", utils.ToString(w.Settings.TargetLoudness), 8, acceptFloat, func(t string) { w.Settings.TargetLoudness = utils.ToFloat(t) })
+	f.AddInputField("Target true peak (dBTP):", utils.ToString(w.Settings.TargetTruePeak), 8, acceptFloat, func(t string) { w.Settings.TargetTruePeak = utils.ToFloat(t) })
+	f.AddCheckbox("Trim leading and trailing silence?", w.Settings.TrimSilence, func(t bool) { w.Settings.TrimSilence = t })
+	f.AddInputField("Silence threshold (dB):", utils.ToString(w.Settings.SilenceThresholdDb), 8, acceptFloat, func(t string) { w.Settings.SilenceThresholdDb = utils.ToFloat(t) })
+	f.AddInputField("Silence min duration (sec):", utils.ToString(w.Settings.SilenceMinDuration), 8, acceptFloat, func(t string) { w.Settings.SilenceMinDuration = utils.ToFloat(t) })
+	f.AddInputField("Max silence trim per file end (sec):", utils.ToString(w.Settings.SilenceMaxTrim), 8, acceptFloat, func(t string) { w.Settings.SilenceMaxTrim = utils.ToFloat(t) })
+	f.AddInputField("Audiobook part max file size (Mb):", utils.ToString(w.Settings.MaxFileSizeMb), 8, acceptInt, func(t string) { w.Settings.MaxFileSizeMb = utils.ToInt(t) })
+	f.AddDropdown("Files order:", utils.AddSpaces(c.GetFileOrderOptions()), utils.GetIndex(c.GetFileOrderOptions(), w.Settings.FileOrder), func(o string, i int) { w.Settings.FileOrder = strings.TrimSpace(o) })
+	f.AddDropdown("Output format:", utils.AddSpaces(c.GetOutputFormatOptions()), utils.GetIndex(c.GetOutputFormatOptions(), w.Settings.OutputFormat), func(o string, i int) { w.Settings.OutputFormat = strings.TrimSpace(o) })
+	f.AddCheckbox("Copy to output dir?", w.Settings.CopyToOutputDir, func(t bool) { w.Settings.CopyToOutputDir = t })
+	f.AddInputField("Output directory:", w.Settings.OutputDir, 30, nil, func(t string) { w.Settings.OutputDir = t })
+	f.AddCheckbox("Upload to Audiobookshelf?", w.Settings.UploadToAudiobookshelf, func(t bool) { w.Settings.UploadToAudiobookshelf = t })
+	f.AddCheckbox("Scan the Audiobookshelf library?", w.Settings.ScanAudiobookshelf, func(t bool) { w.Settings.ScanAudiobookshelf = t })
+
+	f.AddButton("Save", func() {
+		if w.Name == "" {
+			return
+		}
+		dispatcher.SendMessage(mq.SearchPage, mq.WatchController, &dto.SaveWatchCommand{Watch: w}, true)
+		d.Close()
+		closeFunc()
+	})
+	f.AddButton("Cancel", func() {
+		d.Close()
+		closeFunc()
+	})
+	d.setForm(f.Form)
+	d.Show()
+}
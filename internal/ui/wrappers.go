@@ -354,6 +354,15 @@ func acceptInt(textToCheck string, lastChar rune) bool {
 	return err == nil
 }
 
+// a signed decimal number. The minus sign alone is accepted to start typing a negative number
+func acceptFloat(textToCheck string, lastChar rune) bool {
+	if textToCheck == "-" {
+		return true
+	}
+	_, err := strconv.ParseFloat(textToCheck, 64)
+	return err == nil
+}
+
 func (f *form) AddButton(label string, selected func()) *tview.Button {
 	f.mu.Lock()
 	f.Form.AddButton(label, selected)
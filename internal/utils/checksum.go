@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+)
+
+// Verify a file against MD5, SHA1 and CRC32 checksums provided by Internet Archive.
+// Empty checksums are not checked. Returns an error if any of the checksums doesn't match
+func VerifyChecksum(filePath string, md5Sum string, sha1Sum string, crc32Sum string) error {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	md5Hash := md5.New()
+	sha1Hash := sha1.New()
+	crc32Hash := crc32.NewIEEE()
+	if _, err := io.Copy(io.MultiWriter(md5Hash, sha1Hash, crc32Hash), f); err != nil {
+		return err
+	}
+
+	checks := [][3]string{
+		{"md5", md5Sum, hex.EncodeToString(md5Hash.Sum(nil))},
+		{"sha1", sha1Sum, hex.EncodeToString(sha1Hash.Sum(nil))},
+		{"crc32", crc32Sum, hex.EncodeToString(crc32Hash.Sum(nil))},
+	}
+	for _, check := range checks {
+		name, expected, actual := check[0], strings.ToLower(strings.TrimSpace(check[1])), check[2]
+		if expected != "" && expected != actual {
+			return fmt.Errorf("%s checksum mismatch: expected %s, got %s", name, expected, actual)
+		}
+	}
+	return nil
+}
+
+// SHA1 checksum of a file (hex)
+func Sha1Sum(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
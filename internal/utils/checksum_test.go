@@ -0,0 +1,58 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyChecksum(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		md5     string
+		sha1    string
+		crc32   string
+		wantErr bool
+	}{
+		{"no checksums", "", "", "", false},
+		{"all valid", "5eb63bbbe01eeed093cb22bb8f5acdc3", "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", "0d4a1185", false},
+		{"upper case", "5EB63BBBE01EEED093CB22BB8F5ACDC3", "", "", false},
+		{"md5 only", "5eb63bbbe01eeed093cb22bb8f5acdc3", "", "", false},
+		{"wrong md5", "00000000000000000000000000000000", "", "", true},
+		{"wrong sha1", "", "0000000000000000000000000000000000000000", "", true},
+		{"wrong crc32", "", "", "00000000", true},
+		{"valid md5, wrong sha1", "5eb63bbbe01eeed093cb22bb8f5acdc3", "0000000000000000000000000000000000000000", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyChecksum(filePath, tt.md5, tt.sha1, tt.crc32)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("VerifyChecksum() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := VerifyChecksum(filepath.Join(t.TempDir(), "missing.mp3"), "", "", ""); err == nil {
+		t.Errorf("VerifyChecksum() expected error for missing file")
+	}
+}
+
+func TestSha1Sum(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.m4b")
+	if err := os.WriteFile(filePath, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := Sha1Sum(filePath)
+	if err != nil || sum != "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed" {
+		t.Errorf("Sha1Sum() = %s, %v", sum, err)
+	}
+	if _, err := Sha1Sum(filepath.Join(t.TempDir(), "missing.m4b")); err == nil {
+		t.Errorf("Sha1Sum() expected error for missing file")
+	}
+}
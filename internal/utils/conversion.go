@@ -14,6 +14,11 @@ func ToInt(s string) int {
 	return i
 }
 
+func ToFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
 // convert any type of number to a string and ignore an error
 func ToString(num interface{}) string {
 	switch num := num.(type) {
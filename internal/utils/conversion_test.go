@@ -52,6 +52,28 @@ func TestToInt(t *testing.T) {
 	}
 }
 
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want float64
+	}{
+		{"empty string", "", 0},
+		{"integer", "-16", -16},
+		{"negative float", "-1.5", -1.5},
+		{"minus sign only", "-", 0},
+		{"invalid", "abc", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ToFloat(tt.s); got != tt.want {
+				t.Errorf("ToFloat(%q) = %v, want %v", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestToString(t *testing.T) {
 	tests := []struct {
 		name string
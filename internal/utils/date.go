@@ -0,0 +1,89 @@
+package utils
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 1949-01-15, 49-01-15, 1949.01.15, 19490115
+var fileDateRe = regexp.MustCompile(`(?:^|[^0-9])((?:19|20)?[0-9]{2})[-_.]?([01][0-9])[-_.]?([0-3][0-9])(?:[^0-9]|$)`)
+
+// Find a date in a file name or title. Old-time radio episodes are usually named like Show_49-01-15_Title.mp3.
+// Two-digit years are 19xx (from 1920 on) or 20xx
+func FileNameDate(name string) (time.Time, bool) {
+	for _, m := range fileDateRe.FindAllStringSubmatch(name, -1) {
+		year, _ := strconv.Atoi(m[1])
+		if len(m[1]) == 2 {
+			if year >= 20 {
+				year += 1900
+			} else {
+				year += 2000
+			}
+		}
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		// reject 49-02-31 and the likes
+		if month >= 1 && month <= 12 && day >= 1 && date.Day() == day {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Parse yyyy, yyyy-mm or yyyy-mm-dd. The end of the period (last day of the year or month) is returned if end is true
+func ParsePartialDate(s string, end bool) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		date, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		if end {
+			switch layout {
+			case "2006":
+				date = date.AddDate(1, 0, -1)
+			case "2006-01":
+				date = date.AddDate(0, 1, -1)
+			}
+		}
+		return date, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid date: %s. Use yyyy, yyyy-mm or yyyy-mm-dd", s)
+}
+
+// Parse a daily time window: "22:00-07:00" -> 1320, 420 (minutes since midnight)
+func ParseTimeWindow(s string) (int, int, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("wrong time window %q, expected hh:mm-hh:mm", s)
+	}
+	f, err := parseClock(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	t, err := parseClock(to)
+	if err != nil {
+		return 0, 0, err
+	}
+	return f, t, nil
+}
+
+func parseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("wrong time %q, expected hh:mm", s)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// Check if the time (minutes since midnight) is in the window. The window may span midnight (22:00-07:00)
+func InTimeWindow(m int, from int, to int) bool {
+	if from <= to {
+		return m >= from && m < to
+	}
+	return m >= from || m < to
+}
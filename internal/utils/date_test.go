@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileNameDate(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   time.Time
+		wantOk bool
+	}{
+		{"Fake_Show_49-01-15_Episode_1.mp3", time.Date(1949, 1, 15, 0, 0, 0, 0, time.UTC), true},
+		{"Fake_Show_1949-01-15_Episode_1.mp3", time.Date(1949, 1, 15, 0, 0, 0, 0, time.UTC), true},
+		{"Fake Show 1952.11.03 Title", time.Date(1952, 11, 3, 0, 0, 0, 0, time.UTC), true},
+		{"fake_19520213.mp3", time.Date(1952, 2, 13, 0, 0, 0, 0, time.UTC), true},
+		{"show_05-03-21.mp3", time.Date(2005, 3, 21, 0, 0, 0, 0, time.UTC), true},
+		{"Episode_001_52-02-31.mp3", time.Time{}, false},
+		{"Episode_001.mp3", time.Time{}, false},
+		{"lecture_01.ogg", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FileNameDate(tt.name)
+			if ok != tt.wantOk || !got.Equal(tt.want) {
+				t.Errorf("FileNameDate() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestParsePartialDate(t *testing.T) {
+	tests := []struct {
+		date    string
+		end     bool
+		want    time.Time
+		wantErr bool
+	}{
+		{"1949", false, time.Date(1949, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{"1949", true, time.Date(1949, 12, 31, 0, 0, 0, 0, time.UTC), false},
+		{"1949-02", true, time.Date(1949, 2, 28, 0, 0, 0, 0, time.UTC), false},
+		{" 1949-02-10 ", true, time.Date(1949, 2, 10, 0, 0, 0, 0, time.UTC), false},
+		{"49-02-10", false, time.Time{}, true},
+		{"", false, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			got, err := ParsePartialDate(tt.date, tt.end)
+			if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+				t.Errorf("ParsePartialDate() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTimeWindow(t *testing.T) {
+	from, to, err := ParseTimeWindow("22:30-07:00")
+	if err != nil || from != 22*60+30 || to != 7*60 {
+		t.Fatalf("ParseTimeWindow() = %d, %d, %v", from, to, err)
+	}
+	tests := []struct {
+		m        int
+		from, to int
+		want     bool
+	}{
+		{23 * 60, from, to, true},
+		{6*60 + 59, from, to, true},
+		{7 * 60, from, to, false},
+		{12 * 60, from, to, false},
+		{12 * 60, 9 * 60, 17 * 60, true},
+		{18 * 60, 9 * 60, 17 * 60, false},
+	}
+	for _, tt := range tests {
+		if got := InTimeWindow(tt.m, tt.from, tt.to); got != tt.want {
+			t.Errorf("InTimeWindow(%d, %d, %d) = %v, want %v", tt.m, tt.from, tt.to, got, tt.want)
+		}
+	}
+	for _, s := range []string{"22:30", "25:00-07:00", "after midnight"} {
+		if _, _, err := ParseTimeWindow(s); err == nil {
+			t.Errorf("ParseTimeWindow(%q) should fail", s)
+		}
+	}
+}
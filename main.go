@@ -2,7 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
 
 	"abb_ia/cmd"
@@ -20,6 +22,7 @@ func main() {
 	useMock := flag.Bool("mock-load", false, "Use mock data")
 	saveMock := flag.Bool("mock-save", false, "Save mock data")
 	help := flag.Bool("help", false, "Display usage information")
+	flag.Usage = usage
 	flag.Parse()
 
 	// get IA search condition from command line if specified
@@ -31,6 +34,37 @@ func main() {
 		os.Exit(0)
 	}
 
+	// headless build: abb_ia build <identifier|details URL>
+	buildItem := ""
+	if searchCondition == "build" {
+		buildItem = flag.Arg(1)
+		if buildItem == "" {
+			flag.Usage()
+			os.Exit(2)
+		}
+		searchCondition = ""
+	}
+
+	// headless watch check: abb_ia watch [interval hours]
+	watchInterval := -1
+	if searchCondition == "watch" {
+		watchInterval = 0
+		if flag.Arg(1) != "" {
+			hours, err := strconv.Atoi(flag.Arg(1))
+			if err != nil || hours < 0 {
+				flag.Usage()
+				os.Exit(2)
+			}
+			watchInterval = hours
+		}
+		searchCondition = ""
+	}
+
+	// local fake archive.org: abb_ia fakeia [fixtures.json]
+	if searchCondition == "fakeia" {
+		os.Exit(cmd.ExecuteFakeIA(flag.Arg(1)))
+	}
+
 	// save runtime configuration
 	if searchCondition != "" {
 		condition := strings.Split(searchCondition, " - ")
@@ -50,5 +84,22 @@ func main() {
 	}
 
 	logger.Init(config.Instance().GetLogFileName(), config.Instance().GetLogLevel())
+	if buildItem != "" {
+		os.Exit(cmd.ExecuteBuild(buildItem))
+	}
+	if watchInterval >= 0 {
+		os.Exit(cmd.ExecuteWatch(watchInterval))
+	}
 	cmd.Execute()
 }
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage:\n")
+	fmt.Fprintf(out, "  %s [flags] [\"Author - Title\"]               start the TUI\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] build <identifier|details URL>   build an audiobook without the TUI\n", os.Args[0])
+	fmt.Fprintf(out, "  %s [flags] watch [hours]                    check the watches and build the new items (every N hours)\n", os.Args[0])
+	fmt.Fprintf(out, "  %s fakeia [fixtures.json]                   run a local fake archive.org for testing\n", os.Args[0])
+	fmt.Fprintf(out, "Flags:\n")
+	flag.PrintDefaults()
+}